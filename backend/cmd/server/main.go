@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
@@ -65,23 +66,73 @@ func main() {
 	// Initialize repositories
 	userRepo := repositories.NewUserRepository(db)
 	alertRepo := repositories.NewAlertRepository(db)
+	newsSourceRepo := repositories.NewNewsSourceRepository(db)
+	articleRepo := repositories.NewArticleRepository(db)
+	notificationRepo := repositories.NewNotificationRepository(db)
 
 	// Initialize services
 	authService := services.NewAuthService(userRepo, redisClient, cfg.JWTSecret)
 	alertService := services.NewAlertService(alertRepo, redisClient)
-	newsService := services.NewNewsService(cfg.NewsAPIKey)
-	notificationService := services.NewNotificationService(cfg.SMSAPIKey)
+	redisCache := cache.NewCache(redisClient)
+	newsProviders, err := services.NewProviderChain(newsProviderConfigs(cfg, newsSourceRepo, redisCache))
+	if err != nil {
+		log.Fatal("Failed to initialize news providers:", err)
+	}
+	newsService := services.NewNewsService(newsProviders, services.NewRanker(newsSourceRepo, cfg.ScoreHalfLife))
+	var urlResolver services.URLResolver
+	if cfg.ResolveCanonicalURLs {
+		urlResolver = services.NewCanonicalLinkResolver(redisCache)
+	}
+	ingestionService := services.NewIngestionService(newsProviders, articleRepo, urlResolver, cfg.IngestionInterval, cfg.ArticleRetention)
+	smsProvider, err := services.NewTwilioProvider(services.TwilioConfig{
+		AccountSID:          cfg.TwilioAccountSID,
+		AuthToken:           cfg.TwilioAuthToken,
+		MessagingServiceSID: cfg.TwilioMessagingSID,
+		FromNumber:          cfg.TwilioFromNumber,
+	})
+	if errors.Is(err, services.ErrSMSNotConfigured) && cfg.Environment == "production" {
+		log.Fatal("Twilio must be configured in production")
+	} else if errors.Is(err, services.ErrSMSNotConfigured) {
+		log.Println("Twilio is not configured; texts will only be logged")
+	} else if err != nil {
+		log.Fatal("Failed to initialize Twilio:", err)
+	}
+	notificationService := services.NewNotificationService(smsProvider)
+	userService := services.NewUserService(userRepo, redisClient, notificationService, cfg.JWTSecret)
+	newsSourceService := services.NewNewsSourceService(newsSourceRepo)
+	seenArticleService := services.NewSeenArticleService(alertRepo, redisClient)
+	alertPreviewService := services.NewAlertPreviewService(newsService, ingestionService)
 
 	// Initialize JWT manager for middleware
 	jwtManager := auth.NewJWTManager(cfg.JWTSecret)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
-	alertHandler := handlers.NewAlertHandler(alertService, authService)
+	userHandler := handlers.NewUserHandler(userService)
+	alertHandler := handlers.NewAlertHandler(alertService, authService, alertPreviewService)
+	newsSourceHandler := handlers.NewNewsSourceHandler(newsSourceService)
 
 	// Initialize background services
-	backgroundService := services.NewBackgroundService(alertService, newsService, notificationService)
+	go ingestionService.Start()
+	var schedulerLeader services.LeaderElection
+	if cfg.LeaderElection {
+		schedulerLeader = services.NewLeaderElection(redisClient, "scheduler", cfg.LeaderLeaseTTL)
+	}
+	backgroundService := services.NewBackgroundService(alertService, newsService, ingestionService, seenArticleService, notificationService, services.SchedulerConfig{
+		Interval:     cfg.SchedulerInterval,
+		MaxCatchUp:   cfg.SchedulerMaxCatchUp,
+		Workers:      cfg.SchedulerWorkers,
+		AlertTimeout: cfg.AlertTimeout,
+	}, schedulerLeader)
+	schedulerHandler := handlers.NewSchedulerHandler(backgroundService)
 	go backgroundService.Start()
+	notificationDispatcher := services.NewNotificationDispatcher(notificationRepo, notificationService, services.DispatcherConfig{
+		Interval:    cfg.NotifyInterval,
+		MaxAttempts: cfg.NotifyMaxAttempts,
+		RetryBase:   cfg.NotifyRetryBase,
+		RetryMax:    cfg.NotifyRetryMax,
+	})
+	go notificationDispatcher.Start()
 
 	// Setup Gin router
 	if cfg.Environment == "production" {
@@ -115,17 +166,42 @@ func main() {
 			auth.POST("/logout", authHandler.Logout)
 		}
 
+		// User routes (protected)
+		users := v1.Group("/users")
+		users.Use(middleware.AuthMiddleware(jwtManager))
+		{
+			users.GET("/me", userHandler.GetMe)
+			users.PUT("/me/quiet-hours", userHandler.UpdateQuietHours)
+			users.POST("/me/phone", userHandler.StartPhoneVerification)
+			users.POST("/me/phone/verify", userHandler.VerifyPhone)
+		}
+
 		// Alert routes (protected)
 		alerts := v1.Group("/alerts")
 		alerts.Use(middleware.AuthMiddleware(jwtManager))
 		{
 			alerts.GET("", alertHandler.GetAlerts)
 			alerts.POST("", alertHandler.CreateAlert)
+			alerts.POST("/preview", alertHandler.PreviewAlert)
 			alerts.PUT("/:id", alertHandler.UpdateAlert)
 			alerts.DELETE("/:id", alertHandler.DeleteAlert)
 			alerts.GET("/history", alertHandler.GetAlertHistory)
+			alerts.GET("/history/:id/explain", alertHandler.ExplainAlertHistory)
 			alerts.POST("/test", alertHandler.TestAlert)
 		}
+
+		// Admin routes (protected, admin only)
+		admin := v1.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(jwtManager), middleware.AdminMiddleware(authService))
+		{
+			admin.GET("/sources", newsSourceHandler.GetSources)
+			admin.POST("/sources", newsSourceHandler.CreateSource)
+			admin.GET("/sources/:id", newsSourceHandler.GetSource)
+			admin.PUT("/sources/:id", newsSourceHandler.UpdateSource)
+			admin.DELETE("/sources/:id", newsSourceHandler.DeleteSource)
+			admin.GET("/alerts/history/:id/explain", alertHandler.ExplainAnyAlertHistory)
+			admin.GET("/scheduler", schedulerHandler.GetState)
+		}
 	}
 
 	// Swagger documentation
@@ -159,6 +235,8 @@ func main() {
 
 	// Stop background services
 	backgroundService.Stop()
+	notificationDispatcher.Stop()
+	ingestionService.Stop()
 
 	// The context is used to inform the server it has 5 seconds to finish
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -168,4 +246,30 @@ func main() {
 	}
 
 	log.Println("Server exiting")
-}
\ No newline at end of file
+}
+
+// newsProviderConfigs turns the NEWS_PROVIDERS chain into provider configs,
+// attaching the settings each provider needs. The RSS provider reads its feeds
+// from the news_sources table unless RSS_FEEDS pins a static list.
+func newsProviderConfigs(cfg *config.Config, newsSourceRepo repositories.NewsSourceRepository, feedCache cache.Cache) []services.ProviderConfig {
+	rssConfig := services.ProviderConfig{Feeds: cfg.RSSFeeds, Cache: feedCache}
+	if len(cfg.RSSFeeds) == 0 {
+		rssConfig.Sources = newsSourceRepo
+	}
+
+	settings := map[string]services.ProviderConfig{
+		services.ProviderTheNewsAPI: {APIKey: cfg.NewsAPIKey},
+		services.ProviderNewsAPI:    {APIKey: cfg.NewsAPIOrgKey},
+		services.ProviderRSS:        rssConfig,
+		services.ProviderFile:       {Dir: cfg.NewsFixturesDir},
+	}
+
+	configs := make([]services.ProviderConfig, 0, len(cfg.NewsProviders))
+	for _, name := range cfg.NewsProviders {
+		providerConfig := settings[name]
+		providerConfig.Name = name
+		configs = append(configs, providerConfig)
+	}
+
+	return configs
+}
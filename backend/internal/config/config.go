@@ -2,29 +2,74 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	Environment   string
-	Port          string
-	DatabaseURL   string
-	RedisURL      string
-	JWTSecret     string
-	NewsAPIKey    string
-	SMSAPIKey     string
-	LogLevel      string
+	Environment          string
+	Port                 string
+	DatabaseURL          string
+	RedisURL             string
+	JWTSecret            string
+	NewsProviders        []string
+	NewsAPIKey           string
+	NewsAPIOrgKey        string
+	RSSFeeds             []string
+	NewsFixturesDir      string
+	IngestionInterval    time.Duration
+	ArticleRetention     time.Duration
+	ResolveCanonicalURLs bool
+	ScoreHalfLife        time.Duration
+	SchedulerInterval    time.Duration
+	SchedulerMaxCatchUp  time.Duration
+	SchedulerWorkers     int
+	AlertTimeout         time.Duration
+	LeaderElection       bool
+	LeaderLeaseTTL       time.Duration
+	NotifyInterval       time.Duration
+	NotifyMaxAttempts    int
+	NotifyRetryBase      time.Duration
+	NotifyRetryMax       time.Duration
+	TwilioAccountSID     string
+	TwilioAuthToken      string
+	TwilioMessagingSID   string
+	TwilioFromNumber     string
+	LogLevel             string
 }
 
 func Load() *Config {
 	return &Config{
-		Environment: getEnv("ENVIRONMENT", "development"),
-		Port:        getEnv("PORT", "8080"),
-		DatabaseURL: getEnv("DATABASE_URL", "root:password@tcp(localhost:3306)/newstotext?charset=utf8mb4&parseTime=True&loc=Local"),
-		RedisURL:    getEnv("REDIS_URL", "redis://localhost:6379/0"),
-		JWTSecret:   getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-this-in-production"),
-		NewsAPIKey:  getEnv("NEWS_API_KEY", ""),
-		SMSAPIKey:   getEnv("SMS_API_KEY", ""),
-		LogLevel:    getEnv("LOG_LEVEL", "info"),
+		Environment:          getEnv("ENVIRONMENT", "development"),
+		Port:                 getEnv("PORT", "8080"),
+		DatabaseURL:          getEnv("DATABASE_URL", "root:password@tcp(localhost:3306)/newstotext?charset=utf8mb4&parseTime=True&loc=Local"),
+		RedisURL:             getEnv("REDIS_URL", "redis://localhost:6379/0"),
+		JWTSecret:            getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-this-in-production"),
+		NewsProviders:        getEnvList("NEWS_PROVIDERS", []string{"thenewsapi", "rss"}),
+		NewsAPIKey:           getEnv("NEWS_API_KEY", ""),
+		NewsAPIOrgKey:        getEnv("NEWSAPI_ORG_KEY", ""),
+		RSSFeeds:             getEnvList("RSS_FEEDS", nil),
+		NewsFixturesDir:      getEnv("NEWS_FIXTURES_DIR", ""),
+		IngestionInterval:    getEnvDuration("INGESTION_INTERVAL", 5*time.Minute),
+		ArticleRetention:     getEnvDuration("ARTICLE_RETENTION", 7*24*time.Hour),
+		ResolveCanonicalURLs: getEnv("RESOLVE_CANONICAL_URLS", "false") == "true",
+		ScoreHalfLife:        getEnvDuration("SCORE_HALF_LIFE", 24*time.Hour),
+		SchedulerInterval:    getEnvDuration("SCHEDULER_INTERVAL", time.Minute),
+		SchedulerMaxCatchUp:  getEnvDuration("SCHEDULER_MAX_CATCH_UP", 6*time.Hour),
+		SchedulerWorkers:     getEnvInt("SCHEDULER_WORKERS", 8),
+		AlertTimeout:         getEnvDuration("ALERT_TIMEOUT", time.Minute),
+		LeaderElection:       getEnv("LEADER_ELECTION", "true") == "true",
+		LeaderLeaseTTL:       getEnvDuration("LEADER_LEASE_TTL", 15*time.Second),
+		NotifyInterval:       getEnvDuration("NOTIFY_DISPATCH_INTERVAL", 10*time.Second),
+		NotifyMaxAttempts:    getEnvInt("NOTIFY_MAX_ATTEMPTS", 5),
+		NotifyRetryBase:      getEnvDuration("NOTIFY_RETRY_BASE", 30*time.Second),
+		NotifyRetryMax:       getEnvDuration("NOTIFY_RETRY_MAX", time.Hour),
+		TwilioAccountSID:     getEnv("TWILIO_ACCOUNT_SID", ""),
+		TwilioAuthToken:      getEnv("TWILIO_AUTH_TOKEN", ""),
+		TwilioMessagingSID:   getEnv("TWILIO_MESSAGING_SERVICE_SID", ""),
+		TwilioFromNumber:     getEnv("TWILIO_FROM_NUMBER", ""),
+		LogLevel:             getEnv("LOG_LEVEL", "info"),
 	}
 }
 
@@ -33,4 +78,36 @@ func getEnv(key, defaultValue string) string {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+// getEnvList reads a comma-separated environment variable
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvDuration reads a positive duration such as "5m" or "168h"
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvInt reads a positive whole number
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
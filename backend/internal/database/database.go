@@ -20,7 +20,11 @@ func Initialize(databaseURL string) (*gorm.DB, error) {
 		&models.User{},
 		&models.Alert{},
 		&models.AlertHistory{},
+		&models.SeenArticle{},
 		&models.NewsSource{},
+		&models.Article{},
+		&models.Notification{},
+		&models.SchedulerLease{},
 	)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,172 @@
+package dedup
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// trackingParams are query parameters that identify a campaign or referrer
+// rather than the article, in addition to every utm_* parameter
+var trackingParams = map[string]bool{
+	"fbclid":     true,
+	"gclid":      true,
+	"dclid":      true,
+	"msclkid":    true,
+	"mc_cid":     true,
+	"mc_eid":     true,
+	"igshid":     true,
+	"ocid":       true,
+	"cmpid":      true,
+	"ncid":       true,
+	"taid":       true,
+	"ref":        true,
+	"ref_src":    true,
+	"smid":       true,
+	"guccounter": true,
+	"_ga":        true,
+	"outputtype": true,
+	"amp":        true,
+}
+
+// CanonicalURL normalizes an article URL so the same page reached through
+// different links compares equal. It lowercases the scheme and host, drops
+// "www." and "amp." host prefixes, default ports, fragments, tracking
+// parameters (utm_* and friends) and AMP path markers, and sorts what is left
+// of the query. URLs that cannot be parsed are returned trimmed but unchanged.
+func CanonicalURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme == "http" {
+		u.Scheme = "https"
+	}
+
+	host := strings.ToLower(u.Hostname())
+	host = strings.TrimPrefix(host, "www.")
+	host = strings.TrimPrefix(host, "amp.")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+	u.Host = host
+
+	u.User = nil
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Path = canonicalPath(u.Path)
+	u.RawPath = ""
+	u.RawQuery = canonicalQuery(u.Query())
+
+	return u.String()
+}
+
+// canonicalPath removes AMP markers such as /amp/, /amp or .amp.html and any
+// trailing slash
+func canonicalPath(path string) string {
+	segments := strings.Split(path, "/")
+	kept := segments[:0]
+	for _, segment := range segments {
+		if strings.EqualFold(segment, "amp") {
+			continue
+		}
+		kept = append(kept, segment)
+	}
+	path = strings.Join(kept, "/")
+
+	lower := strings.ToLower(path)
+	for _, suffix := range []string{".amp.html", ".amp"} {
+		if strings.HasSuffix(lower, suffix) {
+			path = path[:len(path)-len(suffix)]
+			if suffix == ".amp.html" {
+				path += ".html"
+			}
+			break
+		}
+	}
+
+	path = strings.TrimRight(path, "/")
+	return path
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		lower := strings.ToLower(key)
+		if strings.HasPrefix(lower, "utm_") || trackingParams[lower] {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(url.QueryEscape(key))
+			b.WriteByte('=')
+			b.WriteString(url.QueryEscape(value))
+		}
+	}
+	return b.String()
+}
+
+var (
+	linkTagPattern   = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	attributePattern = regexp.MustCompile(`(?is)([a-z-]+)\s*=\s*("[^"]*"|'[^']*'|[^\s>]+)`)
+)
+
+// ExtractCanonicalLink returns the href of the page's <link rel="canonical">,
+// resolved against the page URL, or "" when the page does not declare one
+func ExtractCanonicalLink(page []byte, pageURL string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+
+	for _, tag := range linkTagPattern.FindAll(page, -1) {
+		var rel, href string
+		for _, match := range attributePattern.FindAllSubmatch(tag, -1) {
+			value := strings.Trim(string(match[2]), `"'`)
+			switch strings.ToLower(string(match[1])) {
+			case "rel":
+				rel = value
+			case "href":
+				href = value
+			}
+		}
+
+		if href == "" || !hasToken(rel, "canonical") {
+			continue
+		}
+
+		ref, err := url.Parse(strings.TrimSpace(href))
+		if err != nil {
+			continue
+		}
+		return base.ResolveReference(ref).String()
+	}
+
+	return ""
+}
+
+func hasToken(list, token string) bool {
+	for _, field := range strings.Fields(list) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
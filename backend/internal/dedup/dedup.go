@@ -0,0 +1,94 @@
+// Package dedup detects when articles from different sources are the same
+// story, by canonical URL or by near-duplicate title and description.
+package dedup
+
+import (
+	"news-to-text/internal/models"
+)
+
+type story struct {
+	key          string
+	canonicalURL string
+	fingerprint  Fingerprint
+}
+
+// Index remembers the stories seen so far and finds the one a new article
+// belongs to
+type Index struct {
+	stories []story
+	byURL   map[string]string
+}
+
+func NewIndex() *Index {
+	return &Index{byURL: make(map[string]string)}
+}
+
+// Add records an article under the given story key
+func (idx *Index) Add(key, canonicalURL string, fingerprint Fingerprint) {
+	if canonicalURL != "" {
+		if _, exists := idx.byURL[canonicalURL]; !exists {
+			idx.byURL[canonicalURL] = key
+		}
+	}
+	idx.stories = append(idx.stories, story{key: key, canonicalURL: canonicalURL, fingerprint: fingerprint})
+}
+
+// Find returns the key of the story the article belongs to, matching first on
+// canonical URL and then on fingerprint similarity
+func (idx *Index) Find(canonicalURL string, fingerprint Fingerprint) (string, bool) {
+	if canonicalURL != "" {
+		if key, exists := idx.byURL[canonicalURL]; exists {
+			return key, true
+		}
+	}
+
+	for _, s := range idx.stories {
+		if fingerprint.Matches(s.fingerprint) {
+			return s.key, true
+		}
+	}
+
+	return "", false
+}
+
+// ArticleFingerprint fingerprints an article's title and description
+func ArticleFingerprint(article models.NewsArticle) Fingerprint {
+	return NewFingerprint(article.Title, article.Description)
+}
+
+// Deduplicate keeps one representative per story, the first one in the
+// slice. Articles that share a story key, a canonical URL or a near-duplicate
+// title or description are the same story.
+func Deduplicate(articles []models.NewsArticle) []models.NewsArticle {
+	if len(articles) < 2 {
+		return articles
+	}
+
+	idx := NewIndex()
+	seenKeys := make(map[string]bool)
+	kept := make([]models.NewsArticle, 0, len(articles))
+
+	for _, article := range articles {
+		if article.StoryKey != "" && seenKeys[article.StoryKey] {
+			continue
+		}
+
+		canonicalURL := article.CanonicalURL
+		if canonicalURL == "" && article.URL != "" {
+			canonicalURL = CanonicalURL(article.URL)
+		}
+		fingerprint := ArticleFingerprint(article)
+
+		if _, exists := idx.Find(canonicalURL, fingerprint); exists {
+			continue
+		}
+
+		if article.StoryKey != "" {
+			seenKeys[article.StoryKey] = true
+		}
+		idx.Add(article.StoryKey, canonicalURL, fingerprint)
+		kept = append(kept, article)
+	}
+
+	return kept
+}
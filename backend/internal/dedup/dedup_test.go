@@ -0,0 +1,184 @@
+package dedup
+
+import (
+	"testing"
+
+	"news-to-text/internal/models"
+)
+
+func TestCanonicalURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "utm parameters",
+			input:    "https://www.reuters.com/markets/fed-holds-rates/?utm_source=twitter&utm_medium=social",
+			expected: "https://reuters.com/markets/fed-holds-rates",
+		},
+		{
+			name:     "other tracking parameters and fragment",
+			input:    "https://example.com/story?id=42&fbclid=abc&gclid=def#comments",
+			expected: "https://example.com/story?id=42",
+		},
+		{
+			name:     "query order",
+			input:    "https://example.com/story?b=2&a=1",
+			expected: "https://example.com/story?a=1&b=2",
+		},
+		{
+			name:     "amp path segment",
+			input:    "https://www.cnbc.com/amp/2024/01/02/fed-holds-rates.html",
+			expected: "https://cnbc.com/2024/01/02/fed-holds-rates.html",
+		},
+		{
+			name:     "amp suffix",
+			input:    "https://example.com/news/fed-holds-rates/amp/",
+			expected: "https://example.com/news/fed-holds-rates",
+		},
+		{
+			name:     "amp html extension",
+			input:    "https://example.com/news/fed-holds-rates.amp.html",
+			expected: "https://example.com/news/fed-holds-rates.html",
+		},
+		{
+			name:     "amp host and query flag",
+			input:    "http://amp.example.com/news/story?amp=1&outputType=amp",
+			expected: "https://example.com/news/story",
+		},
+		{
+			name:     "host case and default port",
+			input:    "HTTPS://Example.COM:443/Story",
+			expected: "https://example.com/Story",
+		},
+		{
+			name:     "not a url",
+			input:    " urn:example:1 ",
+			expected: "urn:example:1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanonicalURL(tt.input); got != tt.expected {
+				t.Errorf("Expected %q but got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestExtractCanonicalLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     string
+		expected string
+	}{
+		{
+			name:     "absolute href",
+			page:     `<html><head><link rel="stylesheet" href="/a.css"><link rel="canonical" href="https://example.com/story"></head></html>`,
+			expected: "https://example.com/story",
+		},
+		{
+			name:     "relative href and attribute order",
+			page:     `<head><LINK href='/news/story' REL='canonical' /></head>`,
+			expected: "https://amp.example.com/news/story",
+		},
+		{
+			name:     "no canonical link",
+			page:     `<head><link rel="amphtml" href="https://example.com/amp/story"></head>`,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractCanonicalLink([]byte(tt.page), "https://amp.example.com/amp/story")
+			if got != tt.expected {
+				t.Errorf("Expected %q but got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFingerprint_Matches(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        models.NewsArticle
+		b        models.NewsArticle
+		expected bool
+	}{
+		{
+			name:     "same headline with outlet suffix",
+			a:        models.NewsArticle{Title: "Fed holds interest rates steady, signals cuts later this year - Reuters"},
+			b:        models.NewsArticle{Title: "Fed holds interest rates steady, signals cuts later this year"},
+			expected: true,
+		},
+		{
+			name: "rewritten headline with the same wire lead",
+			a: models.NewsArticle{
+				Title:       "Fed keeps rates unchanged",
+				Description: "The Federal Reserve held its benchmark interest rate steady on Wednesday and signaled it still expects to cut borrowing costs later this year.",
+			},
+			b: models.NewsArticle{
+				Title:       "Powell: cuts still coming",
+				Description: "The Federal Reserve held its benchmark interest rate steady on Wednesday and signaled it still expects to cut borrowing costs later this year, officials said.",
+			},
+			expected: true,
+		},
+		{
+			name:     "one word changes the story",
+			a:        models.NewsArticle{Title: "Apple quarterly earnings beat analyst estimates"},
+			b:        models.NewsArticle{Title: "Apple quarterly earnings miss analyst estimates"},
+			expected: false,
+		},
+		{
+			name:     "unrelated stories",
+			a:        models.NewsArticle{Title: "Tesla recalls 2 million vehicles over Autopilot"},
+			b:        models.NewsArticle{Title: "Bitcoin climbs past $50,000 for the first time since 2021"},
+			expected: false,
+		},
+		{
+			name:     "short generic headlines are not compared",
+			a:        models.NewsArticle{Title: "Live updates"},
+			b:        models.NewsArticle{Title: "Live updates"},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ArticleFingerprint(tt.a).Matches(ArticleFingerprint(tt.b))
+			if got != tt.expected {
+				t.Errorf("Expected %v but got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDeduplicate(t *testing.T) {
+	articles := []models.NewsArticle{
+		{Title: "Fed holds interest rates steady, signals cuts later this year", URL: "https://www.reuters.com/markets/fed?utm_source=rss", Source: "Reuters"},
+		{Title: "Fed holds interest rates steady, signals cuts later this year - CNBC", URL: "https://www.cnbc.com/2024/fed.html", Source: "CNBC"},
+		{Title: "Fed decision: what it means for you", URL: "https://reuters.com/markets/fed#top", Source: "TheNewsAPI"},
+		{Title: "Tesla recalls vehicles over Autopilot", URL: "https://example.com/tesla", StoryKey: "tesla"},
+		{Title: "Tesla Autopilot recall explained", URL: "https://example.com/tesla-explained", StoryKey: "tesla"},
+		{Title: "Bitcoin climbs past $50,000", URL: "https://example.com/bitcoin"},
+	}
+
+	deduplicated := Deduplicate(articles)
+
+	if len(deduplicated) != 3 {
+		for _, article := range deduplicated {
+			t.Logf("Kept: %s", article.Title)
+		}
+		t.Fatalf("Expected 3 stories but got %d", len(deduplicated))
+	}
+
+	if deduplicated[0].Source != "Reuters" {
+		t.Errorf("Expected the first copy to represent the story but got %s", deduplicated[0].Source)
+	}
+	if deduplicated[1].URL != "https://example.com/tesla" || deduplicated[2].URL != "https://example.com/bitcoin" {
+		t.Errorf("Unexpected representatives: %v", deduplicated)
+	}
+}
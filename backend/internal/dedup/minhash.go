@@ -0,0 +1,156 @@
+package dedup
+
+import (
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// signatureSize is the number of hash functions in a MinHash signature.
+	// 64 keeps the similarity estimate within about ±0.06.
+	signatureSize = 64
+
+	// SimilarityThreshold is the estimated Jaccard similarity of word bigrams
+	// above which two titles (or two descriptions) are the same story
+	SimilarityThreshold = 0.6
+
+	// Texts with fewer shingles than this are too short to compare reliably;
+	// "Live updates" must not swallow every other live blog.
+	minTitleShingles       = 3
+	minDescriptionShingles = 8
+)
+
+// stopWords are dropped before shingling so small rewordings between outlets
+// ("the Fed" / "Fed") don't lower the similarity
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "of": true, "to": true, "in": true,
+	"on": true, "for": true, "and": true, "or": true, "at": true, "by": true,
+	"with": true, "as": true, "is": true, "are": true, "was": true, "its": true,
+}
+
+// Signature is a MinHash signature of a set of shingles. A nil signature means
+// the text was too short to fingerprint.
+type Signature []uint64
+
+// MinHash computes the signature of a set of shingles
+func MinHash(shingles []string) Signature {
+	if len(shingles) == 0 {
+		return nil
+	}
+
+	signature := make(Signature, signatureSize)
+	for i := range signature {
+		signature[i] = ^uint64(0)
+	}
+
+	for _, shingle := range shingles {
+		h := fnv.New64a()
+		h.Write([]byte(shingle))
+		base := h.Sum64()
+
+		for i := range signature {
+			if value := mix(base + uint64(i)*0x9e3779b97f4a7c15); value < signature[i] {
+				signature[i] = value
+			}
+		}
+	}
+
+	return signature
+}
+
+// Similarity estimates the Jaccard similarity of the two shingle sets
+func (s Signature) Similarity(other Signature) float64 {
+	if len(s) == 0 || len(s) != len(other) {
+		return 0
+	}
+
+	equal := 0
+	for i := range s {
+		if s[i] == other[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(s))
+}
+
+// Fingerprint holds the MinHash signatures of an article's title and
+// description. Syndicated copies of a wire story usually keep either the
+// headline or the lead paragraph, so the two are compared separately.
+type Fingerprint struct {
+	Title       Signature
+	Description Signature
+}
+
+func NewFingerprint(title, description string) Fingerprint {
+	var fingerprint Fingerprint
+
+	if shingles := Shingles(stripSourceSuffix(title)); len(shingles) >= minTitleShingles {
+		fingerprint.Title = MinHash(shingles)
+	}
+	if shingles := Shingles(description); len(shingles) >= minDescriptionShingles {
+		fingerprint.Description = MinHash(shingles)
+	}
+
+	return fingerprint
+}
+
+// Matches reports whether the two fingerprints belong to the same story
+func (f Fingerprint) Matches(other Fingerprint) bool {
+	return f.Title.Similarity(other.Title) >= SimilarityThreshold ||
+		f.Description.Similarity(other.Description) >= SimilarityThreshold
+}
+
+// Shingles returns the distinct word bigrams of the text, ignoring case,
+// punctuation and stop words
+func Shingles(text string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if len([]rune(word)) < 2 || stopWords[word] {
+			continue
+		}
+		words = append(words, word)
+	}
+
+	if len(words) == 1 {
+		return words
+	}
+
+	seen := make(map[string]bool, len(words))
+	shingles := make([]string, 0, len(words))
+	for i := 0; i+1 < len(words); i++ {
+		shingle := words[i] + " " + words[i+1]
+		if !seen[shingle] {
+			seen[shingle] = true
+			shingles = append(shingles, shingle)
+		}
+	}
+
+	return shingles
+}
+
+// stripSourceSuffix removes a trailing outlet name such as " - Reuters" or
+// " | CNBC" that aggregators append to headlines
+func stripSourceSuffix(title string) string {
+	for _, separator := range []string{" - ", " | ", " — "} {
+		if i := strings.LastIndex(title, separator); i > 0 {
+			if len(strings.Fields(title[i+len(separator):])) <= 4 {
+				return title[:i]
+			}
+		}
+	}
+	return title
+}
+
+// mix is the splitmix64 finalizer, used to derive independent hash functions
+// from a single FNV hash
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
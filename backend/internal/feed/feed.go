@@ -0,0 +1,364 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/pkg/dateparse"
+	"news-to-text/pkg/langdetect"
+)
+
+type Format string
+
+const (
+	FormatRSS  Format = "rss"
+	FormatRDF  Format = "rdf"
+	FormatAtom Format = "atom"
+	FormatJSON Format = "json"
+)
+
+var ErrUnknownFormat = errors.New("unknown feed format")
+
+// Feed is a parsed feed in any supported format
+type Feed struct {
+	Format   Format
+	Title    string
+	Link     string
+	Language string
+	TTL      time.Duration
+	Articles []models.NewsArticle
+}
+
+// Detect sniffs the feed format from the document root
+func Detect(data []byte) (Format, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimSpace(data)
+
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var probe struct {
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal(trimmed, &probe); err != nil {
+			return "", err
+		}
+		if !strings.Contains(probe.Version, "jsonfeed.org") {
+			return "", ErrUnknownFormat
+		}
+		return FormatJSON, nil
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(trimmed))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return "", ErrUnknownFormat
+			}
+			return "", err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch strings.ToLower(start.Name.Local) {
+		case "rss":
+			return FormatRSS, nil
+		case "rdf":
+			return FormatRDF, nil
+		case "feed":
+			return FormatAtom, nil
+		default:
+			return "", ErrUnknownFormat
+		}
+	}
+}
+
+// Parse detects the format of a feed document and maps its items to articles.
+// Articles get the language declared by the feed, or detected from their
+// text when the feed has none.
+func Parse(data []byte) (*Feed, error) {
+	format, err := Detect(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var feed *Feed
+	switch format {
+	case FormatRSS:
+		feed, err = parseRSS(data)
+	case FormatRDF:
+		feed, err = parseRDF(data)
+	case FormatAtom:
+		feed, err = parseAtom(data)
+	case FormatJSON:
+		feed, err = parseJSON(data)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range feed.Articles {
+		setLanguage(&feed.Articles[i], feed.Language)
+	}
+	return feed, nil
+}
+
+// setLanguage splits the article's language tag, which only Atom entries
+// carry, or else the feed's into a language and locale, and detects the
+// language from the text when neither declares one
+func setLanguage(article *models.NewsArticle, feedLanguage string) {
+	tag := article.Language
+	if tag == "" {
+		tag = feedLanguage
+	}
+
+	article.Language, article.Locale = langdetect.ParseTag(tag)
+	if article.Language == "" {
+		article.Language = langdetect.Detect(article.Title + "\n" + article.Description)
+	}
+}
+
+func unmarshalXML(data []byte, v interface{}) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		// Go only decodes UTF-8; most feeds declaring Latin-1 are ASCII in practice
+		return input, nil
+	}
+	return decoder.Decode(v)
+}
+
+func parseRSS(data []byte) (*Feed, error) {
+	var rss models.RSSFeed
+	if err := unmarshalXML(data, &rss); err != nil {
+		return nil, err
+	}
+
+	channel := rss.Channel
+	feed := &Feed{
+		Format:   FormatRSS,
+		Title:    clean(channel.Title),
+		Link:     firstNonEmpty(channel.Links...),
+		Language: clean(channel.Language),
+	}
+	if channel.TTL > 0 {
+		feed.TTL = time.Duration(channel.TTL) * time.Minute
+	}
+
+	for _, item := range channel.Items {
+		article := models.NewsArticle{
+			Title:       clean(item.Title),
+			URL:         clean(firstNonEmpty(item.Links...)),
+			Source:      feed.Title,
+			Description: clean(firstNonEmpty(item.Description, item.ContentEncoded)),
+			PublishedAt: parseDate(firstNonEmpty(item.PubDate, item.DCDate)),
+			Author:      clean(firstNonEmpty(item.Creator, item.Author)),
+			Categories:  cleanAll(item.Categories),
+			GUID:        clean(item.GUID),
+			ImageURL:    rssImage(item),
+		}
+		if article.URL == "" && strings.HasPrefix(article.GUID, "http") {
+			article.URL = article.GUID
+		}
+		feed.Articles = append(feed.Articles, article)
+	}
+
+	return feed, nil
+}
+
+func rssImage(item models.RSSItem) string {
+	for _, enclosure := range item.Enclosures {
+		if strings.HasPrefix(enclosure.Type, "image/") {
+			return enclosure.URL
+		}
+	}
+	for _, media := range item.MediaContent {
+		if media.Medium == "image" || strings.HasPrefix(media.Type, "image/") {
+			return media.URL
+		}
+	}
+	for _, media := range item.MediaThumbnail {
+		if media.URL != "" {
+			return media.URL
+		}
+	}
+	return ""
+}
+
+func parseRDF(data []byte) (*Feed, error) {
+	var rdf models.RDFFeed
+	if err := unmarshalXML(data, &rdf); err != nil {
+		return nil, err
+	}
+
+	feed := &Feed{
+		Format:   FormatRDF,
+		Title:    clean(rdf.Channel.Title),
+		Link:     clean(rdf.Channel.Link),
+		Language: clean(rdf.Channel.Language),
+	}
+
+	for _, item := range rdf.Items {
+		feed.Articles = append(feed.Articles, models.NewsArticle{
+			Title:       clean(item.Title),
+			URL:         clean(firstNonEmpty(item.Link, item.About)),
+			Source:      feed.Title,
+			Description: clean(item.Description),
+			PublishedAt: parseDate(item.Date),
+			Author:      clean(item.Creator),
+			Categories:  cleanAll(item.Subjects),
+			GUID:        clean(firstNonEmpty(item.About, item.Link)),
+		})
+	}
+
+	return feed, nil
+}
+
+func parseAtom(data []byte) (*Feed, error) {
+	var atom models.AtomFeed
+	if err := unmarshalXML(data, &atom); err != nil {
+		return nil, err
+	}
+
+	feed := &Feed{
+		Format:   FormatAtom,
+		Title:    clean(atom.Title.Value),
+		Link:     atomLink(atom.Links, "alternate"),
+		Language: clean(atom.Lang),
+	}
+
+	for _, entry := range atom.Entries {
+		var author string
+		if len(entry.Authors) > 0 {
+			author = entry.Authors[0].Name
+		}
+
+		var categories []string
+		for _, category := range entry.Categories {
+			categories = append(categories, firstNonEmpty(category.Label, category.Term))
+		}
+
+		var image string
+		for _, link := range entry.Links {
+			if link.Rel == "enclosure" && strings.HasPrefix(link.Type, "image/") {
+				image = link.Href
+				break
+			}
+		}
+
+		feed.Articles = append(feed.Articles, models.NewsArticle{
+			Title:       clean(entry.Title.Value),
+			URL:         atomLink(entry.Links, "alternate"),
+			Source:      feed.Title,
+			Description: clean(firstNonEmpty(entry.Summary.Value, entry.Content.Value)),
+			PublishedAt: parseDate(firstNonEmpty(entry.Published, entry.Updated)),
+			Author:      clean(author),
+			Categories:  cleanAll(categories),
+			GUID:        clean(entry.ID),
+			ImageURL:    image,
+			Language:    clean(entry.Lang),
+		})
+	}
+
+	return feed, nil
+}
+
+// atomLink picks the link with the given rel, treating a missing rel as
+// "alternate" as the Atom spec does
+func atomLink(links []models.AtomLink, rel string) string {
+	for _, link := range links {
+		linkRel := link.Rel
+		if linkRel == "" {
+			linkRel = "alternate"
+		}
+		if linkRel == rel {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	if len(links) > 0 {
+		return strings.TrimSpace(links[0].Href)
+	}
+	return ""
+}
+
+func parseJSON(data []byte) (*Feed, error) {
+	var jsonFeed models.JSONFeed
+	if err := json.Unmarshal(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), &jsonFeed); err != nil {
+		return nil, err
+	}
+
+	feed := &Feed{
+		Format:   FormatJSON,
+		Title:    clean(jsonFeed.Title),
+		Link:     jsonFeed.HomePageURL,
+		Language: clean(jsonFeed.Language),
+	}
+
+	for _, item := range jsonFeed.Items {
+		var author string
+		if len(item.Authors) > 0 {
+			author = item.Authors[0].Name
+		} else if item.Author != nil {
+			author = item.Author.Name
+		}
+
+		feed.Articles = append(feed.Articles, models.NewsArticle{
+			Title:       clean(item.Title),
+			URL:         clean(firstNonEmpty(item.URL, item.ExternalURL)),
+			Source:      feed.Title,
+			Description: clean(firstNonEmpty(item.Summary, item.ContentText, item.ContentHTML)),
+			PublishedAt: parseDate(firstNonEmpty(item.DatePublished, item.DateModified)),
+			Author:      clean(author),
+			Categories:  cleanAll(item.Tags),
+			GUID:        item.ID,
+			ImageURL:    firstNonEmpty(item.Image, item.BannerImage),
+		})
+	}
+
+	return feed, nil
+}
+
+// parseDate returns the zero time for missing or unrecognized dates; callers
+// decide how to fill them in
+func parseDate(value string) time.Time {
+	t, err := dateparse.Parse(value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func clean(value string) string {
+	return strings.TrimSpace(value)
+}
+
+func cleanAll(values []string) []string {
+	var cleaned []string
+	for _, value := range values {
+		if value = clean(value); value != "" {
+			cleaned = append(cleaned, value)
+		}
+	}
+	return cleaned
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,245 @@
+package feed
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"news-to-text/internal/models"
+)
+
+func loadFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture %s: %v", name, err)
+	}
+	return data
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name         string
+		fixture      string
+		format       Format
+		title        string
+		articleCount int
+		firstArticle models.NewsArticle
+		expectedTTL  time.Duration
+	}{
+		{
+			name:         "RSS 2.0",
+			fixture:      "rss2.xml",
+			format:       FormatRSS,
+			title:        "Example Tech News",
+			articleCount: 2,
+			expectedTTL:  30 * time.Minute,
+			firstArticle: models.NewsArticle{
+				Title:       "Chipmaker unveils new AI accelerator",
+				URL:         "https://example.com/2024/01/chip?utm_source=rss",
+				Source:      "Example Tech News",
+				Description: "<p>The company said the part ships next quarter.</p>",
+				PublishedAt: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+				ImageURL:    "https://example.com/images/chip.jpg",
+				Author:      "Jane Reporter",
+				Categories:  []string{"Hardware", "AI"},
+				GUID:        "example-1234",
+				Language:    "en",
+				Locale:      "us",
+			},
+		},
+		{
+			name:         "RSS 1.0 (RDF)",
+			fixture:      "rdf.xml",
+			format:       FormatRDF,
+			title:        "Example RDF Journal",
+			articleCount: 1,
+			firstArticle: models.NewsArticle{
+				Title:       "Telescope spots distant galaxy",
+				URL:         "https://rdf.example.org/articles/42",
+				Source:      "Example RDF Journal",
+				Description: "Astronomers report the most distant galaxy yet.",
+				PublishedAt: time.Date(2024, 1, 4, 8, 30, 0, 0, time.UTC),
+				Author:      "Dr. Ada Astronomer",
+				Categories:  []string{"Astronomy", "Science"},
+				GUID:        "https://rdf.example.org/articles/42",
+			},
+		},
+		{
+			name:         "Atom 1.0",
+			fixture:      "atom.xml",
+			format:       FormatAtom,
+			title:        "Example Atom Blog",
+			articleCount: 1,
+			firstArticle: models.NewsArticle{
+				Title:       "Markets close higher",
+				URL:         "https://atom.example.net/markets-close-higher",
+				Source:      "Example Atom Blog",
+				Description: "Stocks ended the session up across sectors.",
+				PublishedAt: time.Date(2024, 1, 5, 11, 0, 0, 0, time.UTC),
+				ImageURL:    "https://atom.example.net/img/markets.jpg",
+				Author:      "Max Markets",
+				Categories:  []string{"Finance", "stocks"},
+				GUID:        "tag:atom.example.net,2024:markets-close-higher",
+				Language:    "en",
+				Locale:      "gb",
+			},
+		},
+		{
+			name:         "JSON Feed 1.1",
+			fixture:      "jsonfeed.json",
+			format:       FormatJSON,
+			title:        "Example JSON Feed",
+			articleCount: 1,
+			firstArticle: models.NewsArticle{
+				Title:       "Robots learn to fold laundry",
+				URL:         "https://json.example.io/posts/robots",
+				Source:      "Example JSON Feed",
+				Description: "Robots folding shirts in a lab demo.",
+				PublishedAt: time.Date(2024, 1, 6, 15, 0, 0, 0, time.UTC),
+				ImageURL:    "https://json.example.io/img/robots.png",
+				Author:      "Riley Robotics",
+				Categories:  []string{"robotics", "AI"},
+				GUID:        "https://json.example.io/posts/robots",
+				Language:    "en", // detected
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := Parse(loadFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if parsed.Format != tt.format {
+				t.Errorf("Expected format %s but got %s", tt.format, parsed.Format)
+			}
+
+			if parsed.Title != tt.title {
+				t.Errorf("Expected title %q but got %q", tt.title, parsed.Title)
+			}
+
+			if parsed.TTL != tt.expectedTTL {
+				t.Errorf("Expected TTL %v but got %v", tt.expectedTTL, parsed.TTL)
+			}
+
+			if len(parsed.Articles) != tt.articleCount {
+				t.Fatalf("Expected %d articles but got %d", tt.articleCount, len(parsed.Articles))
+			}
+
+			got := parsed.Articles[0]
+			if !got.PublishedAt.Equal(tt.firstArticle.PublishedAt) {
+				t.Errorf("Expected published at %v but got %v", tt.firstArticle.PublishedAt, got.PublishedAt)
+			}
+			got.PublishedAt = tt.firstArticle.PublishedAt
+
+			if !reflect.DeepEqual(got, tt.firstArticle) {
+				t.Errorf("Unexpected first article:\n got: %+v\nwant: %+v", got, tt.firstArticle)
+			}
+		})
+	}
+}
+
+func TestParse_RSSFallbacks(t *testing.T) {
+	parsed, err := Parse(loadFixture(t, "rss2.xml"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	article := parsed.Articles[1]
+
+	if article.URL != "https://example.com/2024/01/startup" {
+		t.Errorf("Expected permalink GUID to be used as URL but got %q", article.URL)
+	}
+
+	if article.Author != "editor@example.com (Sam Editor)" {
+		t.Errorf("Expected author element to be used but got %q", article.Author)
+	}
+
+	if article.ImageURL != "https://example.com/images/startup.png" {
+		t.Errorf("Expected media:content image but got %q", article.ImageURL)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		format  Format
+		wantErr bool
+	}{
+		{name: "RSS with BOM", data: "\xef\xbb\xbf<rss version=\"2.0\"><channel/></rss>", format: FormatRSS},
+		{name: "Atom", data: `<feed xmlns="http://www.w3.org/2005/Atom"/>`, format: FormatAtom},
+		{name: "RDF", data: `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"/>`, format: FormatRDF},
+		{name: "JSON Feed", data: `{"version":"https://jsonfeed.org/version/1"}`, format: FormatJSON},
+		{name: "HTML page", data: `<html><body>Not a feed</body></html>`, wantErr: true},
+		{name: "Arbitrary JSON", data: `{"status":"ok"}`, wantErr: true},
+		{name: "Empty", data: ``, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := Detect([]byte(tt.data))
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error but got format %s", format)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if format != tt.format {
+				t.Errorf("Expected format %s but got %s", tt.format, format)
+			}
+		})
+	}
+}
+
+func TestParse_Language(t *testing.T) {
+	rss := []byte(`<rss version="2.0"><channel><title>Mixed</title>
+<item><title>Le président annonce une réforme des retraites</title><description>Le texte sera présenté au Parlement.</description></item>
+<item><title>El Gobierno aprueba la reforma de las pensiones</title></item>
+<item><title>Apple iPhone</title></item>
+</channel></rss>`)
+
+	atom := []byte(`<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="en">
+<title>Bilingual</title>
+<entry xml:lang="fr-CA"><title>Nouvelles du Québec</title></entry>
+<entry><title>News from Toronto</title></entry>
+</feed>`)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected [][2]string
+	}{
+		{"Detected per item", rss, [][2]string{{"fr", ""}, {"es", ""}, {"", ""}}},
+		{"Entry overrides feed", atom, [][2]string{{"fr", "ca"}, {"en", ""}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := Parse(tt.data)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(parsed.Articles) != len(tt.expected) {
+				t.Fatalf("Expected %d articles but got %d", len(tt.expected), len(parsed.Articles))
+			}
+
+			for i, article := range parsed.Articles {
+				if got := [2]string{article.Language, article.Locale}; got != tt.expected[i] {
+					t.Errorf("%q: expected language and locale %v but got %v", article.Title, tt.expected[i], got)
+				}
+			}
+		})
+	}
+}
@@ -1,25 +1,30 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"news-to-text/internal/middleware"
 	"news-to-text/internal/models"
+	"news-to-text/internal/query"
 	"news-to-text/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AlertHandler struct {
-	alertService services.AlertService
-	authService  services.AuthService
+	alertService   services.AlertService
+	authService    services.AuthService
+	previewService services.AlertPreviewService
 }
 
-func NewAlertHandler(alertService services.AlertService, authService services.AuthService) *AlertHandler {
+func NewAlertHandler(alertService services.AlertService, authService services.AuthService, previewService services.AlertPreviewService) *AlertHandler {
 	return &AlertHandler{
-		alertService: alertService,
-		authService:  authService,
+		alertService:   alertService,
+		authService:    authService,
+		previewService: previewService,
 	}
 }
 
@@ -40,7 +45,7 @@ func (h *AlertHandler) GetAlerts(c *gin.Context) {
 		return
 	}
 
-	alerts, err := h.alertService.GetAlerts(userID)
+	alerts, err := h.alertService.GetAlerts(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get alerts"})
 		return
@@ -75,8 +80,11 @@ func (h *AlertHandler) CreateAlert(c *gin.Context) {
 		return
 	}
 
-	alert, err := h.alertService.CreateAlert(userID, &req)
+	alert, err := h.alertService.CreateAlert(c.Request.Context(), userID, &req)
 	if err != nil {
+		if respondInvalidQuery(c, err) || respondInvalidSchedule(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create alert"})
 		return
 	}
@@ -84,6 +92,52 @@ func (h *AlertHandler) CreateAlert(c *gin.Context) {
 	c.JSON(http.StatusCreated, alert)
 }
 
+// PreviewAlert godoc
+// @Summary Preview an alert
+// @Description Run an alert definition over a lookback window and list the articles it would have sent, without creating the alert or sending anything
+// @Tags alerts
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param alert body models.AlertPreviewRequest true "Alert definition, lookback and source"
+// @Success 200 {object} models.AlertPreviewResponse
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 502 {object} map[string]interface{} "News providers unavailable"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /alerts/preview [post]
+func (h *AlertHandler) PreviewAlert(c *gin.Context) {
+	if _, exists := middleware.GetUserIDFromContext(c); !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.AlertPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := h.previewService.Preview(c.Request.Context(), &req)
+	if err != nil {
+		if respondInvalidQuery(c, err) {
+			return
+		}
+		if strings.HasPrefix(err.Error(), "lookback must be") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Source == models.PreviewSourceLive {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch news from providers"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to preview alert"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
 // UpdateAlert godoc
 // @Summary Update an alert
 // @Description Update an existing alert for the authenticated user
@@ -119,12 +173,15 @@ func (h *AlertHandler) UpdateAlert(c *gin.Context) {
 		return
 	}
 
-	alert, err := h.alertService.UpdateAlert(userID, uint(alertID), &req)
+	alert, err := h.alertService.UpdateAlert(c.Request.Context(), userID, uint(alertID), &req)
 	if err != nil {
 		if err.Error() == "alert not found" || err.Error() == "unauthorized access to alert" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if respondInvalidQuery(c, err) || respondInvalidSchedule(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update alert"})
 		return
 	}
@@ -158,7 +215,7 @@ func (h *AlertHandler) DeleteAlert(c *gin.Context) {
 		return
 	}
 
-	err = h.alertService.DeleteAlert(userID, uint(alertID))
+	err = h.alertService.DeleteAlert(c.Request.Context(), userID, uint(alertID))
 	if err != nil {
 		if err.Error() == "alert not found" || err.Error() == "unauthorized access to alert" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -188,7 +245,7 @@ func (h *AlertHandler) GetAlertHistory(c *gin.Context) {
 		return
 	}
 
-	history, err := h.alertService.GetAlertHistory(userID)
+	history, err := h.alertService.GetAlertHistory(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get alert history"})
 		return
@@ -197,6 +254,74 @@ func (h *AlertHandler) GetAlertHistory(c *gin.Context) {
 	c.JSON(http.StatusOK, history)
 }
 
+// ExplainAlertHistory godoc
+// @Summary Explain an alert history entry
+// @Description Show which terms matched in which fields and how the score was made up for an article that was sent
+// @Tags alerts
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Alert history ID"
+// @Success 200 {object} models.AlertHistoryExplanation
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Alert history or explanation not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /alerts/history/{id}/explain [get]
+func (h *AlertHandler) ExplainAlertHistory(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	historyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert history ID"})
+		return
+	}
+
+	explanation, err := h.alertService.ExplainAlertHistory(c.Request.Context(), userID, uint(historyID))
+	respondExplanation(c, explanation, err)
+}
+
+// ExplainAnyAlertHistory godoc
+// @Summary Explain any alert history entry
+// @Description Show why an article was sent for any user's alert, for support
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Alert history ID"
+// @Success 200 {object} models.AlertHistoryExplanation
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Alert history or explanation not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/alerts/history/{id}/explain [get]
+func (h *AlertHandler) ExplainAnyAlertHistory(c *gin.Context) {
+	historyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert history ID"})
+		return
+	}
+
+	explanation, err := h.alertService.ExplainAnyAlertHistory(c.Request.Context(), uint(historyID))
+	respondExplanation(c, explanation, err)
+}
+
+func respondExplanation(c *gin.Context, explanation *models.AlertHistoryExplanation, err error) {
+	if err != nil {
+		if err.Error() == "alert history not found" || err.Error() == "no explanation recorded for this alert history" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to explain alert history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, explanation)
+}
+
 // TestAlert godoc
 // @Summary Test an alert
 // @Description Send a test notification for an alert
@@ -224,7 +349,7 @@ func (h *AlertHandler) TestAlert(c *gin.Context) {
 		return
 	}
 
-	err := h.alertService.TestAlert(userID, req.AlertID)
+	err := h.alertService.TestAlert(c.Request.Context(), userID, req.AlertID)
 	if err != nil {
 		if err.Error() == "alert not found" || err.Error() == "unauthorized access to alert" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -235,4 +360,29 @@ func (h *AlertHandler) TestAlert(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Test alert sent successfully"})
-}
\ No newline at end of file
+}
+
+// respondInvalidQuery answers 400 when the alert's query or keywords were
+// rejected, including the position of a query syntax error
+func respondInvalidQuery(c *gin.Context, err error) bool {
+	var syntaxErr *query.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "position": syntaxErr.Position})
+		return true
+	}
+	if err.Error() == "alert must have keywords or a query" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return true
+	}
+	return false
+}
+
+// respondInvalidSchedule answers 400 when the alert's cron schedule or time
+// zone was rejected
+func respondInvalidSchedule(c *gin.Context, err error) bool {
+	if strings.HasPrefix(err.Error(), "invalid schedule") || strings.HasPrefix(err.Error(), "invalid time zone") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return true
+	}
+	return false
+}
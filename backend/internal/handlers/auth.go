@@ -38,7 +38,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	user, token, err := h.authService.Register(&req)
+	user, token, err := h.authService.Register(c.Request.Context(), &req)
 	if err != nil {
 		if err.Error() == "user already exists" {
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
@@ -73,7 +73,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	user, token, err := h.authService.Login(&req)
+	user, token, err := h.authService.Login(c.Request.Context(), &req)
 	if err != nil {
 		if err.Error() == "invalid credentials" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
@@ -108,7 +108,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	// Extract token from "Bearer <token>"
 	token := authHeader[7:] // Remove "Bearer " prefix
 
-	if err := h.authService.Logout(token); err != nil {
+	if err := h.authService.Logout(c.Request.Context(), token); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
 		return
 	}
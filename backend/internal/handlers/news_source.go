@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NewsSourceHandler struct {
+	sourceService services.NewsSourceService
+}
+
+func NewNewsSourceHandler(sourceService services.NewsSourceService) *NewsSourceHandler {
+	return &NewsSourceHandler{
+		sourceService: sourceService,
+	}
+}
+
+// GetSources godoc
+// @Summary List news sources
+// @Description Get all configured news sources (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.NewsSource
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/sources [get]
+func (h *NewsSourceHandler) GetSources(c *gin.Context) {
+	sources, err := h.sourceService.GetSources(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get news sources"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sources)
+}
+
+// GetSource godoc
+// @Summary Get a news source
+// @Description Get a single news source by ID (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "News source ID"
+// @Success 200 {object} models.NewsSource
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "News source not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/sources/{id} [get]
+func (h *NewsSourceHandler) GetSource(c *gin.Context) {
+	sourceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid news source ID"})
+		return
+	}
+
+	source, err := h.sourceService.GetSourceByID(c.Request.Context(), uint(sourceID))
+	if err != nil {
+		if err.Error() == "news source not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get news source"})
+		return
+	}
+
+	c.JSON(http.StatusOK, source)
+}
+
+// CreateSource godoc
+// @Summary Create a news source
+// @Description Add a news source used for ingestion (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param source body models.NewsSourceCreateRequest true "News source data"
+// @Success 201 {object} models.NewsSource
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/sources [post]
+func (h *NewsSourceHandler) CreateSource(c *gin.Context) {
+	var req models.NewsSourceCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	source, err := h.sourceService.CreateSource(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create news source"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, source)
+}
+
+// UpdateSource godoc
+// @Summary Update a news source
+// @Description Update, disable or re-categorize a news source (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "News source ID"
+// @Param source body models.NewsSourceUpdateRequest true "News source update data"
+// @Success 200 {object} models.NewsSource
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "News source not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/sources/{id} [put]
+func (h *NewsSourceHandler) UpdateSource(c *gin.Context) {
+	sourceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid news source ID"})
+		return
+	}
+
+	var req models.NewsSourceUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	source, err := h.sourceService.UpdateSource(c.Request.Context(), uint(sourceID), &req)
+	if err != nil {
+		if err.Error() == "news source not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update news source"})
+		return
+	}
+
+	c.JSON(http.StatusOK, source)
+}
+
+// DeleteSource godoc
+// @Summary Delete a news source
+// @Description Remove a news source (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Param id path int true "News source ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "News source not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/sources/{id} [delete]
+func (h *NewsSourceHandler) DeleteSource(c *gin.Context) {
+	sourceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid news source ID"})
+		return
+	}
+
+	if err := h.sourceService.DeleteSource(c.Request.Context(), uint(sourceID)); err != nil {
+		if err.Error() == "news source not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete news source"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
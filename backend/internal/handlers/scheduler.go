@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"news-to-text/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SchedulerHandler struct {
+	backgroundService services.BackgroundService
+}
+
+func NewSchedulerHandler(backgroundService services.BackgroundService) *SchedulerHandler {
+	return &SchedulerHandler{
+		backgroundService: backgroundService,
+	}
+}
+
+// GetState godoc
+// @Summary Inspect the alert scheduler
+// @Description Show the scheduler's interval and catch-up window, what its last pass did, how many alerts are due and which run next
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.SchedulerState
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/scheduler [get]
+func (h *SchedulerHandler) GetState(c *gin.Context) {
+	state, err := h.backgroundService.State(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get scheduler state"})
+		return
+	}
+
+	c.JSON(http.StatusOK, state)
+}
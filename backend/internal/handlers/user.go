@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"news-to-text/internal/middleware"
+	"news-to-text/internal/models"
+	"news-to-text/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserHandler struct {
+	userService services.UserService
+}
+
+func NewUserHandler(userService services.UserService) *UserHandler {
+	return &UserHandler{
+		userService: userService,
+	}
+}
+
+// GetMe godoc
+// @Summary Get the current user
+// @Description Get the authenticated user's account and notification settings
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.UserResponse
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me [get]
+func (h *UserHandler) GetMe(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	user, err := h.userService.GetProfile(c.Request.Context(), userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateQuietHours godoc
+// @Summary Set quiet hours
+// @Description Set the daily window, in the user's time zone, during which notifications are held and sent together when it ends. Empty start and end times turn quiet hours off.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param quiet_hours body models.QuietHoursUpdateRequest true "Quiet hours"
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/quiet-hours [put]
+func (h *UserHandler) UpdateQuietHours(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.QuietHoursUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userService.UpdateQuietHours(c.Request.Context(), userID, &req)
+	if err != nil {
+		switch {
+		case err.Error() == "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "invalid quiet hours") || strings.HasPrefix(err.Error(), "invalid time zone"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update quiet hours"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// StartPhoneVerification godoc
+// @Summary Add a phone number
+// @Description Text a six digit verification code to a phone number in E.164 format. The number is used for notifications once it is verified; no texts are sent until then.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param phone body models.PhoneUpdateRequest true "Phone number"
+// @Success 202 {object} map[string]interface{} "Verification code sent"
+// @Failure 400 {object} map[string]interface{} "Invalid phone number"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 429 {object} map[string]interface{} "Code recently sent"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/phone [post]
+func (h *UserHandler) StartPhoneVerification(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.PhoneUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.StartPhoneVerification(c.Request.Context(), userID, &req); err != nil {
+		switch {
+		case err.Error() == "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "invalid phone number"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "verification code recently sent":
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification code"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification code sent"})
+}
+
+// VerifyPhone godoc
+// @Summary Verify a phone number
+// @Description Confirm the phone number with the code texted to it. The code expires after ten minutes and five wrong attempts.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param code body models.PhoneVerifyRequest true "Verification code"
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} map[string]interface{} "Invalid or expired code"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 429 {object} map[string]interface{} "Too many attempts"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/phone/verify [post]
+func (h *UserHandler) VerifyPhone(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.PhoneVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userService.VerifyPhone(c.Request.Context(), userID, &req)
+	if err != nil {
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "invalid verification code", "no phone verification pending":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "too many verification attempts":
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify phone number"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+
+	"news-to-text/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware only lets administrators through. It must run after
+// AuthMiddleware so the user ID is available on the context.
+func AdminMiddleware(authService services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := GetUserIDFromContext(c)
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			c.Abort()
+			return
+		}
+
+		user, err := authService.GetUserByID(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			c.Abort()
+			return
+		}
+
+		if !user.IsAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
-	"time"
 	"gorm.io/gorm"
+	"time"
 )
 
 type AlertFrequency string
@@ -14,6 +14,9 @@ const (
 	FrequencyRealTime AlertFrequency = "realtime"
 	FrequencyHourly   AlertFrequency = "hourly"
 	FrequencyDaily    AlertFrequency = "daily"
+
+	// FrequencyCustom alerts run on their own cron schedule
+	FrequencyCustom AlertFrequency = "custom"
 )
 
 type Keywords []string
@@ -46,6 +49,9 @@ type Alert struct {
 	UserID      uint           `json:"user_id" gorm:"not null;index"`
 	Topic       string         `json:"topic" gorm:"not null"`
 	Keywords    Keywords       `json:"keywords" gorm:"type:json"`
+	Query       string         `json:"query" gorm:"type:text"`
+	Stemming    bool           `json:"stemming" gorm:"not null;default:false"`
+	MinScore    float64        `json:"min_score" gorm:"not null;default:0"`
 	Frequency   AlertFrequency `json:"frequency" gorm:"not null;default:'daily'"`
 	Active      bool           `json:"active" gorm:"not null;default:true"`
 	LastChecked *time.Time     `json:"last_checked"`
@@ -53,6 +59,27 @@ type Alert struct {
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 
+	// Schedule is a cron expression evaluated in TimeZone, an IANA zone
+	// such as "Europe/Berlin"; daily alerts without one run at 9 AM in it.
+	// NextRunAt is when the scheduler will next run a scheduled alert.
+	Schedule  string     `json:"schedule" gorm:"size:100"`
+	TimeZone  string     `json:"time_zone" gorm:"size:64"`
+	NextRunAt *time.Time `json:"next_run_at" gorm:"index"`
+
+	// Urgent alerts are sent straight away even in their owner's quiet hours
+	Urgent bool `json:"urgent" gorm:"not null;default:false"`
+
+	// Filters applied on top of the query: articles containing an excluded
+	// keyword are dropped, sources are matched by name or domain, and when
+	// categories, languages or locales are set an article must be in one of
+	// them
+	ExcludeKeywords Keywords `json:"exclude_keywords" gorm:"type:json"`
+	AllowedSources  Keywords `json:"allowed_sources" gorm:"type:json"`
+	BlockedSources  Keywords `json:"blocked_sources" gorm:"type:json"`
+	Categories      Keywords `json:"categories" gorm:"type:json"`
+	Languages       Keywords `json:"languages" gorm:"type:json"`
+	Locales         Keywords `json:"locales" gorm:"type:json"`
+
 	// Relationships
 	User         User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	AlertHistory []AlertHistory `json:"alert_history,omitempty" gorm:"foreignKey:AlertID"`
@@ -64,15 +91,41 @@ type AlertHistory struct {
 	NewsTitle  string    `json:"news_title" gorm:"not null"`
 	NewsURL    string    `json:"news_url" gorm:"not null"`
 	NewsSource string    `json:"news_source"`
+	StoryKey   string    `json:"story_key,omitempty" gorm:"size:64;index"`
 	SentAt     time.Time `json:"sent_at"`
 	Success    bool      `json:"success" gorm:"not null;default:false"`
 	ErrorMsg   string    `json:"error_msg"`
 	CreatedAt  time.Time `json:"created_at"`
 
+	// Held notifications were produced in the user's quiet hours and wait to
+	// be sent with the others held until HeldUntil. Once sent, Held is
+	// cleared and HeldUntil kept to show the notification was deferred.
+	Held      bool       `json:"held" gorm:"not null;default:false;index:idx_alert_histories_held"`
+	HeldUntil *time.Time `json:"held_until,omitempty" gorm:"index:idx_alert_histories_held"`
+
+	// NotificationID is the outbox entry that sends the article. Success,
+	// ErrorMsg and Attempts follow its delivery attempts, and SentAt is when
+	// it went out.
+	NotificationID *uint `json:"notification_id,omitempty" gorm:"index"`
+	Attempts       int   `json:"attempts" gorm:"not null;default:0"`
+
+	// Explanation is served by the explain endpoint rather than the history list
+	Explanation *MatchExplanation `json:"-" gorm:"type:json"`
+
 	// Relationships
 	Alert Alert `json:"alert,omitempty" gorm:"foreignKey:AlertID"`
 }
 
+// SeenArticle is an entry in an alert's seen-set: a story that has been
+// delivered for the alert and must not be sent again
+type SeenArticle struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	AlertID        uint      `json:"alert_id" gorm:"not null;uniqueIndex:idx_seen_articles_alert_story"`
+	StoryKey       string    `json:"story_key" gorm:"size:64;not null;uniqueIndex:idx_seen_articles_alert_story"`
+	AlertHistoryID uint      `json:"alert_history_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
 type NewsSource struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
 	Name        string         `json:"name" gorm:"not null"`
@@ -81,44 +134,168 @@ type NewsSource struct {
 	APIEndpoint string         `json:"api_endpoint"`
 	Active      bool           `json:"active" gorm:"not null;default:true"`
 	Category    string         `json:"category"`
+	Weight      float64        `json:"weight" gorm:"not null;default:1"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+type NewsSourceCreateRequest struct {
+	Name        string `json:"name" binding:"required"`
+	URL         string `json:"url" binding:"required,url"`
+	RSSFeedURL  string `json:"rss_feed_url" binding:"omitempty,url"`
+	APIEndpoint string `json:"api_endpoint" binding:"omitempty,url"`
+	Category    string `json:"category"`
+	Active      *bool  `json:"active,omitempty"`
+
+	// Weight scales the score of articles from this source when ranking
+	// matches, 1 when not set
+	Weight *float64 `json:"weight,omitempty" binding:"omitempty,gt=0,lte=10"`
+}
+
+type NewsSourceUpdateRequest struct {
+	Name        *string  `json:"name,omitempty"`
+	URL         *string  `json:"url,omitempty" binding:"omitempty,url"`
+	RSSFeedURL  *string  `json:"rss_feed_url,omitempty" binding:"omitempty,url"`
+	APIEndpoint *string  `json:"api_endpoint,omitempty" binding:"omitempty,url"`
+	Category    *string  `json:"category,omitempty"`
+	Active      *bool    `json:"active,omitempty"`
+	Weight      *float64 `json:"weight,omitempty" binding:"omitempty,gt=0,lte=10"`
+}
+
+// AlertMatchRequest holds the fields that decide which articles an alert
+// matches. It is shared by the requests that create and preview an alert.
+type AlertMatchRequest struct {
+	Keywords        []string `json:"keywords" binding:"required_without=Query"`
+	Query           string   `json:"query,omitempty"`
+	Stemming        bool     `json:"stemming,omitempty"`
+	MinScore        float64  `json:"min_score,omitempty" binding:"gte=0,lte=100"`
+	ExcludeKeywords []string `json:"exclude_keywords,omitempty"`
+	AllowedSources  []string `json:"allowed_sources,omitempty"`
+	BlockedSources  []string `json:"blocked_sources,omitempty"`
+	Categories      []string `json:"categories,omitempty"`
+	Languages       []string `json:"languages,omitempty" binding:"omitempty,dive,len=2,alpha"`
+	Locales         []string `json:"locales,omitempty" binding:"omitempty,dive,len=2,alpha"`
+}
+
+// AlertCreateRequest needs keywords, a query, or both. When a query is given
+// it decides which articles match, e.g. (Apple OR title:iPhone) NOT "rumor".
+// Stemming matches English words by their stem, so "recall" finds "recalled".
+// MinScore drops matches ranked below it, on a scale of 0 to 100.
+// ExcludeKeywords drops articles containing any of them, so a "Tesla" alert
+// can exclude "Nikola Tesla". Sources are names or domains such as
+// "reuters.com"; only allowed sources are kept when any are given.
+// Languages are ISO 639-1 codes such as "en" and locales country codes such
+// as "us". Schedule is a cron expression such as "0 7 * * mon-fri", run in
+// TimeZone; an alert with a schedule needs no frequency. Urgent alerts are
+// sent during the user's quiet hours rather than held until they end.
 type AlertCreateRequest struct {
+	AlertMatchRequest
 	Topic     string         `json:"topic" binding:"required"`
-	Keywords  []string       `json:"keywords" binding:"required,min=1"`
-	Frequency AlertFrequency `json:"frequency" binding:"required,oneof=realtime hourly daily"`
+	Frequency AlertFrequency `json:"frequency" binding:"required_without=Schedule,omitempty,oneof=realtime hourly daily"`
+	Schedule  string         `json:"schedule,omitempty"`
+	TimeZone  string         `json:"time_zone,omitempty"`
+	Urgent    bool           `json:"urgent,omitempty"`
 }
 
 type AlertUpdateRequest struct {
-	Topic     *string         `json:"topic,omitempty"`
-	Keywords  *[]string       `json:"keywords,omitempty"`
-	Frequency *AlertFrequency `json:"frequency,omitempty"`
-	Active    *bool           `json:"active,omitempty"`
+	Topic           *string         `json:"topic,omitempty"`
+	Keywords        *[]string       `json:"keywords,omitempty"`
+	Query           *string         `json:"query,omitempty"`
+	Stemming        *bool           `json:"stemming,omitempty"`
+	MinScore        *float64        `json:"min_score,omitempty" binding:"omitempty,gte=0,lte=100"`
+	Frequency       *AlertFrequency `json:"frequency,omitempty" binding:"omitempty,oneof=realtime hourly daily"`
+	Schedule        *string         `json:"schedule,omitempty"`
+	TimeZone        *string         `json:"time_zone,omitempty"`
+	Urgent          *bool           `json:"urgent,omitempty"`
+	Active          *bool           `json:"active,omitempty"`
+	ExcludeKeywords *[]string       `json:"exclude_keywords,omitempty"`
+	AllowedSources  *[]string       `json:"allowed_sources,omitempty"`
+	BlockedSources  *[]string       `json:"blocked_sources,omitempty"`
+	Categories      *[]string       `json:"categories,omitempty"`
+	Languages       *[]string       `json:"languages,omitempty" binding:"omitempty,dive,len=2,alpha"`
+	Locales         *[]string       `json:"locales,omitempty" binding:"omitempty,dive,len=2,alpha"`
+}
+
+// Sources an alert preview can match against
+const (
+	PreviewSourceStored = "stored"
+	PreviewSourceLive   = "live"
+)
+
+// AlertPreviewRequest is an alert definition to try out without saving it.
+// Lookback is how far back to look, as a duration such as "24h"; Source
+// chooses between the ingested articles and a live fetch from the providers.
+type AlertPreviewRequest struct {
+	AlertMatchRequest
+	Topic     string         `json:"topic"`
+	Frequency AlertFrequency `json:"frequency,omitempty" binding:"omitempty,oneof=realtime hourly daily"`
+	Lookback  string         `json:"lookback,omitempty"`
+	Source    string         `json:"source,omitempty" binding:"omitempty,oneof=stored live"`
+	Limit     int            `json:"limit,omitempty" binding:"gte=0,lte=100"`
+}
+
+// AlertPreviewResponse lists the articles an alert would have sent, best
+// scored first. Total counts them all, even when Articles is cut at the limit.
+type AlertPreviewResponse struct {
+	Query    string        `json:"query"`
+	Source   string        `json:"source"`
+	Since    time.Time     `json:"since"`
+	Total    int           `json:"total"`
+	Articles []NewsArticle `json:"articles"`
 }
 
 type AlertResponse struct {
-	ID          uint           `json:"id"`
-	Topic       string         `json:"topic"`
-	Keywords    []string       `json:"keywords"`
-	Frequency   AlertFrequency `json:"frequency"`
-	Active      bool           `json:"active"`
-	LastChecked *time.Time     `json:"last_checked"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
+	ID              uint           `json:"id"`
+	Topic           string         `json:"topic"`
+	Keywords        []string       `json:"keywords"`
+	Query           string         `json:"query,omitempty"`
+	Stemming        bool           `json:"stemming"`
+	MinScore        float64        `json:"min_score"`
+	ExcludeKeywords []string       `json:"exclude_keywords,omitempty"`
+	AllowedSources  []string       `json:"allowed_sources,omitempty"`
+	BlockedSources  []string       `json:"blocked_sources,omitempty"`
+	Categories      []string       `json:"categories,omitempty"`
+	Languages       []string       `json:"languages,omitempty"`
+	Locales         []string       `json:"locales,omitempty"`
+	Frequency       AlertFrequency `json:"frequency"`
+	Schedule        string         `json:"schedule,omitempty"`
+	TimeZone        string         `json:"time_zone,omitempty"`
+	NextRunAt       *time.Time     `json:"next_run_at,omitempty"`
+	Urgent          bool           `json:"urgent"`
+	Active          bool           `json:"active"`
+	LastChecked     *time.Time     `json:"last_checked"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
 }
 
 func (a *Alert) ToResponse() *AlertResponse {
+	keywords := []string(a.Keywords)
+	if keywords == nil {
+		keywords = []string{}
+	}
+
 	return &AlertResponse{
-		ID:          a.ID,
-		Topic:       a.Topic,
-		Keywords:    a.Keywords,
-		Frequency:   a.Frequency,
-		Active:      a.Active,
-		LastChecked: a.LastChecked,
-		CreatedAt:   a.CreatedAt,
-		UpdatedAt:   a.UpdatedAt,
+		ID:              a.ID,
+		Topic:           a.Topic,
+		Keywords:        keywords,
+		Query:           a.Query,
+		Stemming:        a.Stemming,
+		MinScore:        a.MinScore,
+		ExcludeKeywords: a.ExcludeKeywords,
+		AllowedSources:  a.AllowedSources,
+		BlockedSources:  a.BlockedSources,
+		Categories:      a.Categories,
+		Languages:       a.Languages,
+		Locales:         a.Locales,
+		Frequency:       a.Frequency,
+		Schedule:        a.Schedule,
+		TimeZone:        a.TimeZone,
+		NextRunAt:       a.NextRunAt,
+		Urgent:          a.Urgent,
+		Active:          a.Active,
+		LastChecked:     a.LastChecked,
+		CreatedAt:       a.CreatedAt,
+		UpdatedAt:       a.UpdatedAt,
 	}
-}
\ No newline at end of file
+}
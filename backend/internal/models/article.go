@@ -0,0 +1,115 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type StringList []string
+
+func (l *StringList) Scan(value interface{}) error {
+	if value == nil {
+		*l = nil
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	}
+
+	return errors.New("cannot scan string list")
+}
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+	return json.Marshal(l)
+}
+
+// Article is a news article stored by the ingestion pipeline. Key is derived
+// from the canonical URL so the same page fetched twice is stored once;
+// StoryKey is shared with near-duplicate copies of the story from other
+// sources.
+type Article struct {
+	ID                  uint       `json:"id" gorm:"primaryKey"`
+	Key                 string     `json:"key" gorm:"column:article_key;size:64;not null;uniqueIndex"`
+	StoryKey            string     `json:"story_key" gorm:"size:64;index"`
+	Title               string     `json:"title" gorm:"not null"`
+	URL                 string     `json:"url" gorm:"not null"`
+	CanonicalURL        string     `json:"canonical_url"`
+	Source              string     `json:"source"`
+	Description         string     `json:"description"`
+	Author              string     `json:"author"`
+	Category            string     `json:"category" gorm:"index"`
+	Categories          StringList `json:"categories" gorm:"type:json"`
+	Language            string     `json:"language" gorm:"size:8;index"`
+	Locale              string     `json:"locale" gorm:"size:8"`
+	ImageURL            string     `json:"image_url"`
+	GUID                string     `json:"guid"`
+	Provider            string     `json:"provider"`
+	PublishedAt         time.Time  `json:"published_at" gorm:"index"`
+	PublishedAtInferred bool       `json:"published_at_inferred" gorm:"not null;default:false"`
+	ProviderRelevance   *float64   `json:"provider_relevance"`
+	CreatedAt           time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+func NewArticle(key, provider string, article NewsArticle) *Article {
+	return &Article{
+		Key:                 key,
+		StoryKey:            article.StoryKey,
+		Title:               article.Title,
+		URL:                 article.URL,
+		CanonicalURL:        article.CanonicalURL,
+		Source:              article.Source,
+		Description:         article.Description,
+		Author:              article.Author,
+		Category:            article.Category,
+		Categories:          StringList(article.Categories),
+		Language:            article.Language,
+		Locale:              article.Locale,
+		ImageURL:            article.ImageURL,
+		GUID:                article.GUID,
+		Provider:            provider,
+		PublishedAt:         article.PublishedAt,
+		PublishedAtInferred: article.PublishedAtInferred,
+		ProviderRelevance:   article.ProviderRelevance,
+	}
+}
+
+func (a *Article) ToNewsArticle() NewsArticle {
+	return NewsArticle{
+		Title:               a.Title,
+		URL:                 a.URL,
+		Source:              a.Source,
+		Description:         a.Description,
+		PublishedAt:         a.PublishedAt,
+		ImageURL:            a.ImageURL,
+		Category:            a.Category,
+		Author:              a.Author,
+		Categories:          a.Categories,
+		Language:            a.Language,
+		Locale:              a.Locale,
+		GUID:                a.GUID,
+		PublishedAtInferred: a.PublishedAtInferred,
+		CanonicalURL:        a.CanonicalURL,
+		StoryKey:            a.storyKey(),
+		ProviderRelevance:   a.ProviderRelevance,
+		IngestedAt:          a.CreatedAt,
+	}
+}
+
+// storyKey falls back to the article's own key for rows stored before story
+// grouping existed
+func (a *Article) storyKey() string {
+	if a.StoryKey != "" {
+		return a.StoryKey
+	}
+	return a.Key
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// MatchExplanation records why an article matched an alert: the query that
+// was evaluated, the terms found in each field and how the score was made up
+type MatchExplanation struct {
+	Query    string         `json:"query"`
+	Stemming bool           `json:"stemming"`
+	Terms    []MatchedTerm  `json:"terms"`
+	Score    ScoreBreakdown `json:"score"`
+}
+
+// MatchedTerm is a query term found in one field of the article, with the
+// text it matched, such as "MacBook" for the term Mac
+type MatchedTerm struct {
+	Term    string   `json:"term"`
+	Field   string   `json:"field"`
+	Hits    int      `json:"hits"`
+	Matched []string `json:"matched"`
+}
+
+// ScoreBreakdown is the components of an article's score, which is
+// 100 × Relevance × Recency × SourceWeight
+type ScoreBreakdown struct {
+	TitleHits         int      `json:"title_hits"`
+	DescriptionHits   int      `json:"description_hits"`
+	OtherHits         int      `json:"other_hits"`
+	TermScore         float64  `json:"term_score"`
+	ProviderRelevance *float64 `json:"provider_relevance,omitempty"`
+	Relevance         float64  `json:"relevance"`
+	AgeHours          float64  `json:"age_hours"`
+	Recency           float64  `json:"recency"`
+	SourceWeight      float64  `json:"source_weight"`
+	Score             float64  `json:"score"`
+}
+
+func (e *MatchExplanation) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, e)
+	case string:
+		return json.Unmarshal([]byte(v), e)
+	}
+
+	return errors.New("cannot scan match explanation")
+}
+
+func (e *MatchExplanation) Value() (driver.Value, error) {
+	if e == nil {
+		return nil, nil
+	}
+	return json.Marshal(e)
+}
+
+// AlertHistoryExplanation answers why a history entry's article was sent
+type AlertHistoryExplanation struct {
+	HistoryID   uint              `json:"history_id"`
+	AlertID     uint              `json:"alert_id"`
+	AlertTopic  string            `json:"alert_topic"`
+	NewsTitle   string            `json:"news_title"`
+	NewsURL     string            `json:"news_url"`
+	NewsSource  string            `json:"news_source"`
+	SentAt      time.Time         `json:"sent_at"`
+	Success     bool              `json:"success"`
+	Explanation *MatchExplanation `json:"explanation"`
+}
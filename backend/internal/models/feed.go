@@ -0,0 +1,142 @@
+package models
+
+// RSS 2.0 feed format
+type RSSFeed struct {
+	Channel RSSChannel `xml:"channel"`
+}
+
+type RSSChannel struct {
+	Title       string    `xml:"title"`
+	Links       []string  `xml:"link"`
+	Description string    `xml:"description"`
+	Language    string    `xml:"language"`
+	TTL         int       `xml:"ttl"`
+	Items       []RSSItem `xml:"item"`
+}
+
+type RSSItem struct {
+	Title          string          `xml:"title"`
+	Links          []string        `xml:"link"`
+	Description    string          `xml:"description"`
+	ContentEncoded string          `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Author         string          `xml:"author"`
+	Creator        string          `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories     []string        `xml:"category"`
+	PubDate        string          `xml:"pubDate"`
+	DCDate         string          `xml:"http://purl.org/dc/elements/1.1/ date"`
+	GUID           string          `xml:"guid"`
+	Enclosures     []RSSEnclosure  `xml:"enclosure"`
+	MediaContent   []MediaResource `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaThumbnail []MediaResource `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// MediaResource is a Media RSS (media:content / media:thumbnail) element
+type MediaResource struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Medium string `xml:"medium,attr"`
+}
+
+// RSS 1.0 (RDF) feed format
+type RDFFeed struct {
+	Channel RDFChannel `xml:"channel"`
+	Items   []RDFItem  `xml:"item"`
+}
+
+type RDFChannel struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	Language    string `xml:"http://purl.org/dc/elements/1.1/ language"`
+}
+
+type RDFItem struct {
+	About       string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Subjects    []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+// Atom 1.0 feed format
+type AtomFeed struct {
+	Lang    string      `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Title   AtomText    `xml:"title"`
+	Links   []AtomLink  `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+type AtomEntry struct {
+	Lang       string         `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	ID         string         `xml:"id"`
+	Title      AtomText       `xml:"title"`
+	Links      []AtomLink     `xml:"link"`
+	Summary    AtomText       `xml:"summary"`
+	Content    AtomText       `xml:"content"`
+	Authors    []AtomPerson   `xml:"author"`
+	Categories []AtomCategory `xml:"category"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+}
+
+type AtomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type AtomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email"`
+}
+
+type AtomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+// JSON Feed 1.1 format (https://www.jsonfeed.org/version/1.1/)
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Language    string         `json:"language"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+type JSONFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	ExternalURL   string           `json:"external_url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	ContentText   string           `json:"content_text"`
+	Summary       string           `json:"summary"`
+	Image         string           `json:"image"`
+	BannerImage   string           `json:"banner_image"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []JSONFeedAuthor `json:"authors"`
+	Author        *JSONFeedAuthor  `json:"author"` // JSON Feed 1.0
+	Tags          []string         `json:"tags"`
+}
+
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
@@ -10,6 +10,36 @@ type NewsArticle struct {
 	PublishedAt time.Time `json:"published_at"`
 	ImageURL    string    `json:"image_url,omitempty"`
 	Category    string    `json:"category,omitempty"`
+	Author      string    `json:"author,omitempty"`
+	Categories  []string  `json:"categories,omitempty"`
+	GUID        string    `json:"guid,omitempty"`
+
+	// Language is an ISO 639-1 code such as "en" and Locale a country code
+	// such as "us", as given by the provider or feed. Language is detected
+	// from the text of feed items that don't declare one.
+	Language string `json:"language,omitempty"`
+	Locale   string `json:"locale,omitempty"`
+
+	// PublishedAtInferred is set when the source gave no usable date and
+	// PublishedAt holds the time the article was fetched instead
+	PublishedAtInferred bool `json:"published_at_inferred,omitempty"`
+
+	// CanonicalURL is URL without tracking parameters or AMP markers, and
+	// StoryKey is shared by every copy of the same story across sources.
+	// Both are filled in by the ingestion pipeline.
+	CanonicalURL string `json:"canonical_url,omitempty"`
+	StoryKey     string `json:"story_key,omitempty"`
+
+	// ProviderRelevance is the provider's own relevance score, when it
+	// gives one, and Score is the rank of the article for an alert
+	ProviderRelevance *float64 `json:"provider_relevance,omitempty"`
+	Score             float64  `json:"score,omitempty"`
+
+	// Explanation is set when matches are explained
+	Explanation *MatchExplanation `json:"explanation,omitempty"`
+
+	// IngestedAt is when a stored article was saved by the ingestion pipeline
+	IngestedAt time.Time `json:"-"`
 }
 
 // Legacy NewsAPI.org response format (kept for RSS fallback)
@@ -60,21 +90,6 @@ type TheNewsAPIArticle struct {
 	PublishedAt string    `json:"published_at"`
 	Source      string    `json:"source"`
 	Categories  []string  `json:"categories"`
-	Relevance   int       `json:"relevance_score"`
+	Relevance   *float64  `json:"relevance_score"` // null unless searching
 	Locale      string    `json:"locale"`
 }
-
-type RSSFeed struct {
-	Title       string    `xml:"title"`
-	Link        string    `xml:"link"`
-	Description string    `xml:"description"`
-	Items       []RSSItem `xml:"item"`
-}
-
-type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
-	GUID        string `xml:"guid"`
-}
\ No newline at end of file
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// NotificationStatus is where a notification is in the outbox
+type NotificationStatus string
+
+const (
+	// NotificationPending notifications wait for their next attempt
+	NotificationPending NotificationStatus = "pending"
+	// NotificationSent notifications have been delivered
+	NotificationSent NotificationStatus = "sent"
+	// NotificationDead notifications failed too many times and are no
+	// longer tried
+	NotificationDead NotificationStatus = "dead"
+)
+
+// Notification is a text in the outbox. It is written in the same
+// transaction as the alert history rows it reports and sent by the
+// dispatcher, which retries failed attempts with a growing delay until
+// NextAttemptAt and dead-letters the notification after too many, or
+// straight away when the SMS gateway refuses it for good. MessageSID is the
+// gateway's ID for the text once it is accepted.
+type Notification struct {
+	ID            uint               `json:"id" gorm:"primaryKey"`
+	UserID        uint               `json:"user_id" gorm:"not null;index"`
+	Message       string             `json:"message" gorm:"type:text;not null"`
+	Status        NotificationStatus `json:"status" gorm:"size:16;not null;default:'pending';index:idx_notifications_status_next_attempt"`
+	Attempts      int                `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time          `json:"next_attempt_at" gorm:"index:idx_notifications_status_next_attempt"`
+	LastError     string             `json:"last_error,omitempty"`
+	MessageSID    string             `json:"message_sid,omitempty" gorm:"column:message_sid;size:64"`
+	SentAt        *time.Time         `json:"sent_at,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
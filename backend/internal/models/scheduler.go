@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// SchedulerState describes the alert scheduler for inspection: how it is
+// configured, whether this replica is the leader that runs alerts, how many
+// alerts wait for a worker, what its last pass did and which alerts run next
+type SchedulerState struct {
+	Running      bool             `json:"running"`
+	Leader       bool             `json:"leader"`
+	Interval     string           `json:"interval"`
+	MaxCatchUp   string           `json:"max_catch_up"`
+	Workers      int              `json:"workers"`
+	AlertTimeout string           `json:"alert_timeout"`
+	Queued       int64            `json:"queued"`
+	LastRun      *SchedulerRun    `json:"last_run,omitempty"`
+	Due          int64            `json:"due"`
+	Upcoming     []ScheduledAlert `json:"upcoming"`
+}
+
+// SchedulerRun counts what one pass of the scheduler did with the alerts it
+// claimed. Skipped alerts missed their run by more than the catch-up window;
+// scheduled ones had no next run yet and were only given one; timed out ones
+// are also counted as failed. Queue lag is how long alerts waited past their
+// due time before a worker picked them up. FencingToken identifies the
+// leadership term the pass ran in.
+type SchedulerRun struct {
+	StartedAt     time.Time `json:"started_at"`
+	FinishedAt    time.Time `json:"finished_at"`
+	FencingToken  int64     `json:"fencing_token,omitempty"`
+	Claimed       int       `json:"claimed"`
+	Run           int       `json:"run"`
+	Failed        int       `json:"failed"`
+	TimedOut      int       `json:"timed_out"`
+	Skipped       int       `json:"skipped"`
+	Scheduled     int       `json:"scheduled"`
+	MaxQueueLagMs int64     `json:"max_queue_lag_ms"`
+	AvgQueueLagMs int64     `json:"avg_queue_lag_ms"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// ScheduledAlert is an alert waiting for its next run
+type ScheduledAlert struct {
+	AlertID     uint           `json:"alert_id"`
+	UserID      uint           `json:"user_id"`
+	Topic       string         `json:"topic"`
+	Frequency   AlertFrequency `json:"frequency"`
+	Schedule    string         `json:"schedule,omitempty"`
+	TimeZone    string         `json:"time_zone,omitempty"`
+	NextRunAt   time.Time      `json:"next_run_at"`
+	LastChecked *time.Time     `json:"last_checked"`
+}
+
+// SchedulerLease holds the highest fencing token a scheduler leader has
+// written under. The scheduler's writes check their token against it, so a
+// replica whose lease ran out can't write after the leader that replaced it.
+type SchedulerLease struct {
+	Name      string    `json:"name" gorm:"primaryKey;size:64"`
+	Token     int64     `json:"token" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
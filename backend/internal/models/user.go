@@ -9,10 +9,24 @@ type User struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
 	Email     string         `json:"email" gorm:"uniqueIndex;not null"`
 	Password  string         `json:"-" gorm:"not null"`
+	IsAdmin   bool           `json:"is_admin" gorm:"not null;default:false"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
+	// Quiet hours are a daily window, as "HH:MM" wall clock times in
+	// TimeZone, during which notifications are held and sent together when
+	// it ends. A window whose end is before its start runs past midnight.
+	QuietHoursStart string `json:"quiet_hours_start" gorm:"size:5"`
+	QuietHoursEnd   string `json:"quiet_hours_end" gorm:"size:5"`
+	TimeZone        string `json:"time_zone" gorm:"size:64"`
+
+	// Phone is the E.164 number notifications are texted to. It is only set
+	// once the user has confirmed it with a code sent to it, and nothing is
+	// texted to the user until then.
+	Phone           string     `json:"phone" gorm:"size:16"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at"`
+
 	// Relationships
 	Alerts []Alert `json:"alerts,omitempty" gorm:"foreignKey:UserID"`
 }
@@ -27,18 +41,55 @@ type UserLoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// QuietHoursUpdateRequest sets the user's quiet hours, e.g. 22:00 to 07:00
+// in "Europe/London". Empty start and end times turn them off.
+type QuietHoursUpdateRequest struct {
+	Start    *string `json:"start" binding:"omitempty,datetime=15:04"`
+	End      *string `json:"end" binding:"omitempty,datetime=15:04"`
+	TimeZone *string `json:"time_zone,omitempty"`
+}
+
+// PhoneUpdateRequest texts a verification code to a phone number in E.164
+// form, e.g. "+14155552671"
+type PhoneUpdateRequest struct {
+	Phone string `json:"phone" binding:"required"`
+}
+
+// PhoneVerifyRequest confirms the phone number with the code texted to it
+type PhoneVerifyRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              uint      `json:"id"`
+	Email           string    `json:"email"`
+	IsAdmin         bool      `json:"is_admin"`
+	QuietHoursStart string    `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   string    `json:"quiet_hours_end,omitempty"`
+	TimeZone        string    `json:"time_zone,omitempty"`
+	Phone           string    `json:"phone,omitempty"`
+	PhoneVerified   bool      `json:"phone_verified"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 func (u *User) ToResponse() *UserResponse {
 	return &UserResponse{
-		ID:        u.ID,
-		Email:     u.Email,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:              u.ID,
+		Email:           u.Email,
+		IsAdmin:         u.IsAdmin,
+		QuietHoursStart: u.QuietHoursStart,
+		QuietHoursEnd:   u.QuietHoursEnd,
+		TimeZone:        u.TimeZone,
+		Phone:           u.Phone,
+		PhoneVerified:   u.HasVerifiedPhone(),
+		CreatedAt:       u.CreatedAt,
+		UpdatedAt:       u.UpdatedAt,
 	}
+}
+
+// HasVerifiedPhone reports whether the user has a confirmed phone number to
+// text notifications to
+func (u *User) HasVerifiedPhone() bool {
+	return u.Phone != "" && u.PhoneVerifiedAt != nil
 }
\ No newline at end of file
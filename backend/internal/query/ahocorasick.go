@@ -0,0 +1,116 @@
+package query
+
+// automaton is an Aho-Corasick automaton over words rather than characters:
+// every pattern is a sequence of words, and a text is scanned once to find
+// every pattern that occurs in it, however many patterns there are
+type automaton struct {
+	vocab map[string]int32
+	next  []map[int32]int32
+	fail  []int32
+	out   [][]int32
+	built bool
+}
+
+func newAutomaton() *automaton {
+	return &automaton{
+		vocab: make(map[string]int32),
+		next:  []map[int32]int32{{}},
+		fail:  []int32{0},
+		out:   [][]int32{nil},
+	}
+}
+
+func (a *automaton) empty() bool {
+	return len(a.next) == 1
+}
+
+// add inserts the words of a pattern, reported as id when found
+func (a *automaton) add(words []string, id int32) {
+	state := int32(0)
+	for _, w := range words {
+		sym, ok := a.vocab[w]
+		if !ok {
+			sym = int32(len(a.vocab))
+			a.vocab[w] = sym
+		}
+
+		nextState, ok := a.next[state][sym]
+		if !ok {
+			nextState = int32(len(a.next))
+			a.next = append(a.next, map[int32]int32{})
+			a.fail = append(a.fail, 0)
+			a.out = append(a.out, nil)
+			a.next[state][sym] = nextState
+		}
+		state = nextState
+	}
+
+	a.out[state] = append(a.out[state], id)
+	a.built = false
+}
+
+// build computes the failure links breadth first, so each state also reports
+// the patterns that end in its longest proper suffix
+func (a *automaton) build() {
+	queue := make([]int32, 0, len(a.next))
+	for _, child := range a.next[0] {
+		a.fail[child] = 0
+		queue = append(queue, child)
+	}
+
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		for sym, child := range a.next[state] {
+			fail := a.fail[state]
+			for fail != 0 {
+				if _, ok := a.next[fail][sym]; ok {
+					break
+				}
+				fail = a.fail[fail]
+			}
+			if target, ok := a.next[fail][sym]; ok && target != child {
+				a.fail[child] = target
+			} else {
+				a.fail[child] = 0
+			}
+
+			a.out[child] = append(a.out[child], a.out[a.fail[child]]...)
+			queue = append(queue, child)
+		}
+	}
+
+	a.built = true
+}
+
+// scan reports the id of every pattern occurrence in words
+func (a *automaton) scan(words []string, emit func(id int32)) {
+	if !a.built {
+		a.build()
+	}
+
+	state := int32(0)
+	for _, w := range words {
+		sym, ok := a.vocab[w]
+		if !ok {
+			state = 0
+			continue
+		}
+
+		for {
+			if nextState, ok := a.next[state][sym]; ok {
+				state = nextState
+				break
+			}
+			if state == 0 {
+				break
+			}
+			state = a.fail[state]
+		}
+
+		for _, id := range a.out[state] {
+			emit(id)
+		}
+	}
+}
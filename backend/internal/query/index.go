@@ -0,0 +1,239 @@
+package query
+
+import (
+	"sort"
+	"strings"
+
+	"news-to-text/internal/models"
+)
+
+// matchMode is how the words of a term are compared with an article
+type matchMode int
+
+const (
+	modeLower matchMode = iota
+	modeStem
+	modeExact
+	modeCount
+)
+
+// fieldMask is a set of fields in which a pattern was found
+type fieldMask uint8
+
+const (
+	maskTitle fieldMask = 1 << iota
+	maskDescription
+	maskSource
+	maskCategory
+)
+
+var indexedFields = []struct {
+	name string
+	mask fieldMask
+}{
+	{FieldTitle, maskTitle},
+	{FieldDescription, maskDescription},
+	{FieldSource, maskSource},
+	{FieldCategory, maskCategory},
+}
+
+func fieldMaskOf(field string) fieldMask {
+	switch field {
+	case FieldTitle:
+		return maskTitle
+	case FieldDescription:
+		return maskDescription
+	case FieldSource:
+		return maskSource
+	case FieldCategory:
+		return maskCategory
+	}
+	return maskTitle | maskDescription
+}
+
+type patternKey struct {
+	mode  matchMode
+	words string
+}
+
+// termKey identifies a term of an indexed query. Queries can share terms,
+// as copies made by WithStemming do, while matching them differently.
+type termKey struct {
+	id   uint
+	term *Term
+}
+
+// Index evaluates many queries against an article at once. The words of
+// every term are compiled into one Aho-Corasick automaton per match mode, so
+// each field of an article is scanned once no matter how many queries there
+// are, and only the queries with a term found in the article are evaluated.
+//
+// An Index is not safe for concurrent use.
+type Index struct {
+	ids      []uint
+	queries  map[uint]*Query
+	patterns map[patternKey]int32
+	terms    map[termKey]int32
+	postings [][]uint
+
+	automata [modeCount]*automaton
+	single   [modeCount]map[string][]int32 // single-word patterns, to match compound word pieces
+}
+
+func NewIndex() *Index {
+	ix := &Index{
+		queries:  make(map[uint]*Query),
+		patterns: make(map[patternKey]int32),
+		terms:    make(map[termKey]int32),
+	}
+	for mode := range ix.automata {
+		ix.automata[mode] = newAutomaton()
+		ix.single[mode] = make(map[string][]int32)
+	}
+	return ix
+}
+
+// Add indexes a query under id. Adding an id a second time has no effect.
+func (ix *Index) Add(id uint, q *Query) {
+	if q == nil || q.root == nil {
+		return
+	}
+	if _, exists := ix.queries[id]; exists {
+		return
+	}
+
+	ix.ids = append(ix.ids, id)
+	ix.queries[id] = q
+
+	walkTerms(q.root, func(term *Term) {
+		if len(term.words) == 0 {
+			return
+		}
+
+		mode := modeLower
+		switch {
+		case term.Exact:
+			mode = modeExact
+		case q.stem:
+			mode = modeStem
+		}
+
+		words := make([]string, len(term.words))
+		for i, w := range term.words {
+			words[i] = w.form(mode)
+		}
+
+		key := patternKey{mode: mode, words: strings.Join(words, "\x00")}
+		pattern, ok := ix.patterns[key]
+		if !ok {
+			pattern = int32(len(ix.postings))
+			ix.patterns[key] = pattern
+			ix.postings = append(ix.postings, nil)
+			ix.automata[mode].add(words, pattern)
+			if len(words) == 1 {
+				ix.single[mode][words[0]] = append(ix.single[mode][words[0]], pattern)
+			}
+		}
+
+		ix.terms[termKey{id: id, term: term}] = pattern
+		if postings := ix.postings[pattern]; len(postings) == 0 || postings[len(postings)-1] != id {
+			ix.postings[pattern] = append(postings, id)
+		}
+	})
+}
+
+// Len returns the number of indexed queries
+func (ix *Index) Len() int {
+	return len(ix.ids)
+}
+
+// Match returns the ids of the queries the article satisfies, in ascending
+// order. It agrees with calling Match on each query.
+func (ix *Index) Match(article models.NewsArticle) []uint {
+	hits := ix.scan(&article)
+	if len(hits) == 0 {
+		return nil
+	}
+
+	candidates := make(map[uint]bool)
+	for pattern := range hits {
+		for _, id := range ix.postings[pattern] {
+			candidates[id] = true
+		}
+	}
+
+	var matched []uint
+	for id := range candidates {
+		doc := &Document{hit: func(term *Term) bool {
+			pattern, ok := ix.terms[termKey{id: id, term: term}]
+			return ok && hits[pattern]&fieldMaskOf(term.Field) != 0
+		}}
+		if ix.queries[id].root.Match(doc) {
+			matched = append(matched, id)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool { return matched[i] < matched[j] })
+	return matched
+}
+
+// scan finds every indexed pattern in the article and the fields it is in
+func (ix *Index) scan(article *models.NewsArticle) map[int32]fieldMask {
+	hits := make(map[int32]fieldMask)
+	doc := newDocument(article, !ix.automata[modeStem].empty())
+
+	var forms []string
+	for _, field := range indexedFields {
+		for _, segment := range doc.segments(field.name) {
+			for mode, a := range ix.automata {
+				if a.empty() {
+					continue
+				}
+
+				forms = forms[:0]
+				for _, w := range segment {
+					forms = append(forms, w.form(matchMode(mode)))
+				}
+				a.scan(forms, func(pattern int32) { hits[pattern] |= field.mask })
+
+				single := ix.single[mode]
+				for _, w := range segment {
+					for _, part := range w.parts {
+						for _, pattern := range single[part.form(matchMode(mode))] {
+							hits[pattern] |= field.mask
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return hits
+}
+
+// form is the token as compared in the given mode
+func (t textToken) form(mode matchMode) string {
+	switch mode {
+	case modeExact:
+		return t.text
+	case modeStem:
+		return t.stem
+	}
+	return t.lower
+}
+
+// walkTerms visits every term of the expression, negated or not
+func walkTerms(node Node, visit func(*Term)) {
+	switch n := node.(type) {
+	case *Term:
+		visit(n)
+	case *And:
+		walkTerms(n.Left, visit)
+		walkTerms(n.Right, visit)
+	case *Or:
+		walkTerms(n.Left, visit)
+		walkTerms(n.Right, visit)
+	case *Not:
+		walkTerms(n.Operand, visit)
+	}
+}
@@ -0,0 +1,288 @@
+package query
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"news-to-text/internal/models"
+)
+
+func TestIndex_Match(t *testing.T) {
+	queries := []struct {
+		query    string
+		keywords []string
+		stemming bool
+	}{
+		{keywords: []string{"Apple"}},                                   // 1
+		{keywords: []string{"AI", "artificial intelligence"}},           // 2
+		{query: `"interest rates" NOT Tesla`},                           // 3
+		{query: "title:recalls OR source:reuters"},                      // 4
+		{query: "=AAPL"},                                                // 5
+		{query: "recall", stemming: true},                               // 6
+		{query: "Mac AND category:tech"},                                // 7
+		{query: "Apple NOT (iPhone OR rumor)"},                          // 8
+		{query: `rates "rates steady"`},                                 // 9
+		{keywords: []string{"recall"}},                                  // 10
+		{query: `description:"Federal Reserve" AND title:(Fed OR ECB)`}, // 11
+	}
+
+	ix := NewIndex()
+	var compiled []*Query
+	for i, tt := range queries {
+		q := FromKeywords(tt.keywords)
+		if tt.query != "" {
+			var err error
+			if q, err = Parse(tt.query); err != nil {
+				t.Fatalf("Failed to parse %q: %v", tt.query, err)
+			}
+		}
+		q = q.WithStemming(tt.stemming)
+		compiled = append(compiled, q)
+		ix.Add(uint(i+1), q)
+	}
+
+	articles := []struct {
+		article  models.NewsArticle
+		expected []uint
+	}{
+		{
+			article:  models.NewsArticle{Title: "Apple unveils iPhone with AI features", Category: "Tech"},
+			expected: []uint{1, 2},
+		},
+		{
+			article:  models.NewsArticle{Title: "Fed holds interest rates steady", Description: "The Federal Reserve kept rates unchanged", Source: "Reuters"},
+			expected: []uint{3, 4, 9, 11},
+		},
+		{
+			article:  models.NewsArticle{Title: "Tesla recalls vehicles", Description: "Interest rates did not matter"},
+			expected: []uint{4, 6},
+		},
+		{
+			article:  models.NewsArticle{Title: "AAPL and new MacBook models", Categories: []string{"Business", "Tech"}},
+			expected: []uint{5, 7},
+		},
+		{
+			article:  models.NewsArticle{Title: "Apple said to recall older models", Description: "Analysts said gains came again in Spain"},
+			expected: []uint{1, 6, 8, 10},
+		},
+		{
+			article:  models.NewsArticle{Title: "aapl shares", Description: "Apple rumor mill"},
+			expected: []uint{1},
+		},
+		{
+			article:  models.NewsArticle{Title: "Sports roundup"},
+			expected: nil,
+		},
+	}
+
+	for i, tt := range articles {
+		t.Run(tt.article.Title, func(t *testing.T) {
+			got := ix.Match(tt.article)
+			if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+				t.Errorf("Expected %v but got %v", tt.expected, got)
+			}
+
+			var naive []uint
+			for j, q := range compiled {
+				if q.Match(tt.article) {
+					naive = append(naive, uint(j+1))
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(naive) {
+				t.Errorf("Article %d: index matched %v but the queries match %v", i, got, naive)
+			}
+		})
+	}
+}
+
+func TestIndex_AgreesWithQueries(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	vocab := benchmarkVocabulary(rng, 200)
+
+	ix := NewIndex()
+	queries := make([]*Query, 500)
+	for i := range queries {
+		queries[i] = benchmarkQuery(rng, vocab)
+		ix.Add(uint(i), queries[i])
+	}
+
+	for n := 0; n < 200; n++ {
+		article := benchmarkArticle(rng, vocab)
+
+		var naive []uint
+		for i, q := range queries {
+			if q.Match(article) {
+				naive = append(naive, uint(i))
+			}
+		}
+
+		if got := ix.Match(article); fmt.Sprint(got) != fmt.Sprint(naive) {
+			t.Fatalf("Index matched %v but the queries match %v for %+v", got, naive, article)
+		}
+	}
+}
+
+func TestIndex_AddTwice(t *testing.T) {
+	ix := NewIndex()
+	ix.Add(1, FromKeywords([]string{"Apple"}))
+	ix.Add(1, FromKeywords([]string{"Tesla"}))
+	ix.Add(2, FromKeywords(nil))
+
+	if ix.Len() != 1 {
+		t.Errorf("Expected 1 indexed query but got %d", ix.Len())
+	}
+	if got := ix.Match(models.NewsArticle{Title: "Tesla recalls"}); len(got) != 0 {
+		t.Errorf("Expected the second add to be ignored, got %v", got)
+	}
+}
+
+const (
+	benchmarkAlerts   = 10000
+	benchmarkArticles = 100000
+)
+
+var benchmarkData struct {
+	vocab    []string
+	queries  []*Query
+	articles []models.NewsArticle
+}
+
+// loadBenchmarkData generates 10k alert queries and 100k articles once, from
+// a vocabulary with the long tail of real headlines
+func loadBenchmarkData() {
+	if benchmarkData.queries != nil {
+		return
+	}
+
+	rng := rand.New(rand.NewSource(42))
+	benchmarkData.vocab = benchmarkVocabulary(rng, 20000)
+	for i := 0; i < benchmarkAlerts; i++ {
+		benchmarkData.queries = append(benchmarkData.queries, benchmarkQuery(rng, benchmarkData.vocab))
+	}
+	for i := 0; i < benchmarkArticles; i++ {
+		benchmarkData.articles = append(benchmarkData.articles, benchmarkArticle(rng, benchmarkData.vocab))
+	}
+}
+
+// BenchmarkIndex_Match routes articles to every matching alert of 10k with
+// one scan per article. Run with -benchtime=100000x to go through all 100k
+// articles once.
+func BenchmarkIndex_Match(b *testing.B) {
+	loadBenchmarkData()
+
+	ix := NewIndex()
+	for i, q := range benchmarkData.queries {
+		ix.Add(uint(i), q)
+	}
+	ix.Match(benchmarkData.articles[0]) // compile the automata
+
+	matches := 0
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matches += len(ix.Match(benchmarkData.articles[i%benchmarkArticles]))
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "articles/s")
+	b.ReportMetric(float64(matches)/float64(b.N), "matches/article")
+}
+
+// BenchmarkQuery_MatchEach is the per-alert evaluation the index replaces:
+// every article is matched against each of the 10k alerts in turn
+func BenchmarkQuery_MatchEach(b *testing.B) {
+	loadBenchmarkData()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		article := benchmarkData.articles[i%benchmarkArticles]
+		for _, q := range benchmarkData.queries {
+			q.Match(article)
+		}
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "articles/s")
+}
+
+func BenchmarkIndex_Build(b *testing.B) {
+	loadBenchmarkData()
+
+	for i := 0; i < b.N; i++ {
+		ix := NewIndex()
+		for id, q := range benchmarkData.queries {
+			ix.Add(uint(id), q)
+		}
+		ix.Match(benchmarkData.articles[0])
+	}
+}
+
+var syllables = []string{"ba", "ko", "ri", "te", "mu", "sa", "lo", "ne", "vi", "da", "po", "ge", "fi", "zu", "ha", "ly", "chor", "tran", "mex", "quin"}
+
+func benchmarkVocabulary(rng *rand.Rand, size int) []string {
+	seen := make(map[string]bool)
+	vocab := make([]string, 0, size)
+	for len(vocab) < size {
+		var w strings.Builder
+		for n := 2 + rng.Intn(3); n > 0; n-- {
+			w.WriteString(syllables[rng.Intn(len(syllables))])
+		}
+		if word := w.String(); !seen[word] {
+			seen[word] = true
+			vocab = append(vocab, word)
+		}
+	}
+	return vocab
+}
+
+// zipfWord picks common words far more often than rare ones
+func zipfWord(rng *rand.Rand, vocab []string) string {
+	i := int(float64(len(vocab)) * rng.Float64() * rng.Float64() * rng.Float64())
+	return vocab[i]
+}
+
+func benchmarkQuery(rng *rand.Rand, vocab []string) *Query {
+	word := func() string { return vocab[rng.Intn(len(vocab))] }
+
+	switch rng.Intn(6) {
+	case 0:
+		q, _ := Parse(fmt.Sprintf("%s OR title:%s", word(), word()))
+		return q
+	case 1:
+		q, _ := Parse(fmt.Sprintf(`"%s %s" NOT %s`, zipfWord(rng, vocab), zipfWord(rng, vocab), word()))
+		return q
+	case 2:
+		q, _ := Parse(fmt.Sprintf("%s AND (%s OR %s)", zipfWord(rng, vocab), word(), word()))
+		return q.WithStemming(true)
+	case 3:
+		w := word()
+		return FromKeywords([]string{"=" + strings.ToUpper(w[:1]) + w[1:]})
+	}
+
+	keywords := make([]string, 1+rng.Intn(3))
+	for i := range keywords {
+		keywords[i] = word()
+	}
+	return FromKeywords(keywords)
+}
+
+func benchmarkArticle(rng *rand.Rand, vocab []string) models.NewsArticle {
+	sentence := func(n int) string {
+		words := make([]string, n)
+		for i := range words {
+			words[i] = zipfWord(rng, vocab)
+			if rng.Intn(5) == 0 {
+				words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+			}
+		}
+		return strings.Join(words, " ")
+	}
+
+	return models.NewsArticle{
+		Title:       sentence(6 + rng.Intn(8)),
+		Description: sentence(20 + rng.Intn(30)),
+		Source:      vocab[rng.Intn(50)],
+		Category:    vocab[rng.Intn(10)],
+	}
+}
@@ -0,0 +1,190 @@
+package query
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenPhrase
+	tokenField
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenLParen
+	tokenRParen
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokenEOF:
+		return "end of query"
+	case tokenWord:
+		return "term"
+	case tokenPhrase:
+		return "phrase"
+	case tokenField:
+		return "field"
+	case tokenAnd:
+		return "AND"
+	case tokenOr:
+		return "OR"
+	case tokenNot:
+		return "NOT"
+	case tokenLParen:
+		return `"("`
+	case tokenRParen:
+		return `")"`
+	}
+	return "token"
+}
+
+type token struct {
+	kind  tokenKind
+	text  string
+	pos   int  // 1-based character position in the query
+	exact bool // the term was prefixed with "=" and is case sensitive
+}
+
+// lex splits a query into tokens. Operators are only recognized in upper
+// case so that "and" or "or" can still be searched for as words.
+func lex(input string) ([]token, error) {
+	var tokens []token
+	pos := 1
+	i := 0
+	exactPos := 0 // position of a pending "=" prefix
+
+	for i < len(input) {
+		r, size := utf8.DecodeRuneInString(input[i:])
+
+		if exactPos != 0 && (unicode.IsSpace(r) || r == '(' || r == ')') {
+			return nil, &SyntaxError{Position: exactPos, Message: `"=" must be followed by a term or phrase`}
+		}
+
+		switch {
+		case r == '=' && exactPos == 0:
+			exactPos = pos
+			i += size
+			pos++
+
+		case unicode.IsSpace(r):
+			i += size
+			pos++
+
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: pos})
+			i += size
+			pos++
+
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: pos})
+			i += size
+			pos++
+
+		case r == '"':
+			start := pos
+			i += size
+			pos++
+
+			var phrase strings.Builder
+			closed := false
+			for i < len(input) {
+				r, size = utf8.DecodeRuneInString(input[i:])
+				i += size
+				pos++
+				if r == '\\' && i < len(input) {
+					r, size = utf8.DecodeRuneInString(input[i:])
+					i += size
+					pos++
+					phrase.WriteRune(r)
+					continue
+				}
+				if r == '"' {
+					closed = true
+					break
+				}
+				phrase.WriteRune(r)
+			}
+
+			if !closed {
+				return nil, &SyntaxError{Position: start, Message: "unterminated quoted phrase"}
+			}
+			text := strings.TrimSpace(phrase.String())
+			if text == "" {
+				return nil, &SyntaxError{Position: start, Message: "empty quoted phrase"}
+			}
+			tok := token{kind: tokenPhrase, text: text, pos: start}
+			if exactPos != 0 {
+				tok.pos, tok.exact, exactPos = exactPos, true, 0
+			}
+			tokens = append(tokens, tok)
+
+		default:
+			start := pos
+			var word strings.Builder
+			for i < len(input) {
+				r, size = utf8.DecodeRuneInString(input[i:])
+				if unicode.IsSpace(r) || r == '(' || r == ')' || r == '"' {
+					break
+				}
+				word.WriteRune(r)
+				i += size
+				pos++
+
+				if r == ':' && isFieldName(word.String()) {
+					break
+				}
+			}
+
+			if exactPos != 0 {
+				tokens = append(tokens, token{kind: tokenWord, text: word.String(), pos: exactPos, exact: true})
+				exactPos = 0
+				continue
+			}
+			tokens = append(tokens, wordToken(word.String(), start))
+		}
+	}
+
+	if exactPos != 0 {
+		return nil, &SyntaxError{Position: exactPos, Message: `"=" must be followed by a term or phrase`}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: pos})
+	return tokens, nil
+}
+
+func wordToken(word string, pos int) token {
+	switch word {
+	case "AND":
+		return token{kind: tokenAnd, text: word, pos: pos}
+	case "OR":
+		return token{kind: tokenOr, text: word, pos: pos}
+	case "NOT":
+		return token{kind: tokenNot, text: word, pos: pos}
+	}
+
+	if isFieldName(word) {
+		return token{kind: tokenField, text: strings.ToLower(strings.TrimSuffix(word, ":")), pos: pos}
+	}
+	return token{kind: tokenWord, text: word, pos: pos}
+}
+
+// isFieldName reports whether the word looks like a field scope: letters
+// followed by a colon, as in "title:"
+func isFieldName(word string) bool {
+	name, ok := strings.CutSuffix(word, ":")
+	if !ok || name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
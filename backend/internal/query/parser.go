@@ -0,0 +1,161 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// parser is a recursive descent parser over the token stream:
+//
+//	or      = and { "OR" and }
+//	and     = unary { [ "AND" ] unary }
+//	unary   = "NOT" unary | primary
+//	primary = "(" or ")" | field ( term | phrase | "(" or ")" ) | term | phrase
+//	term    = [ "=" ] word
+//	phrase  = [ "=" ] '"' words '"'
+type parser struct {
+	tokens []token
+	pos    int
+	field  string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOr {
+		op := p.next()
+		right, err := p.parseOperand(op, p.parseAnd)
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch p.peek().kind {
+		case tokenAnd:
+			op := p.next()
+			right, err := p.parseOperand(op, p.parseUnary)
+			if err != nil {
+				return nil, err
+			}
+			left = &And{Left: left, Right: right}
+
+		case tokenWord, tokenPhrase, tokenField, tokenNot, tokenLParen:
+			right, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			left = &And{Left: left, Right: right}
+
+		default:
+			return left, nil
+		}
+	}
+}
+
+// parseOperand parses the operand of an operator, reporting a missing
+// operand at the operator's position
+func (p *parser) parseOperand(op token, parse func() (Node, error)) (Node, error) {
+	switch p.peek().kind {
+	case tokenEOF, tokenRParen, tokenAnd, tokenOr:
+		return nil, &SyntaxError{Position: op.pos, Message: fmt.Sprintf("%s must be followed by a term", op.text)}
+	}
+	return parse()
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if p.peek().kind == tokenNot {
+		op := p.next()
+		operand, err := p.parseOperand(op, p.parseUnary)
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenWord, tokenPhrase:
+		term := newTerm(p.field, tok.text, tok.kind == tokenPhrase, tok.exact)
+		if len(term.words) == 0 {
+			return nil, &SyntaxError{Position: tok.pos, Message: fmt.Sprintf("%s has no letters or digits to match", tok.kind)}
+		}
+		return term, nil
+
+	case tokenLParen:
+		if p.peek().kind == tokenRParen {
+			return nil, &SyntaxError{Position: tok.pos, Message: "empty parentheses"}
+		}
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &SyntaxError{Position: tok.pos, Message: `"(" is never closed`}
+		}
+		return node, nil
+
+	case tokenField:
+		if !fields[tok.text] {
+			return nil, &SyntaxError{Position: tok.pos, Message: fmt.Sprintf("unknown field %q, expected one of %s", tok.text, fieldList())}
+		}
+		if p.field != "" {
+			return nil, &SyntaxError{Position: tok.pos, Message: "field scopes cannot be nested"}
+		}
+
+		switch p.peek().kind {
+		case tokenWord, tokenPhrase, tokenLParen:
+		default:
+			return nil, &SyntaxError{Position: tok.pos, Message: fmt.Sprintf("%s: must be followed by a term, phrase or group", tok.text)}
+		}
+
+		p.field = tok.text
+		node, err := p.parsePrimary()
+		p.field = ""
+		return node, err
+
+	case tokenRParen:
+		return nil, &SyntaxError{Position: tok.pos, Message: `unmatched ")"`}
+	}
+
+	return nil, &SyntaxError{Position: tok.pos, Message: fmt.Sprintf("expected a term but found %s", tok.kind)}
+}
+
+func fieldList() string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name+":")
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
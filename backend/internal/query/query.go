@@ -0,0 +1,426 @@
+// Package query implements the alert query language: terms and quoted
+// phrases combined with AND, OR, NOT and parentheses, optionally scoped to a
+// field as in title:"interest rates" or source:Reuters.
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"news-to-text/internal/models"
+)
+
+// Fields that a term can be scoped to. Unscoped terms search the title and
+// the description.
+const (
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldSource      = "source"
+	FieldCategory    = "category"
+)
+
+var fields = map[string]bool{
+	FieldTitle:       true,
+	FieldDescription: true,
+	FieldSource:      true,
+	FieldCategory:    true,
+}
+
+// SyntaxError describes an invalid query and where the problem is
+type SyntaxError struct {
+	Position int
+	Message  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid query at position %d: %s", e.Position, e.Message)
+}
+
+// Node is a parsed query expression
+type Node interface {
+	Match(doc *Document) bool
+	String() string
+}
+
+// Term is a single word or quoted phrase, optionally scoped to a field. It
+// matches whole words only, ignoring case unless the term is Exact.
+type Term struct {
+	Field  string
+	Text   string
+	Phrase bool
+	Exact  bool
+
+	words []word
+}
+
+func newTerm(field, text string, phrase, exact bool) *Term {
+	return &Term{Field: field, Text: text, Phrase: phrase, Exact: exact, words: tokenize(text, true)}
+}
+
+// Match reports whether the words of the term appear consecutively in the
+// field. A single-word term also matches a piece of a compound word.
+func (t *Term) Match(doc *Document) bool {
+	if doc.hit != nil {
+		return doc.hit(t)
+	}
+
+	for _, segment := range doc.segments(t.Field) {
+		if t.occurrences(segment, doc.stem, 1) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// occurrences counts where the term appears in the words, stopping once it
+// reaches limit. A limit of 0 counts them all.
+func (t *Term) occurrences(words []word, stem bool, limit int) int {
+	n := len(t.words)
+	if n == 0 {
+		return 0
+	}
+
+	count := 0
+	for i := 0; i+n <= len(words); i++ {
+		if t.matchAt(words, i, stem) {
+			count++
+			if count == limit {
+				break
+			}
+		}
+	}
+	return count
+}
+
+// matchedText returns the article text of each occurrence of the term
+func (t *Term) matchedText(words []word, stem bool) []string {
+	n := len(t.words)
+	if n == 0 {
+		return nil
+	}
+
+	var matched []string
+	for i := 0; i+n <= len(words); i++ {
+		if !t.matchAt(words, i, stem) {
+			continue
+		}
+
+		texts := make([]string, n)
+		for j := range texts {
+			texts[j] = words[i+j].text
+		}
+		matched = append(matched, strings.Join(texts, " "))
+	}
+	return matched
+}
+
+func (t *Term) matchAt(words []word, i int, stem bool) bool {
+	if len(t.words) == 1 {
+		if t.equal(t.words[0], words[i].textToken, stem) {
+			return true
+		}
+		for _, part := range words[i].parts {
+			if t.equal(t.words[0], part, stem) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for j := range t.words {
+		if !t.equal(t.words[j], words[i+j].textToken, stem) {
+			return false
+		}
+	}
+	return true
+}
+
+// equal compares a word of the term with a token of the article. Exact terms
+// compare case and are never stemmed.
+func (t *Term) equal(want word, tok textToken, stem bool) bool {
+	switch {
+	case t.Exact:
+		return tok.text == want.text
+	case stem:
+		return tok.stem == want.stem
+	}
+	return tok.lower == want.lower
+}
+
+func (t *Term) String() string {
+	text := t.Text
+	if t.Phrase {
+		text = fmt.Sprintf("%q", t.Text)
+	}
+	if t.Exact {
+		text = "=" + text
+	}
+	if t.Field != "" {
+		return t.Field + ":" + text
+	}
+	return text
+}
+
+type And struct {
+	Left, Right Node
+}
+
+func (n *And) Match(doc *Document) bool {
+	return n.Left.Match(doc) && n.Right.Match(doc)
+}
+
+func (n *And) String() string {
+	return "(" + n.Left.String() + " AND " + n.Right.String() + ")"
+}
+
+type Or struct {
+	Left, Right Node
+}
+
+func (n *Or) Match(doc *Document) bool {
+	return n.Left.Match(doc) || n.Right.Match(doc)
+}
+
+func (n *Or) String() string {
+	return "(" + n.Left.String() + " OR " + n.Right.String() + ")"
+}
+
+type Not struct {
+	Operand Node
+}
+
+func (n *Not) Match(doc *Document) bool {
+	return !n.Operand.Match(doc)
+}
+
+func (n *Not) String() string {
+	return "NOT " + n.Operand.String()
+}
+
+// Query is a compiled alert query
+type Query struct {
+	root Node
+	stem bool
+}
+
+// Parse compiles a query expression. Adjacent terms without an operator are
+// combined with AND, and AND binds tighter than OR.
+func Parse(input string) (*Query, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, &SyntaxError{Position: 1, Message: "query is empty"}
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if next := p.peek(); next.kind != tokenEOF {
+		if next.kind == tokenRParen {
+			return nil, &SyntaxError{Position: next.pos, Message: `unmatched ")"`}
+		}
+		return nil, &SyntaxError{Position: next.pos, Message: fmt.Sprintf("unexpected %s", next.kind)}
+	}
+
+	if !positive(root) {
+		return nil, &SyntaxError{Position: 1, Message: "query must require at least one term that is not negated"}
+	}
+
+	return &Query{root: root}, nil
+}
+
+// FromKeywords builds the query equivalent to a plain keyword list: an
+// article matches when it contains any of the keywords. A keyword prefixed
+// with "=" is case sensitive, as in "=AAPL".
+func FromKeywords(keywords []string) *Query {
+	var root Node
+	for _, keyword := range keywords {
+		keyword = strings.TrimSpace(keyword)
+		exact := strings.HasPrefix(keyword, "=")
+		if exact {
+			keyword = strings.TrimSpace(keyword[1:])
+		}
+
+		term := newTerm("", keyword, strings.ContainsAny(keyword, " \t"), exact)
+		if len(term.words) == 0 {
+			continue
+		}
+
+		if root == nil {
+			root = term
+		} else {
+			root = &Or{Left: root, Right: term}
+		}
+	}
+
+	return &Query{root: root}
+}
+
+// Excluding returns a copy of the query that also rejects articles
+// containing any of the keywords, as in Tesla NOT "Nikola Tesla"
+func (q *Query) Excluding(keywords []string) *Query {
+	if q == nil {
+		return nil
+	}
+
+	excluded := FromKeywords(keywords).root
+	if q.root == nil || excluded == nil {
+		return q
+	}
+	return &Query{root: &And{Left: q.root, Right: &Not{Operand: excluded}}, stem: q.stem}
+}
+
+// WithStemming returns a copy of the query that, when enabled, matches
+// English words by their stem so "recall" also finds "recalls" and
+// "recalled". Exact terms are never stemmed.
+func (q *Query) WithStemming(enabled bool) *Query {
+	if q == nil {
+		return nil
+	}
+	return &Query{root: q.root, stem: enabled}
+}
+
+// Stemming reports whether the query matches words by their stem
+func (q *Query) Stemming() bool {
+	return q != nil && q.stem
+}
+
+// Match reports whether the article satisfies the query. An empty query
+// matches nothing.
+func (q *Query) Match(article models.NewsArticle) bool {
+	if q == nil || q.root == nil {
+		return false
+	}
+	return q.root.Match(newDocument(&article, q.stem))
+}
+
+// Terms returns the distinct terms of the query that are not negated, which
+// are the ones an article can match on
+func (q *Query) Terms() []Term {
+	if q == nil || q.root == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var terms []Term
+	collectTerms(q.root, false, func(term *Term) {
+		if key := term.String(); !seen[key] {
+			seen[key] = true
+			terms = append(terms, *term)
+		}
+	})
+
+	sort.SliceStable(terms, func(i, j int) bool { return terms[i].String() < terms[j].String() })
+	return terms
+}
+
+// Hits counts the occurrences of the query's terms in an article, the term
+// frequencies used to rank matches
+type Hits struct {
+	Title       int
+	Description int
+	Other       int // terms scoped to the source or category
+}
+
+// Hits counts how often each term that is not negated occurs in the article
+func (q *Query) Hits(article models.NewsArticle) Hits {
+	var hits Hits
+	if q == nil || q.root == nil {
+		return hits
+	}
+
+	doc := newDocument(&article, q.stem)
+	for _, term := range q.Terms() {
+		switch term.Field {
+		case "":
+			segments := doc.segments("")
+			hits.Title += term.occurrences(segments[0], doc.stem, 0)
+			hits.Description += term.occurrences(segments[1], doc.stem, 0)
+		case FieldTitle:
+			hits.Title += term.occurrences(doc.segments(FieldTitle)[0], doc.stem, 0)
+		case FieldDescription:
+			hits.Description += term.occurrences(doc.segments(FieldDescription)[0], doc.stem, 0)
+		default:
+			if term.Match(doc) {
+				hits.Other++
+			}
+		}
+	}
+	return hits
+}
+
+// Explain lists where each term that is not negated occurs in the article,
+// one entry per term and field, with the text each occurrence matched
+func (q *Query) Explain(article models.NewsArticle) []models.MatchedTerm {
+	if q == nil || q.root == nil {
+		return nil
+	}
+
+	doc := newDocument(&article, q.stem)
+	var matches []models.MatchedTerm
+	for _, term := range q.Terms() {
+		for _, field := range searchedFields(term.Field) {
+			var matched []string
+			for _, segment := range doc.segments(field) {
+				matched = append(matched, term.matchedText(segment, doc.stem)...)
+			}
+			if len(matched) > 0 {
+				matches = append(matches, models.MatchedTerm{Term: term.String(), Field: field, Hits: len(matched), Matched: matched})
+			}
+		}
+	}
+	return matches
+}
+
+func (q *Query) String() string {
+	if q == nil || q.root == nil {
+		return ""
+	}
+	return q.root.String()
+}
+
+// searchedFields lists the fields a term scoped to field searches
+func searchedFields(field string) []string {
+	if field == "" {
+		return []string{FieldTitle, FieldDescription}
+	}
+	return []string{field}
+}
+
+func collectTerms(node Node, negated bool, visit func(*Term)) {
+	switch n := node.(type) {
+	case *Term:
+		if !negated {
+			visit(n)
+		}
+	case *And:
+		collectTerms(n.Left, negated, visit)
+		collectTerms(n.Right, negated, visit)
+	case *Or:
+		collectTerms(n.Left, negated, visit)
+		collectTerms(n.Right, negated, visit)
+	case *Not:
+		collectTerms(n.Operand, !negated, visit)
+	}
+}
+
+// positive reports whether the expression can only match articles that
+// contain some term, so "NOT sports" on its own is rejected
+func positive(node Node) bool {
+	switch n := node.(type) {
+	case *Term:
+		return true
+	case *And:
+		return positive(n.Left) || positive(n.Right)
+	case *Or:
+		return positive(n.Left) && positive(n.Right)
+	}
+	return false
+}
@@ -0,0 +1,297 @@
+package query
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"news-to-text/internal/models"
+)
+
+var testArticles = map[string]models.NewsArticle{
+	"fed": {
+		Title:       "Fed holds interest rates steady",
+		Description: "The Federal Reserve kept rates unchanged and signaled cuts later this year.",
+		Source:      "Reuters",
+		Category:    "Business",
+	},
+	"apple": {
+		Title:       "Apple unveils iPhone with AI features",
+		Description: "The company showed new machine learning tools.",
+		Source:      "TechCrunch",
+		Category:    "Tech",
+	},
+	"tesla": {
+		Title:       "Tesla recalls vehicles",
+		Description: "Interest in the Autopilot system is under review by regulators.",
+		Source:      "CNBC",
+		Category:    "Business",
+	},
+}
+
+func TestParse_Match(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{name: "single term", query: "Apple", expected: []string{"apple"}},
+		{name: "implicit AND", query: "interest rates", expected: []string{"fed"}},
+		{name: "explicit AND", query: "interest AND recalls", expected: []string{"tesla"}},
+		{name: "OR", query: "Apple OR Tesla", expected: []string{"apple", "tesla"}},
+		{name: "AND binds tighter than OR", query: "Apple OR interest AND Reserve", expected: []string{"apple", "fed"}},
+		{name: "parentheses", query: "(Apple OR Tesla) AND recalls", expected: []string{"tesla"}},
+		{name: "NOT", query: "interest NOT Tesla", expected: []string{"fed"}},
+		{name: "quoted phrase", query: `"interest rates"`, expected: []string{"fed"}},
+		{name: "escaped quote", query: `"say \"hi\"" OR Apple`, expected: []string{"apple"}},
+		{name: "title scope", query: "title:interest", expected: []string{"fed"}},
+		{name: "source scope", query: "source:reuters OR source:CNBC", expected: []string{"fed", "tesla"}},
+		{name: "scoped phrase", query: `description:"machine learning"`, expected: []string{"apple"}},
+		{name: "scoped group", query: "title:(iPhone OR recalls) NOT source:CNBC", expected: []string{"apple"}},
+		{name: "category scope", query: "category:business interest", expected: []string{"fed", "tesla"}},
+		{name: "lowercase operators are words", query: "apple and", expected: []string{}},
+		{name: "colon inside a term", query: `"10:30" OR 10:30`, expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var matched []string
+			for _, name := range []string{"apple", "fed", "tesla"} {
+				if q.Match(testArticles[name]) {
+					matched = append(matched, name)
+				}
+			}
+
+			if len(matched) != len(tt.expected) {
+				t.Fatalf("Expected matches %v but got %v (parsed as %s)", tt.expected, matched, q)
+			}
+			for i := range matched {
+				if matched[i] != tt.expected[i] {
+					t.Errorf("Expected matches %v but got %v (parsed as %s)", tt.expected, matched, q)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		position int
+		message  string
+	}{
+		{name: "empty", query: "   ", position: 1, message: "query is empty"},
+		{name: "unterminated phrase", query: `apple "iphone`, position: 7, message: "unterminated quoted phrase"},
+		{name: "empty phrase", query: `apple ""`, position: 7, message: "empty quoted phrase"},
+		{name: "unclosed group", query: "apple AND (iphone OR ipad", position: 11, message: `"(" is never closed`},
+		{name: "unmatched close", query: "apple)", position: 6, message: `unmatched ")"`},
+		{name: "empty group", query: "apple ()", position: 7, message: "empty parentheses"},
+		{name: "dangling operator", query: "apple AND", position: 7, message: "AND must be followed by a term"},
+		{name: "double operator", query: "apple OR OR ipad", position: 7, message: "OR must be followed by a term"},
+		{name: "leading operator", query: "OR apple", position: 1, message: "expected a term but found OR"},
+		{name: "unknown field", query: "apple author:smith", position: 7, message: `unknown field "author", expected one of category:, description:, source:, title:`},
+		{name: "field without term", query: "title: AND apple", position: 1, message: "title: must be followed by a term, phrase or group"},
+		{name: "nested field", query: "title:(source:reuters)", position: 8, message: "field scopes cannot be nested"},
+		{name: "only negations", query: "NOT sports", position: 1, message: "query must require at least one term that is not negated"},
+		{name: "positions count characters", query: "café )", position: 6, message: `unmatched ")"`},
+		{name: "dangling exact prefix", query: "apple = iphone", position: 7, message: `"=" must be followed by a term or phrase`},
+		{name: "term without letters", query: "apple OR --", position: 10, message: "term has no letters or digits to match"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.query)
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Expected a syntax error but got %v", err)
+			}
+			if syntaxErr.Position != tt.position || syntaxErr.Message != tt.message {
+				t.Errorf("Expected %q at position %d but got %q at position %d", tt.message, tt.position, syntaxErr.Message, syntaxErr.Position)
+			}
+		})
+	}
+}
+
+func TestQuery_MatchWords(t *testing.T) {
+	article := models.NewsArticle{
+		Title:       "Apple shares rise as OpenText deal boosts AAPL",
+		Description: "Analysts said gains came again after the company recalled older MacBook models in Spain. 東京で発表",
+		Source:      "Reuters",
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		stemming bool
+		expected bool
+	}{
+		{name: "AI does not match said, again or Spain", query: "title:AI OR description:AI", expected: false},
+		{name: "AI does not match gains", query: "AI OR ai", expected: false},
+		{name: "compound word piece", query: "OpenText AND Text AND MacBook AND Mac", expected: true},
+		{name: "piece of a phrase word", query: `"older Mac"`, expected: false},
+		{name: "case insensitive by default", query: "aapl", expected: true},
+		{name: "exact term", query: "=AAPL", expected: true},
+		{name: "exact term wrong case", query: "=Aapl OR =apple", expected: false},
+		{name: "exact phrase", query: `="Apple shares"`, expected: true},
+		{name: "exact phrase wrong case", query: `="apple Shares"`, expected: false},
+		{name: "phrase does not span fields", query: `"AAPL Analysts"`, expected: false},
+		{name: "punctuation is a boundary", query: `"models in Spain"`, expected: true},
+		{name: "no stemming by default", query: "recall", expected: false},
+		{name: "stemming", query: "recall AND share AND boost", stemming: true, expected: true},
+		{name: "stemming phrase", query: `"recall old models"`, stemming: true, expected: false},
+		{name: "stemming ignores exact terms", query: "=recall", stemming: true, expected: false},
+		{name: "ideographs", query: `"東京"`, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if got := q.WithStemming(tt.stemming).Match(article); got != tt.expected {
+				t.Errorf("Expected %v but got %v (parsed as %s)", tt.expected, got, q)
+			}
+		})
+	}
+}
+
+func TestQuery_Hits(t *testing.T) {
+	article := models.NewsArticle{
+		Title:       "Fed holds rates as Fed officials wait",
+		Description: "Rates stay put. The Fed meets again in June.",
+		Source:      "Reuters",
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected Hits
+	}{
+		{name: "unscoped term", query: "Fed", expected: Hits{Title: 2, Description: 1}},
+		{name: "several terms", query: "Fed OR rates", expected: Hits{Title: 3, Description: 2}},
+		{name: "scoped terms", query: "title:rates OR source:reuters", expected: Hits{Title: 1, Other: 1}},
+		{name: "negated terms do not count", query: "Fed NOT June", expected: Hits{Title: 2, Description: 1}},
+		{name: "phrase", query: `"Fed officials"`, expected: Hits{Title: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if got := q.Hits(article); got != tt.expected {
+				t.Errorf("Expected %+v but got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestQuery_Explain(t *testing.T) {
+	q, err := Parse(`Mac OR "machine learning" OR source:reuters NOT Tesla`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	matches := q.Explain(models.NewsArticle{
+		Title:       "New MacBook and Mac mini",
+		Description: "Both bring machine learning features",
+		Source:      "Reuters",
+	})
+
+	expected := []models.MatchedTerm{
+		{Term: `"machine learning"`, Field: FieldDescription, Hits: 1, Matched: []string{"machine learning"}},
+		{Term: "Mac", Field: FieldTitle, Hits: 2, Matched: []string{"MacBook", "Mac"}},
+		{Term: "source:reuters", Field: FieldSource, Hits: 1, Matched: []string{"Reuters"}},
+	}
+	if len(matches) != len(expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, matches)
+	}
+	for i := range expected {
+		if matches[i].Term != expected[i].Term || matches[i].Field != expected[i].Field || matches[i].Hits != expected[i].Hits ||
+			strings.Join(matches[i].Matched, "|") != strings.Join(expected[i].Matched, "|") {
+			t.Errorf("Expected %+v but got %+v", expected[i], matches[i])
+		}
+	}
+}
+
+func TestFromKeywords(t *testing.T) {
+	q := FromKeywords([]string{"Apple", "interest rates", " "})
+
+	if !q.Match(testArticles["apple"]) || !q.Match(testArticles["fed"]) || q.Match(testArticles["tesla"]) {
+		t.Errorf("Expected keywords to match any of them as a phrase, parsed as %s", q)
+	}
+
+	if !FromKeywords([]string{"=iPhone"}).Match(testArticles["apple"]) || FromKeywords([]string{"=iphone"}).Match(testArticles["apple"]) {
+		t.Errorf("Expected a keyword prefixed with = to be case sensitive")
+	}
+
+	if FromKeywords(nil).Match(testArticles["apple"]) {
+		t.Errorf("Expected an empty keyword list to match nothing")
+	}
+}
+
+func TestQuery_Terms(t *testing.T) {
+	q, err := Parse(`title:Apple OR ("interest rates" NOT source:CNBC) OR Apple title:Apple`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var terms []string
+	for _, term := range q.Terms() {
+		terms = append(terms, term.String())
+	}
+
+	expected := []string{`"interest rates"`, "Apple", "title:Apple"}
+	if len(terms) != len(expected) {
+		t.Fatalf("Expected terms %v but got %v", expected, terms)
+	}
+	for i := range terms {
+		if terms[i] != expected[i] {
+			t.Errorf("Expected terms %v but got %v", expected, terms)
+			break
+		}
+	}
+}
+
+func TestQuery_Excluding(t *testing.T) {
+	q := FromKeywords([]string{"Tesla"}).Excluding([]string{"Nikola Tesla", "=SpaceX"})
+
+	if expected := `(Tesla AND NOT ("Nikola Tesla" OR =SpaceX))`; q.String() != expected {
+		t.Errorf("Expected %s but got %s", expected, q.String())
+	}
+
+	tests := []struct {
+		title    string
+		expected bool
+	}{
+		{"Tesla recalls Model Y", true},
+		{"Nikola Tesla museum reopens", false},
+		{"Tesla and SpaceX shares", false},
+		{"Tesla and spacex fans", true},
+	}
+
+	for _, tt := range tests {
+		if got := q.Match(models.NewsArticle{Title: tt.title}); got != tt.expected {
+			t.Errorf("%q: expected %v but got %v", tt.title, tt.expected, got)
+		}
+	}
+
+	if FromKeywords(nil).Excluding([]string{"Tesla"}).Match(models.NewsArticle{Title: "Ford"}) {
+		t.Errorf("Expected an empty query to match nothing")
+	}
+	if got := FromKeywords([]string{"Tesla"}).Excluding(nil).String(); got != "Tesla" {
+		t.Errorf("Expected no exclusions to leave the query as is, got %s", got)
+	}
+}
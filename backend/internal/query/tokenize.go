@@ -0,0 +1,164 @@
+package query
+
+import (
+	"strings"
+	"unicode"
+
+	"news-to-text/internal/models"
+	"news-to-text/pkg/stemmer"
+)
+
+// textToken is a word of article or term text in its original case, in
+// lower case and, when stemming, reduced to its English stem
+type textToken struct {
+	text  string
+	lower string
+	stem  string
+}
+
+// word is a token of text along with the pieces of a compound word, so that
+// a term can match "Mac" in "MacBook" or "GPT" in "GPT4" without matching
+// "ai" in "said"
+type word struct {
+	textToken
+	parts []textToken
+}
+
+func newTextToken(text string, stem bool) textToken {
+	lower := strings.ToLower(text)
+	tok := textToken{text: text, lower: lower}
+	if stem {
+		tok.stem = stemmer.Stem(lower)
+	}
+	return tok
+}
+
+// tokenize splits text on Unicode word boundaries. Words are runs of
+// letters, digits and combining marks; Han, Hiragana and Katakana characters
+// are written without spaces, so each one is a word of its own.
+func tokenize(text string, stem bool) []word {
+	var words []word
+	start := -1
+
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		w := word{textToken: newTextToken(text[start:end], stem)}
+		for _, part := range splitCompound(text[start:end]) {
+			w.parts = append(w.parts, newTextToken(part, stem))
+		}
+		words = append(words, w)
+		start = -1
+	}
+
+	for i, r := range text {
+		switch {
+		case isIdeograph(r):
+			flush(i)
+			words = append(words, word{textToken: newTextToken(string(r), stem)})
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			if start < 0 {
+				start = i
+			}
+		default:
+			flush(i)
+		}
+	}
+	flush(len(text))
+
+	return words
+}
+
+func isIdeograph(r rune) bool {
+	// Scripts below U+2E80 are written with spaces between words
+	return r >= 0x2E80 && unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// splitCompound splits a word at lower-to-upper case changes, before the
+// last capital of an acronym followed by a word, and between letters and
+// digits: "MacBook" is Mac and Book, "AIPowered" is AI and Powered. Words
+// without such boundaries have no parts.
+func splitCompound(w string) []string {
+	if !mayBeCompound(w) {
+		return nil
+	}
+
+	runes := []rune(w)
+	var parts []string
+	start := 0
+
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		boundary := unicode.IsLower(prev) && unicode.IsUpper(cur) ||
+			unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) ||
+			unicode.IsDigit(prev) != unicode.IsDigit(cur) && !unicode.IsMark(cur)
+		if boundary {
+			parts = append(parts, string(runes[start:i]))
+			start = i
+		}
+	}
+
+	if start == 0 {
+		return nil
+	}
+	return append(parts, string(runes[start:]))
+}
+
+// mayBeCompound reports whether a word has a capital or a digit after its
+// first character, without which splitCompound finds no boundary
+func mayBeCompound(w string) bool {
+	for i, r := range w {
+		if i > 0 && (unicode.IsUpper(r) || unicode.IsDigit(r)) {
+			return true
+		}
+		if i == 0 && unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Document is an article tokenized for matching. Fields are tokenized the
+// first time a term needs them, so one document can be shared by every term
+// of a query. An Index evaluates queries over a document whose term matches
+// have already been found, reported by hit.
+type Document struct {
+	article *models.NewsArticle
+	stem    bool
+	fields  map[string][][]word
+	hit     func(*Term) bool
+}
+
+func newDocument(article *models.NewsArticle, stem bool) *Document {
+	return &Document{article: article, stem: stem, fields: make(map[string][][]word)}
+}
+
+// segments returns the tokenized texts of a field. A phrase never spans two
+// segments, such as the end of the title and the start of the description.
+func (d *Document) segments(field string) [][]word {
+	if segments, ok := d.fields[field]; ok {
+		return segments
+	}
+
+	var texts []string
+	switch field {
+	case FieldTitle:
+		texts = []string{d.article.Title}
+	case FieldDescription:
+		texts = []string{d.article.Description}
+	case FieldSource:
+		texts = []string{d.article.Source}
+	case FieldCategory:
+		texts = append([]string{d.article.Category}, d.article.Categories...)
+	default:
+		texts = []string{d.article.Title, d.article.Description}
+	}
+
+	segments := make([][]word, 0, len(texts))
+	for _, text := range texts {
+		segments = append(segments, tokenize(text, d.stem))
+	}
+	d.fields[field] = segments
+	return segments
+}
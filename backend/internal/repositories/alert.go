@@ -1,20 +1,34 @@
 package repositories
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"news-to-text/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AlertRepository interface {
-	Create(alert *models.Alert) error
-	GetByID(id uint) (*models.Alert, error)
-	GetByUserID(userID uint) ([]models.Alert, error)
-	GetActiveAlerts() ([]models.Alert, error)
-	Update(alert *models.Alert) error
-	Delete(id uint) error
-	CreateHistory(history *models.AlertHistory) error
-	GetHistoryByAlertID(alertID uint) ([]models.AlertHistory, error)
-	GetHistoryByUserID(userID uint) ([]models.AlertHistory, error)
+	Create(ctx context.Context, alert *models.Alert) error
+	GetByID(ctx context.Context, id uint) (*models.Alert, error)
+	GetByUserID(ctx context.Context, userID uint) ([]models.Alert, error)
+	GetActiveAlerts(ctx context.Context) ([]models.Alert, error)
+	ClaimDueAlerts(ctx context.Context, now time.Time, limit int, token int64, reschedule func(alert *models.Alert)) ([]models.Alert, error)
+	GetScheduledAlerts(ctx context.Context, limit int) ([]models.Alert, error)
+	CountDueAlerts(ctx context.Context, now time.Time) (int64, error)
+	Update(ctx context.Context, alert *models.Alert, reschedule bool) error
+	Delete(ctx context.Context, id uint) error
+	CreateHistory(ctx context.Context, history *models.AlertHistory) error
+	GetHistoryByAlertID(ctx context.Context, alertID uint) ([]models.AlertHistory, error)
+	GetHistoryByUserID(ctx context.Context, userID uint) ([]models.AlertHistory, error)
+	GetHistoryByID(ctx context.Context, id uint) (*models.AlertHistory, error)
+	RecordDelivery(ctx context.Context, histories []*models.AlertHistory, notification *models.Notification, token int64) error
+	GetSeenStoryKeys(ctx context.Context, alertID uint, storyKeys []string) ([]string, error)
+	GetHeldHistory(ctx context.Context, until time.Time) ([]models.AlertHistory, error)
+	ReleaseHeld(ctx context.Context, ids []uint, notification *models.Notification, token int64) error
+	UpdateLastChecked(ctx context.Context, id uint, checkedAt time.Time, token int64) error
 }
 
 type alertRepository struct {
@@ -25,54 +39,250 @@ func NewAlertRepository(db *gorm.DB) AlertRepository {
 	return &alertRepository{db: db}
 }
 
-func (r *alertRepository) Create(alert *models.Alert) error {
-	return r.db.Create(alert).Error
+func (r *alertRepository) Create(ctx context.Context, alert *models.Alert) error {
+	return r.db.WithContext(ctx).Create(alert).Error
 }
 
-func (r *alertRepository) GetByID(id uint) (*models.Alert, error) {
+func (r *alertRepository) GetByID(ctx context.Context, id uint) (*models.Alert, error) {
 	var alert models.Alert
-	err := r.db.Preload("User").First(&alert, id).Error
+	err := r.db.WithContext(ctx).Preload("User").First(&alert, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &alert, nil
 }
 
-func (r *alertRepository) GetByUserID(userID uint) ([]models.Alert, error) {
+func (r *alertRepository) GetByUserID(ctx context.Context, userID uint) ([]models.Alert, error) {
 	var alerts []models.Alert
-	err := r.db.Where("user_id = ?", userID).Find(&alerts).Error
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&alerts).Error
 	return alerts, err
 }
 
-func (r *alertRepository) GetActiveAlerts() ([]models.Alert, error) {
+func (r *alertRepository) GetActiveAlerts(ctx context.Context) ([]models.Alert, error) {
 	var alerts []models.Alert
-	err := r.db.Where("active = ?", true).Preload("User").Find(&alerts).Error
+	err := r.db.WithContext(ctx).Where("active = ?", true).Preload("User").Find(&alerts).Error
 	return alerts, err
 }
 
-func (r *alertRepository) Update(alert *models.Alert) error {
-	return r.db.Save(alert).Error
+// ClaimDueAlerts locks up to limit active alerts whose next run is at or
+// before now, or that have none yet, skipping rows another scheduler holds.
+// reschedule moves each one's next run on, and the new next runs are saved
+// before the locks are released, so no other scheduler claims the same run.
+// The alerts are returned with their owner. Nothing is claimed under a stale
+// fencing token.
+func (r *alertRepository) ClaimDueAlerts(ctx context.Context, now time.Time, limit int, token int64, reschedule func(alert *models.Alert)) ([]models.Alert, error) {
+	var claimed []models.Alert
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := fence(tx, token); err != nil {
+			return err
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("active = ? AND (next_run_at IS NULL OR next_run_at <= ?)", true, now).
+			Order("next_run_at").
+			Limit(limit).
+			Find(&claimed).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range claimed {
+			reschedule(&claimed[i])
+			err := tx.Model(&models.Alert{}).Where("id = ?", claimed[i].ID).
+				Update("next_run_at", claimed[i].NextRunAt).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil || len(claimed) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uint, len(claimed))
+	for i, alert := range claimed {
+		ids[i] = alert.ID
+	}
+
+	var alerts []models.Alert
+	err = r.db.WithContext(ctx).Where("id IN ?", ids).Order("next_run_at").Preload("User").Find(&alerts).Error
+	return alerts, err
 }
 
-func (r *alertRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Alert{}, id).Error
+// GetScheduledAlerts returns the active alerts with a next run, soonest first
+func (r *alertRepository) GetScheduledAlerts(ctx context.Context, limit int) ([]models.Alert, error) {
+	var alerts []models.Alert
+	err := r.db.WithContext(ctx).Where("active = ? AND next_run_at IS NOT NULL", true).
+		Order("next_run_at").
+		Limit(limit).
+		Find(&alerts).Error
+	return alerts, err
 }
 
-func (r *alertRepository) CreateHistory(history *models.AlertHistory) error {
-	return r.db.Create(history).Error
+// CountDueAlerts counts the active alerts whose next run is at or before now
+func (r *alertRepository) CountDueAlerts(ctx context.Context, now time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Alert{}).
+		Where("active = ? AND next_run_at <= ?", true, now).
+		Count(&count).Error
+	return count, err
 }
 
-func (r *alertRepository) GetHistoryByAlertID(alertID uint) ([]models.AlertHistory, error) {
+// alertEditableColumns are the columns of an alert its owner can change
+var alertEditableColumns = []string{
+	"topic", "keywords", "query", "stemming", "min_score", "frequency", "active",
+	"schedule", "time_zone", "urgent", "exclude_keywords", "allowed_sources",
+	"blocked_sources", "categories", "languages", "locales",
+}
+
+// Update saves the columns of the alert its owner can change, and its next
+// run when reschedule is set. The scheduler's columns are otherwise left
+// alone, so an edit can't roll back a run the scheduler has just claimed.
+func (r *alertRepository) Update(ctx context.Context, alert *models.Alert, reschedule bool) error {
+	columns := alertEditableColumns
+	if reschedule {
+		columns = append(columns[:len(columns):len(columns)], "next_run_at")
+	}
+	return r.db.WithContext(ctx).Model(alert).Select(columns).Updates(alert).Error
+}
+
+// UpdateLastChecked sets only the alert's last check, so the scheduler never
+// writes back a stale copy of the rest of the row. Nothing is written under
+// a stale fencing token.
+func (r *alertRepository) UpdateLastChecked(ctx context.Context, id uint, checkedAt time.Time, token int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := fence(tx, token); err != nil {
+			return err
+		}
+		return tx.Model(&models.Alert{}).Where("id = ?", id).Update("last_checked", checkedAt).Error
+	})
+}
+
+func (r *alertRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Alert{}, id).Error
+}
+
+func (r *alertRepository) CreateHistory(ctx context.Context, history *models.AlertHistory) error {
+	return r.db.WithContext(ctx).Create(history).Error
+}
+
+func (r *alertRepository) GetHistoryByAlertID(ctx context.Context, alertID uint) ([]models.AlertHistory, error) {
 	var history []models.AlertHistory
-	err := r.db.Where("alert_id = ?", alertID).Order("created_at DESC").Find(&history).Error
+	err := r.db.WithContext(ctx).Where("alert_id = ?", alertID).Order("created_at DESC").Find(&history).Error
 	return history, err
 }
 
-func (r *alertRepository) GetHistoryByUserID(userID uint) ([]models.AlertHistory, error) {
+func (r *alertRepository) GetHistoryByUserID(ctx context.Context, userID uint) ([]models.AlertHistory, error) {
 	var history []models.AlertHistory
-	err := r.db.Joins("JOIN alerts ON alert_histories.alert_id = alerts.id").
+	err := r.db.WithContext(ctx).Joins("JOIN alerts ON alert_histories.alert_id = alerts.id").
 		Where("alerts.user_id = ?", userID).
 		Order("alert_histories.created_at DESC").
 		Find(&history).Error
 	return history, err
-}
\ No newline at end of file
+}
+
+func (r *alertRepository) GetHistoryByID(ctx context.Context, id uint) (*models.AlertHistory, error) {
+	var history models.AlertHistory
+	err := r.db.WithContext(ctx).Preload("Alert").First(&history, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// RecordDelivery writes one history row per delivered article and adds
+// each story to the alert's seen-set. When a notification is given it is
+// queued in the outbox and the rows point at it, all in the same
+// transaction, so a story is only marked seen once its text is queued.
+// Nothing is written under a stale fencing token.
+func (r *alertRepository) RecordDelivery(ctx context.Context, histories []*models.AlertHistory, notification *models.Notification, token int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := fence(tx, token); err != nil {
+			return err
+		}
+
+		if notification != nil {
+			if err := tx.Create(notification).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, history := range histories {
+			if notification != nil {
+				history.NotificationID = &notification.ID
+			}
+			if err := tx.Create(history).Error; err != nil {
+				return err
+			}
+
+			if history.StoryKey == "" {
+				continue
+			}
+
+			seen := &models.SeenArticle{
+				AlertID:        history.AlertID,
+				StoryKey:       history.StoryKey,
+				AlertHistoryID: history.ID,
+			}
+			err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(seen).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *alertRepository) GetSeenStoryKeys(ctx context.Context, alertID uint, storyKeys []string) ([]string, error) {
+	if len(storyKeys) == 0 {
+		return nil, nil
+	}
+
+	var seen []string
+	err := r.db.WithContext(ctx).Model(&models.SeenArticle{}).
+		Where("alert_id = ? AND story_key IN ?", alertID, storyKeys).
+		Pluck("story_key", &seen).Error
+	return seen, err
+}
+
+// GetHeldHistory returns the held notifications of active alerts due to be
+// sent by until, with their alert and its owner
+func (r *alertRepository) GetHeldHistory(ctx context.Context, until time.Time) ([]models.AlertHistory, error) {
+	var history []models.AlertHistory
+	err := r.db.WithContext(ctx).Joins("JOIN alerts ON alert_histories.alert_id = alerts.id AND alerts.active = ? AND alerts.deleted_at IS NULL", true).
+		Where("alert_histories.held = ? AND alert_histories.held_until <= ?", true, until).
+		Preload("Alert.User").
+		Order("alert_histories.alert_id, alert_histories.id").
+		Find(&history).Error
+	return history, err
+}
+
+// ReleaseHeld queues the notification for held rows and marks them as no
+// longer held, in one transaction. It fails without queueing anything when
+// any of the rows has already been released, or under a stale fencing token.
+func (r *alertRepository) ReleaseHeld(ctx context.Context, ids []uint, notification *models.Notification, token int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := fence(tx, token); err != nil {
+			return err
+		}
+		if err := tx.Create(notification).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.AlertHistory{}).
+			Where("id IN ? AND held = ?", ids, true).
+			Updates(map[string]interface{}{"held": false, "notification_id": notification.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(ids)) {
+			return errors.New("held notifications already released")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"news-to-text/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ArticleRepository interface {
+	CreateIfNotExists(ctx context.Context, articles []*models.Article) (int64, error)
+	GetIngestedSince(ctx context.Context, since time.Time) ([]models.Article, error)
+	DeleteIngestedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type articleRepository struct {
+	db *gorm.DB
+}
+
+func NewArticleRepository(db *gorm.DB) ArticleRepository {
+	return &articleRepository{db: db}
+}
+
+// CreateIfNotExists inserts the articles, skipping any whose key is already
+// stored, and returns the number of new rows
+func (r *articleRepository) CreateIfNotExists(ctx context.Context, articles []*models.Article) (int64, error) {
+	if len(articles) == 0 {
+		return 0, nil
+	}
+
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "article_key"}},
+		DoNothing: true,
+	}).CreateInBatches(articles, 100)
+
+	return result.RowsAffected, result.Error
+}
+
+func (r *articleRepository) GetIngestedSince(ctx context.Context, since time.Time) ([]models.Article, error) {
+	var articles []models.Article
+	err := r.db.WithContext(ctx).Where("created_at > ?", since).Order("published_at DESC").Find(&articles).Error
+	return articles, err
+}
+
+func (r *articleRepository) DeleteIngestedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&models.Article{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+
+	"news-to-text/internal/models"
+	"gorm.io/gorm"
+)
+
+type NewsSourceRepository interface {
+	Create(ctx context.Context, source *models.NewsSource) error
+	GetByID(ctx context.Context, id uint) (*models.NewsSource, error)
+	GetAll(ctx context.Context) ([]models.NewsSource, error)
+	GetActive(ctx context.Context) ([]models.NewsSource, error)
+	Update(ctx context.Context, source *models.NewsSource) error
+	Delete(ctx context.Context, id uint) error
+}
+
+type newsSourceRepository struct {
+	db *gorm.DB
+}
+
+func NewNewsSourceRepository(db *gorm.DB) NewsSourceRepository {
+	return &newsSourceRepository{db: db}
+}
+
+func (r *newsSourceRepository) Create(ctx context.Context, source *models.NewsSource) error {
+	return r.db.WithContext(ctx).Create(source).Error
+}
+
+func (r *newsSourceRepository) GetByID(ctx context.Context, id uint) (*models.NewsSource, error) {
+	var source models.NewsSource
+	err := r.db.WithContext(ctx).First(&source, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &source, nil
+}
+
+func (r *newsSourceRepository) GetAll(ctx context.Context) ([]models.NewsSource, error) {
+	var sources []models.NewsSource
+	err := r.db.WithContext(ctx).Order("id").Find(&sources).Error
+	return sources, err
+}
+
+func (r *newsSourceRepository) GetActive(ctx context.Context) ([]models.NewsSource, error) {
+	var sources []models.NewsSource
+	err := r.db.WithContext(ctx).Where("active = ?", true).Order("id").Find(&sources).Error
+	return sources, err
+}
+
+func (r *newsSourceRepository) Update(ctx context.Context, source *models.NewsSource) error {
+	return r.db.WithContext(ctx).Save(source).Error
+}
+
+func (r *newsSourceRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.NewsSource{}, id).Error
+}
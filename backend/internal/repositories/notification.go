@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"news-to-text/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.Notification, error)
+	RecordAttempt(ctx context.Context, notification *models.Notification) error
+	GetByID(ctx context.Context, id uint) (*models.Notification, error)
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// ClaimDue locks up to limit pending notifications whose next attempt is at
+// or before now, skipping rows another dispatcher holds, and pushes their
+// next attempt back by lease before the locks are released. Another
+// dispatcher only claims them again if this one hasn't recorded an attempt
+// by then. The notifications are returned with their user.
+func (r *notificationRepository) ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.Notification, error) {
+	var claimed []models.Notification
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.NotificationPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&claimed).Error
+		if err != nil || len(claimed) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(claimed))
+		for i, notification := range claimed {
+			ids[i] = notification.ID
+		}
+		return tx.Model(&models.Notification{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil || len(claimed) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uint, len(claimed))
+	for i, notification := range claimed {
+		ids[i] = notification.ID
+	}
+
+	var notifications []models.Notification
+	err = r.db.WithContext(ctx).Where("id IN ?", ids).Order("next_attempt_at, id").Preload("User").Find(&notifications).Error
+	return notifications, err
+}
+
+// RecordAttempt saves the outcome of a delivery attempt and copies it onto
+// the history rows of the articles the notification carries, in one
+// transaction
+func (r *notificationRepository) RecordAttempt(ctx context.Context, notification *models.Notification) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Notification{}).Where("id = ?", notification.ID).
+			Updates(map[string]interface{}{
+				"status":          notification.Status,
+				"attempts":        notification.Attempts,
+				"next_attempt_at": notification.NextAttemptAt,
+				"last_error":      notification.LastError,
+				"message_sid":     notification.MessageSID,
+				"sent_at":         notification.SentAt,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"success":   notification.Status == models.NotificationSent,
+			"error_msg": notification.LastError,
+			"attempts":  notification.Attempts,
+		}
+		if notification.SentAt != nil {
+			updates["sent_at"] = *notification.SentAt
+		}
+		return tx.Model(&models.AlertHistory{}).Where("notification_id = ?", notification.ID).
+			Updates(updates).Error
+	})
+}
+
+func (r *notificationRepository) GetByID(ctx context.Context, id uint) (*models.Notification, error) {
+	var notification models.Notification
+	err := r.db.WithContext(ctx).First(&notification, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
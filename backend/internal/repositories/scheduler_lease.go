@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"errors"
+
+	"news-to-text/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// schedulerLeaseName is the lease the alert scheduler's writes are fenced by
+const schedulerLeaseName = "scheduler"
+
+// ErrStaleFencingToken is returned for a scheduler write made under a
+// leadership term that a later leader has already written under
+var ErrStaleFencingToken = errors.New("stale fencing token")
+
+// fence checks, in tx, that token is not older than the highest fencing
+// token written under so far, and records it as the highest. The lease row
+// stays locked until tx ends, so a new leader's first write waits for this
+// one. A zero token, used without leader election, is not checked.
+func fence(tx *gorm.DB, token int64) error {
+	if token == 0 {
+		return nil
+	}
+
+	lease := models.SchedulerLease{Name: schedulerLeaseName}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&lease).Error; err != nil {
+		return err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&lease).Error; err != nil {
+		return err
+	}
+
+	if lease.Token > token {
+		return ErrStaleFencingToken
+	}
+	if lease.Token < token {
+		return tx.Model(&lease).Update("token", token).Error
+	}
+	return nil
+}
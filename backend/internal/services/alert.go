@@ -1,26 +1,43 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"net/url"
+	"strings"
 	"time"
 
 	"news-to-text/internal/models"
+	"news-to-text/internal/query"
 	"news-to-text/internal/repositories"
+	"news-to-text/pkg/logger"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 type AlertService interface {
-	CreateAlert(userID uint, req *models.AlertCreateRequest) (*models.AlertResponse, error)
-	GetAlerts(userID uint) ([]models.AlertResponse, error)
-	GetAlertByID(userID uint, alertID uint) (*models.AlertResponse, error)
-	UpdateAlert(userID uint, alertID uint, req *models.AlertUpdateRequest) (*models.AlertResponse, error)
-	DeleteAlert(userID uint, alertID uint) error
-	GetAlertHistory(userID uint) ([]models.AlertHistory, error)
-	TestAlert(userID uint, alertID uint) error
-	GetActiveAlerts() ([]models.Alert, error)
-	UpdateLastChecked(alertID uint) error
+	CreateAlert(ctx context.Context, userID uint, req *models.AlertCreateRequest) (*models.AlertResponse, error)
+	GetAlerts(ctx context.Context, userID uint) ([]models.AlertResponse, error)
+	GetAlertByID(ctx context.Context, userID uint, alertID uint) (*models.AlertResponse, error)
+	UpdateAlert(ctx context.Context, userID uint, alertID uint, req *models.AlertUpdateRequest) (*models.AlertResponse, error)
+	DeleteAlert(ctx context.Context, userID uint, alertID uint) error
+	GetAlertHistory(ctx context.Context, userID uint) ([]models.AlertHistory, error)
+	ExplainAlertHistory(ctx context.Context, userID uint, historyID uint) (*models.AlertHistoryExplanation, error)
+	ExplainAnyAlertHistory(ctx context.Context, historyID uint) (*models.AlertHistoryExplanation, error)
+	TestAlert(ctx context.Context, userID uint, alertID uint) error
+	GetActiveAlerts(ctx context.Context) ([]models.Alert, error)
+	UpdateLastChecked(ctx context.Context, alertID uint, token int64) error
+	ClaimDueAlerts(ctx context.Context, now time.Time, limit int, token int64) ([]DueAlert, error)
+	GetScheduledAlerts(ctx context.Context, limit int) ([]models.Alert, error)
+	CountDueAlerts(ctx context.Context, now time.Time) (int64, error)
+}
+
+// DueAlert is an alert claimed by the scheduler, with the run it was due
+// for. DueAt is nil for alerts that had not been scheduled yet.
+type DueAlert struct {
+	Alert models.Alert
+	DueAt *time.Time
 }
 
 type alertService struct {
@@ -35,24 +52,32 @@ func NewAlertService(alertRepo repositories.AlertRepository, redisClient *redis.
 	}
 }
 
-func (s *alertService) CreateAlert(userID uint, req *models.AlertCreateRequest) (*models.AlertResponse, error) {
-	alert := &models.Alert{
-		UserID:    userID,
-		Topic:     req.Topic,
-		Keywords:  models.Keywords(req.Keywords),
-		Frequency: req.Frequency,
-		Active:    true,
+func (s *alertService) CreateAlert(ctx context.Context, userID uint, req *models.AlertCreateRequest) (*models.AlertResponse, error) {
+	alert := matchingAlert(&req.AlertMatchRequest)
+	alert.UserID = userID
+	alert.Topic = req.Topic
+	alert.Frequency = req.Frequency
+	alert.Schedule = strings.TrimSpace(req.Schedule)
+	alert.TimeZone = strings.TrimSpace(req.TimeZone)
+	alert.Urgent = req.Urgent
+	alert.Active = true
+
+	if err := validateAlertQuery(alert); err != nil {
+		return nil, err
+	}
+	if err := applySchedule(alert, time.Now()); err != nil {
+		return nil, err
 	}
 
-	if err := s.alertRepo.Create(alert); err != nil {
+	if err := s.alertRepo.Create(ctx, alert); err != nil {
 		return nil, err
 	}
 
 	return alert.ToResponse(), nil
 }
 
-func (s *alertService) GetAlerts(userID uint) ([]models.AlertResponse, error) {
-	alerts, err := s.alertRepo.GetByUserID(userID)
+func (s *alertService) GetAlerts(ctx context.Context, userID uint) ([]models.AlertResponse, error) {
+	alerts, err := s.alertRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -65,8 +90,8 @@ func (s *alertService) GetAlerts(userID uint) ([]models.AlertResponse, error) {
 	return responses, nil
 }
 
-func (s *alertService) GetAlertByID(userID uint, alertID uint) (*models.AlertResponse, error) {
-	alert, err := s.alertRepo.GetByID(alertID)
+func (s *alertService) GetAlertByID(ctx context.Context, userID uint, alertID uint) (*models.AlertResponse, error) {
+	alert, err := s.alertRepo.GetByID(ctx, alertID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("alert not found")
@@ -81,8 +106,8 @@ func (s *alertService) GetAlertByID(userID uint, alertID uint) (*models.AlertRes
 	return alert.ToResponse(), nil
 }
 
-func (s *alertService) UpdateAlert(userID uint, alertID uint, req *models.AlertUpdateRequest) (*models.AlertResponse, error) {
-	alert, err := s.alertRepo.GetByID(alertID)
+func (s *alertService) UpdateAlert(ctx context.Context, userID uint, alertID uint, req *models.AlertUpdateRequest) (*models.AlertResponse, error) {
+	alert, err := s.alertRepo.GetByID(ctx, alertID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("alert not found")
@@ -94,6 +119,8 @@ func (s *alertService) UpdateAlert(userID uint, alertID uint, req *models.AlertU
 		return nil, errors.New("unauthorized access to alert")
 	}
 
+	before := *alert
+
 	// Update fields
 	if req.Topic != nil {
 		alert.Topic = *req.Topic
@@ -101,22 +128,74 @@ func (s *alertService) UpdateAlert(userID uint, alertID uint, req *models.AlertU
 	if req.Keywords != nil {
 		alert.Keywords = models.Keywords(*req.Keywords)
 	}
+	if req.Query != nil {
+		alert.Query = strings.TrimSpace(*req.Query)
+	}
+	if req.Stemming != nil {
+		alert.Stemming = *req.Stemming
+	}
+	if req.MinScore != nil {
+		alert.MinScore = *req.MinScore
+	}
 	if req.Frequency != nil {
+		// Choosing a frequency replaces the custom schedule
 		alert.Frequency = *req.Frequency
+		alert.Schedule = ""
+	}
+	if req.Schedule != nil {
+		alert.Schedule = strings.TrimSpace(*req.Schedule)
+	}
+	if req.TimeZone != nil {
+		alert.TimeZone = strings.TrimSpace(*req.TimeZone)
+	}
+	if req.Urgent != nil {
+		alert.Urgent = *req.Urgent
 	}
 	if req.Active != nil {
 		alert.Active = *req.Active
 	}
+	if req.ExcludeKeywords != nil {
+		alert.ExcludeKeywords = filterList(*req.ExcludeKeywords)
+	}
+	if req.AllowedSources != nil {
+		alert.AllowedSources = filterList(*req.AllowedSources)
+	}
+	if req.BlockedSources != nil {
+		alert.BlockedSources = filterList(*req.BlockedSources)
+	}
+	if req.Categories != nil {
+		alert.Categories = filterList(*req.Categories)
+	}
+	if req.Languages != nil {
+		alert.Languages = codeList(*req.Languages)
+	}
+	if req.Locales != nil {
+		alert.Locales = codeList(*req.Locales)
+	}
+
+	if err := validateAlertQuery(alert); err != nil {
+		return nil, err
+	}
+
+	// The next run is only moved when the schedule changes or the alert is
+	// resumed; otherwise it stays as the scheduler left it
+	reschedule := alert.Frequency != before.Frequency || alert.Schedule != before.Schedule ||
+		alert.TimeZone != before.TimeZone || (alert.Active && !before.Active)
+	if reschedule {
+		if err := applySchedule(alert, time.Now()); err != nil {
+			return nil, err
+		}
+	}
 
-	if err := s.alertRepo.Update(alert); err != nil {
+	if err := s.alertRepo.Update(ctx, alert, reschedule); err != nil {
 		return nil, err
 	}
 
 	return alert.ToResponse(), nil
 }
 
-func (s *alertService) DeleteAlert(userID uint, alertID uint) error {
-	alert, err := s.alertRepo.GetByID(alertID)
+func (s *alertService) DeleteAlert(ctx context.Context, userID uint, alertID uint) error {
+	alert, err := s.alertRepo.GetByID(ctx, alertID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("alert not found")
@@ -128,15 +207,72 @@ func (s *alertService) DeleteAlert(userID uint, alertID uint) error {
 		return errors.New("unauthorized access to alert")
 	}
 
-	return s.alertRepo.Delete(alertID)
+	return s.alertRepo.Delete(ctx, alertID)
+}
+
+func (s *alertService) GetAlertHistory(ctx context.Context, userID uint) ([]models.AlertHistory, error) {
+	return s.alertRepo.GetHistoryByUserID(ctx, userID)
+}
+
+// ExplainAlertHistory returns why the article of one of the user's history
+// entries was sent
+func (s *alertService) ExplainAlertHistory(ctx context.Context, userID uint, historyID uint) (*models.AlertHistoryExplanation, error) {
+	history, err := s.getHistory(ctx, historyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Other users' history is reported as missing rather than forbidden
+	if history.Alert.UserID != userID {
+		return nil, errors.New("alert history not found")
+	}
+
+	return explainHistory(history)
+}
+
+// ExplainAnyAlertHistory is ExplainAlertHistory for support staff, who can
+// look at any user's history
+func (s *alertService) ExplainAnyAlertHistory(ctx context.Context, historyID uint) (*models.AlertHistoryExplanation, error) {
+	history, err := s.getHistory(ctx, historyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return explainHistory(history)
+}
+
+func (s *alertService) getHistory(ctx context.Context, historyID uint) (*models.AlertHistory, error) {
+	history, err := s.alertRepo.GetHistoryByID(ctx, historyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("alert history not found")
+		}
+		return nil, err
+	}
+
+	return history, nil
 }
 
-func (s *alertService) GetAlertHistory(userID uint) ([]models.AlertHistory, error) {
-	return s.alertRepo.GetHistoryByUserID(userID)
+func explainHistory(history *models.AlertHistory) (*models.AlertHistoryExplanation, error) {
+	if history.Explanation == nil {
+		return nil, errors.New("no explanation recorded for this alert history")
+	}
+
+	return &models.AlertHistoryExplanation{
+		HistoryID:   history.ID,
+		AlertID:     history.AlertID,
+		AlertTopic:  history.Alert.Topic,
+		NewsTitle:   history.NewsTitle,
+		NewsURL:     history.NewsURL,
+		NewsSource:  history.NewsSource,
+		SentAt:      history.SentAt,
+		Success:     history.Success,
+		Explanation: history.Explanation,
+	}, nil
 }
 
-func (s *alertService) TestAlert(userID uint, alertID uint) error {
-	alert, err := s.alertRepo.GetByID(alertID)
+func (s *alertService) TestAlert(ctx context.Context, userID uint, alertID uint) error {
+	alert, err := s.alertRepo.GetByID(ctx, alertID)
 	if err != nil {
 		return err
 	}
@@ -155,20 +291,190 @@ func (s *alertService) TestAlert(userID uint, alertID uint) error {
 		SentAt:     time.Now(),
 	}
 
-	return s.alertRepo.CreateHistory(history)
+	return s.alertRepo.CreateHistory(ctx, history)
+}
+
+func (s *alertService) GetActiveAlerts(ctx context.Context) ([]models.Alert, error) {
+	return s.alertRepo.GetActiveAlerts(ctx)
 }
 
-func (s *alertService) GetActiveAlerts() ([]models.Alert, error) {
-	return s.alertRepo.GetActiveAlerts()
+// UpdateLastChecked marks the alert checked now, under the scheduler
+// leader's fencing token
+func (s *alertService) UpdateLastChecked(ctx context.Context, alertID uint, token int64) error {
+	return s.alertRepo.UpdateLastChecked(ctx, alertID, time.Now(), token)
 }
 
-func (s *alertService) UpdateLastChecked(alertID uint) error {
-	alert, err := s.alertRepo.GetByID(alertID)
+// ClaimDueAlerts claims up to limit alerts due to run at now and moves each
+// one's next run to its following slot, so the run belongs to this caller.
+// An alert whose schedule can no longer be evaluated is tried again in
+// invalidScheduleRetry. token is the scheduler leader's fencing token.
+func (s *alertService) ClaimDueAlerts(ctx context.Context, now time.Time, limit int, token int64) ([]DueAlert, error) {
+	dueAt := make(map[uint]*time.Time)
+	alerts, err := s.alertRepo.ClaimDueAlerts(ctx, now, limit, token, func(alert *models.Alert) {
+		dueAt[alert.ID] = alert.NextRunAt
+
+		next, err := nextRun(alert, now)
+		if err != nil {
+			logger.Error("Invalid schedule for alert", alert.ID, ":", err)
+			next = now.Add(invalidScheduleRetry)
+		}
+		alert.NextRunAt = &next
+	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	due := make([]DueAlert, len(alerts))
+	for i, alert := range alerts {
+		due[i] = DueAlert{Alert: alert, DueAt: dueAt[alert.ID]}
+	}
+	return due, nil
+}
+
+func (s *alertService) GetScheduledAlerts(ctx context.Context, limit int) ([]models.Alert, error) {
+	return s.alertRepo.GetScheduledAlerts(ctx, limit)
+}
+
+func (s *alertService) CountDueAlerts(ctx context.Context, now time.Time) (int64, error) {
+	return s.alertRepo.CountDueAlerts(ctx, now)
+}
+
+// validateAlertQuery checks that the alert has something to match on and
+// that its query, if any, parses
+func validateAlertQuery(alert *models.Alert) error {
+	if alert.Query == "" {
+		if len(alert.Keywords) == 0 {
+			return errors.New("alert must have keywords or a query")
+		}
+		return nil
+	}
+
+	_, err := query.Parse(alert.Query)
+	return err
+}
+
+// alertQuery compiles the alert's query, falling back to matching any of its
+// keywords when no query is set, and excludes its negative keywords
+func alertQuery(alert *models.Alert) (*query.Query, error) {
+	if alert.Query == "" {
+		return query.FromKeywords(alert.Keywords).Excluding(alert.ExcludeKeywords).WithStemming(alert.Stemming), nil
+	}
+
+	q, err := query.Parse(alert.Query)
+	if err != nil {
+		return nil, err
+	}
+	return q.Excluding(alert.ExcludeKeywords).WithStemming(alert.Stemming), nil
+}
+
+// filterList trims the entries of a filter list and drops blank ones
+func filterList(values []string) models.Keywords {
+	var list models.Keywords
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// matchingAlert builds an alert with the matching fields of the request, with
+// the lists cleaned up
+func matchingAlert(req *models.AlertMatchRequest) *models.Alert {
+	return &models.Alert{
+		Keywords: models.Keywords(req.Keywords),
+		Query:    strings.TrimSpace(req.Query),
+		Stemming: req.Stemming,
+		MinScore: req.MinScore,
+
+		ExcludeKeywords: filterList(req.ExcludeKeywords),
+		AllowedSources:  filterList(req.AllowedSources),
+		BlockedSources:  filterList(req.BlockedSources),
+		Categories:      filterList(req.Categories),
+		Languages:       codeList(req.Languages),
+		Locales:         codeList(req.Locales),
 	}
+}
+
+// codeList is filterList for language and country codes, in lower case
+func codeList(values []string) models.Keywords {
+	list := filterList(values)
+	for i := range list {
+		list[i] = strings.ToLower(list[i])
+	}
+	return list
+}
+
+// alertNewsFilter is the language and locale filter passed to providers when
+// searching for the alert
+func alertNewsFilter(alert *models.Alert) NewsFilter {
+	return NewsFilter{Languages: alert.Languages, Locales: alert.Locales}
+}
+
+// filterAlertArticles keeps the articles from the alert's allowed sources,
+// when it has any, that are not from a blocked source and are in one of the
+// alert's categories, languages and locales, when it has any
+func filterAlertArticles(alert *models.Alert, articles []models.NewsArticle) []models.NewsArticle {
+	articles = filterNews(articles, alertNewsFilter(alert))
+	if len(alert.AllowedSources) == 0 && len(alert.BlockedSources) == 0 && len(alert.Categories) == 0 {
+		return articles
+	}
+
+	var kept []models.NewsArticle
+	for _, article := range articles {
+		if len(alert.AllowedSources) > 0 && !sourceListed(alert.AllowedSources, article) {
+			continue
+		}
+		if sourceListed(alert.BlockedSources, article) {
+			continue
+		}
+		if len(alert.Categories) > 0 && !categoryListed(alert.Categories, article) {
+			continue
+		}
+		kept = append(kept, article)
+	}
+	return kept
+}
 
-	now := time.Now()
-	alert.LastChecked = &now
-	return s.alertRepo.Update(alert)
-}
\ No newline at end of file
+// sourceListed reports whether the article's source name, or the domain of
+// its URL or one of its subdomains, is in the list. Entries may be names,
+// domains or URLs.
+func sourceListed(list []string, article models.NewsArticle) bool {
+	name := sourceHost(strings.TrimSpace(article.Source))
+
+	var host string
+	for _, raw := range []string{article.CanonicalURL, article.URL} {
+		if u, err := url.Parse(raw); err == nil && u.Host != "" {
+			host = sourceHost(u.Hostname())
+			break
+		}
+	}
+
+	for _, entry := range list {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if u, err := url.Parse(entry); err == nil && u.Host != "" {
+			entry = u.Hostname()
+		}
+		entry = sourceHost(entry)
+
+		if entry == name {
+			return true
+		}
+		if host != "" && (host == entry || strings.HasSuffix(host, "."+entry)) {
+			return true
+		}
+	}
+	return false
+}
+
+func categoryListed(list []string, article models.NewsArticle) bool {
+	categories := append([]string{article.Category}, article.Categories...)
+	for _, entry := range list {
+		for _, category := range categories {
+			if strings.EqualFold(strings.TrimSpace(category), entry) {
+				return true
+			}
+		}
+	}
+	return false
+}
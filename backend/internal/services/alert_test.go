@@ -1,15 +1,18 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"news-to-text/internal/models"
+	"news-to-text/internal/query"
 	"news-to-text/internal/repositories"
-
-	"github.com/redis/go-redis/v9"
 )
 
 func TestAlertService_CreateAlert(t *testing.T) {
+	ctx := context.Background()
 	db, err := setupTestDB()
 	if err != nil {
 		t.Fatalf("Failed to setup test database: %v", err)
@@ -25,7 +28,7 @@ func TestAlertService_CreateAlert(t *testing.T) {
 		Email:    "test@example.com",
 		Password: "hashedpassword",
 	}
-	userRepo.Create(testUser)
+	userRepo.Create(ctx, testUser)
 
 	tests := []struct {
 		name    string
@@ -37,9 +40,9 @@ func TestAlertService_CreateAlert(t *testing.T) {
 			name:   "Valid alert creation",
 			userID: testUser.ID,
 			request: &models.AlertCreateRequest{
-				Topic:     "Technology",
-				Keywords:  []string{"AI", "machine learning"},
-				Frequency: models.FrequencyDaily,
+				Topic:             "Technology",
+				Frequency:         models.FrequencyDaily,
+				AlertMatchRequest: models.AlertMatchRequest{Keywords: []string{"AI", "machine learning"}},
 			},
 			wantErr: false,
 		},
@@ -47,17 +50,46 @@ func TestAlertService_CreateAlert(t *testing.T) {
 			name:   "Alert with real-time frequency",
 			userID: testUser.ID,
 			request: &models.AlertCreateRequest{
-				Topic:     "Stocks",
-				Keywords:  []string{"AAPL", "Tesla"},
-				Frequency: models.FrequencyRealTime,
+				Topic:             "Stocks",
+				Frequency:         models.FrequencyRealTime,
+				AlertMatchRequest: models.AlertMatchRequest{Keywords: []string{"AAPL", "Tesla"}},
 			},
 			wantErr: false,
 		},
+		{
+			name:   "Alert with a query instead of keywords",
+			userID: testUser.ID,
+			request: &models.AlertCreateRequest{
+				Topic:             "Rates",
+				Frequency:         models.FrequencyHourly,
+				AlertMatchRequest: models.AlertMatchRequest{Query: `title:("interest rates" OR Fed) NOT source:Blog`},
+			},
+			wantErr: false,
+		},
+		{
+			name:   "Alert with an invalid query",
+			userID: testUser.ID,
+			request: &models.AlertCreateRequest{
+				Topic:             "Rates",
+				Frequency:         models.FrequencyHourly,
+				AlertMatchRequest: models.AlertMatchRequest{Query: "Fed AND (rates OR"},
+			},
+			wantErr: true,
+		},
+		{
+			name:   "Alert without keywords or query",
+			userID: testUser.ID,
+			request: &models.AlertCreateRequest{
+				Topic:     "Nothing",
+				Frequency: models.FrequencyDaily,
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			alert, err := alertService.CreateAlert(tt.userID, tt.request)
+			alert, err := alertService.CreateAlert(ctx, tt.userID, tt.request)
 
 			if tt.wantErr {
 				if err == nil {
@@ -96,6 +128,7 @@ func TestAlertService_CreateAlert(t *testing.T) {
 }
 
 func TestAlertService_GetAlerts(t *testing.T) {
+	ctx := context.Background()
 	db, err := setupTestDB()
 	if err != nil {
 		t.Fatalf("Failed to setup test database: %v", err)
@@ -109,8 +142,8 @@ func TestAlertService_GetAlerts(t *testing.T) {
 	userRepo := repositories.NewUserRepository(db)
 	testUser1 := &models.User{Email: "user1@example.com", Password: "password"}
 	testUser2 := &models.User{Email: "user2@example.com", Password: "password"}
-	userRepo.Create(testUser1)
-	userRepo.Create(testUser2)
+	userRepo.Create(ctx, testUser1)
+	userRepo.Create(ctx, testUser2)
 
 	// Create test alerts
 	alert1 := &models.Alert{
@@ -135,9 +168,9 @@ func TestAlertService_GetAlerts(t *testing.T) {
 		Active:    true,
 	}
 
-	alertRepo.Create(alert1)
-	alertRepo.Create(alert2)
-	alertRepo.Create(alert3)
+	alertRepo.Create(ctx, alert1)
+	alertRepo.Create(ctx, alert2)
+	alertRepo.Create(ctx, alert3)
 
 	tests := []struct {
 		name           string
@@ -163,7 +196,7 @@ func TestAlertService_GetAlerts(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			alerts, err := alertService.GetAlerts(tt.userID)
+			alerts, err := alertService.GetAlerts(ctx, tt.userID)
 
 			if err != nil {
 				t.Errorf("Unexpected error: %v", err)
@@ -184,4 +217,275 @@ func TestAlertService_GetAlerts(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestAlertService_UpdateAlertQuery(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	alertService := NewAlertService(repositories.NewAlertRepository(db), setupTestRedis())
+
+	userRepo := repositories.NewUserRepository(db)
+	testUser := &models.User{Email: "query@example.com", Password: "hashedpassword"}
+	userRepo.Create(ctx, testUser)
+
+	alert, err := alertService.CreateAlert(ctx, testUser.ID, &models.AlertCreateRequest{
+		Topic:             "Apple",
+		Frequency:         models.FrequencyDaily,
+		AlertMatchRequest: models.AlertMatchRequest{Keywords: []string{"Apple"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create alert: %v", err)
+	}
+
+	invalid := "Apple OR title:"
+	_, err = alertService.UpdateAlert(ctx, testUser.ID, alert.ID, &models.AlertUpdateRequest{Query: &invalid})
+
+	var syntaxErr *query.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("Expected a query syntax error but got %v", err)
+	}
+	if syntaxErr.Position != 10 {
+		t.Errorf("Expected error at position 10 but got %d", syntaxErr.Position)
+	}
+
+	valid := "Apple NOT rumor"
+	updated, err := alertService.UpdateAlert(ctx, testUser.ID, alert.ID, &models.AlertUpdateRequest{Query: &valid})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if updated.Query != valid {
+		t.Errorf("Expected query %q but got %q", valid, updated.Query)
+	}
+
+	noKeywords := []string{}
+	cleared := ""
+	_, err = alertService.UpdateAlert(ctx, testUser.ID, alert.ID, &models.AlertUpdateRequest{Keywords: &noKeywords, Query: &cleared})
+	if err == nil || err.Error() != "alert must have keywords or a query" {
+		t.Errorf("Expected error for an alert with nothing to match but got %v", err)
+	}
+}
+
+func TestAlertService_ExplainAlertHistory(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	alertRepo := repositories.NewAlertRepository(db)
+	alertService := NewAlertService(alertRepo, setupTestRedis())
+
+	userRepo := repositories.NewUserRepository(db)
+	owner := &models.User{Email: "owner@example.com", Password: "hashedpassword"}
+	other := &models.User{Email: "other@example.com", Password: "hashedpassword"}
+	userRepo.Create(ctx, owner)
+	userRepo.Create(ctx, other)
+
+	alert, err := alertService.CreateAlert(ctx, owner.ID, &models.AlertCreateRequest{
+		Topic:             "Apple",
+		Frequency:         models.FrequencyDaily,
+		AlertMatchRequest: models.AlertMatchRequest{Query: "Apple NOT rumor"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create alert: %v", err)
+	}
+
+	q, _ := query.Parse("Apple NOT rumor")
+	articles := NewNewsService(nil, nil).ExplainQuery([]models.NewsArticle{
+		{Title: "Apple unveils MacBook", Description: "Apple's new laptop", URL: "https://example.com/mac", Source: "Reuters"},
+	}, q)
+	if err := NewSeenArticleService(alertRepo, nil).RecordDelivery(ctx, alert.ID, articles, nil, 0); err != nil {
+		t.Fatalf("Failed to record delivery: %v", err)
+	}
+
+	history, _ := alertService.GetAlertHistory(ctx, owner.ID)
+	if len(history) != 1 {
+		t.Fatalf("Expected 1 history entry but got %d", len(history))
+	}
+
+	explained, err := alertService.ExplainAlertHistory(ctx, owner.ID, history[0].ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	explanation := explained.Explanation
+	if explained.AlertTopic != "Apple" || explanation.Query != "(Apple AND NOT rumor)" {
+		t.Errorf("Unexpected explanation: %+v", explained)
+	}
+	if len(explanation.Terms) != 2 || explanation.Terms[0].Field != "title" || explanation.Terms[1].Field != "description" {
+		t.Fatalf("Expected Apple to match the title and description, got %+v", explanation.Terms)
+	}
+	if explanation.Score.TitleHits != 1 || explanation.Score.DescriptionHits != 1 || explanation.Score.Score != articles[0].Score {
+		t.Errorf("Unexpected score breakdown %+v for score %v", explanation.Score, articles[0].Score)
+	}
+
+	if _, err := alertService.ExplainAlertHistory(ctx, other.ID, history[0].ID); err == nil || err.Error() != "alert history not found" {
+		t.Errorf("Expected another user's history to be not found, got %v", err)
+	}
+	if _, err := alertService.ExplainAnyAlertHistory(ctx, history[0].ID); err != nil {
+		t.Errorf("Expected support to explain any history, got %v", err)
+	}
+
+	if err := alertService.TestAlert(ctx, owner.ID, alert.ID); err != nil {
+		t.Fatalf("Failed to send test alert: %v", err)
+	}
+	history, _ = alertService.GetAlertHistory(ctx, owner.ID)
+	for _, entry := range history {
+		if entry.ID == explained.HistoryID {
+			continue
+		}
+		if _, err := alertService.ExplainAlertHistory(ctx, owner.ID, entry.ID); err == nil || err.Error() != "no explanation recorded for this alert history" {
+			t.Errorf("Expected a test alert to have no explanation, got %v", err)
+		}
+	}
+}
+
+func TestAlertFilters(t *testing.T) {
+	articles := []models.NewsArticle{
+		{Title: "Tesla recalls Model Y", Source: "Reuters", URL: "https://www.reuters.com/business/tesla", Category: "business"},
+		{Title: "Nikola Tesla museum reopens", Source: "AP", URL: "https://apnews.com/article/tesla", Category: "culture"},
+		{Title: "Tesla stock climbs", Source: "Daily Aggregator", URL: "https://news.aggregator.io/tesla", Categories: []string{"Business", "Markets"}, Language: "de", Locale: "de"},
+		{Title: "Tesla opens new factory", Source: "Bloomberg", URL: "https://www.bloomberg.com/news/tesla", Language: "en", Locale: "us"},
+	}
+
+	tests := []struct {
+		name     string
+		alert    models.Alert
+		expected []string
+	}{
+		{
+			name:     "No filters",
+			alert:    models.Alert{Keywords: models.Keywords{"Tesla"}},
+			expected: []string{"Reuters", "AP", "Daily Aggregator", "Bloomberg"},
+		},
+		{
+			name:     "Excluded phrase",
+			alert:    models.Alert{Keywords: models.Keywords{"Tesla"}, ExcludeKeywords: models.Keywords{"Nikola Tesla"}},
+			expected: []string{"Reuters", "Daily Aggregator", "Bloomberg"},
+		},
+		{
+			name:     "Excluded keyword with a query",
+			alert:    models.Alert{Query: "Tesla OR museum", ExcludeKeywords: models.Keywords{"stock", "factory"}},
+			expected: []string{"Reuters", "AP"},
+		},
+		{
+			name:     "Blocked domain matches subdomains",
+			alert:    models.Alert{Keywords: models.Keywords{"Tesla"}, BlockedSources: models.Keywords{"aggregator.io"}},
+			expected: []string{"Reuters", "AP", "Bloomberg"},
+		},
+		{
+			name:     "Allowed sources by name and URL",
+			alert:    models.Alert{Keywords: models.Keywords{"Tesla"}, AllowedSources: models.Keywords{"reuters", "https://www.bloomberg.com/"}},
+			expected: []string{"Reuters", "Bloomberg"},
+		},
+		{
+			name:     "Allowed and blocked",
+			alert:    models.Alert{Keywords: models.Keywords{"Tesla"}, AllowedSources: models.Keywords{"reuters.com", "AP"}, BlockedSources: models.Keywords{"apnews.com"}},
+			expected: []string{"Reuters"},
+		},
+		{
+			name:     "Categories",
+			alert:    models.Alert{Keywords: models.Keywords{"Tesla"}, Categories: models.Keywords{"business"}},
+			expected: []string{"Reuters", "Daily Aggregator"},
+		},
+		{
+			name:     "Languages keep articles of unknown language",
+			alert:    models.Alert{Keywords: models.Keywords{"Tesla"}, Languages: models.Keywords{"en", "fr"}},
+			expected: []string{"Reuters", "AP", "Bloomberg"},
+		},
+		{
+			name:     "Locales",
+			alert:    models.Alert{Keywords: models.Keywords{"Tesla"}, Locales: models.Keywords{"de"}},
+			expected: []string{"Reuters", "AP", "Daily Aggregator"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := alertQuery(&tt.alert)
+			if err != nil {
+				t.Fatalf("Failed to compile alert query: %v", err)
+			}
+
+			var matched []models.NewsArticle
+			for _, article := range articles {
+				if q.Match(article) {
+					matched = append(matched, article)
+				}
+			}
+
+			var sources []string
+			for _, article := range filterAlertArticles(&tt.alert, matched) {
+				sources = append(sources, article.Source)
+			}
+			if strings.Join(sources, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected %v but got %v", tt.expected, sources)
+			}
+		})
+	}
+}
+
+func TestAlertService_SavesFilters(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	alertService := NewAlertService(repositories.NewAlertRepository(db), setupTestRedis())
+
+	created, err := alertService.CreateAlert(ctx, 1, &models.AlertCreateRequest{
+		Topic:             "Tesla",
+		Frequency:         models.FrequencyDaily,
+		AlertMatchRequest: models.AlertMatchRequest{
+			Keywords:        []string{"Tesla"},
+			ExcludeKeywords: []string{" Nikola Tesla ", ""},
+			BlockedSources:  []string{"aggregator.io"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create alert: %v", err)
+	}
+
+	categories := []string{"Business"}
+	updated, err := alertService.UpdateAlert(ctx, 1, created.ID, &models.AlertUpdateRequest{Categories: &categories})
+	if err != nil {
+		t.Fatalf("Failed to update alert: %v", err)
+	}
+
+	if len(updated.ExcludeKeywords) != 1 || updated.ExcludeKeywords[0] != "Nikola Tesla" {
+		t.Errorf("Expected the trimmed exclusion but got %v", updated.ExcludeKeywords)
+	}
+	if len(updated.BlockedSources) != 1 || len(updated.Categories) != 1 || len(updated.AllowedSources) != 0 {
+		t.Errorf("Expected the saved filters but got %+v", updated)
+	}
+}
+
+func TestAlertService_CancelledContext(t *testing.T) {
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	alertService := NewAlertService(repositories.NewAlertRepository(db), nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A cancelled request stops before its queries run
+	req := &models.AlertCreateRequest{Topic: "Markets", Frequency: models.FrequencyDaily, AlertMatchRequest: models.AlertMatchRequest{Keywords: []string{"stocks"}}}
+	if _, err := alertService.CreateAlert(ctx, 1, req); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the create to be cancelled but got %v", err)
+	}
+	if _, err := alertService.GetAlerts(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the read to be cancelled but got %v", err)
+	}
+
+	alerts, err := alertService.GetAlerts(context.Background(), 1)
+	if err != nil || len(alerts) != 0 {
+		t.Errorf("Expected the cancelled create not to store the alert but got %v, %v", alerts, err)
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -14,11 +15,11 @@ import (
 )
 
 type AuthService interface {
-	Register(req *models.UserCreateRequest) (*models.UserResponse, string, error)
-	Login(req *models.UserLoginRequest) (*models.UserResponse, string, error)
-	Logout(token string) error
-	ValidateToken(token string) (*auth.Claims, error)
-	GetUserByID(id uint) (*models.UserResponse, error)
+	Register(ctx context.Context, req *models.UserCreateRequest) (*models.UserResponse, string, error)
+	Login(ctx context.Context, req *models.UserLoginRequest) (*models.UserResponse, string, error)
+	Logout(ctx context.Context, token string) error
+	ValidateToken(ctx context.Context, token string) (*auth.Claims, error)
+	GetUserByID(ctx context.Context, id uint) (*models.UserResponse, error)
 }
 
 type authService struct {
@@ -35,9 +36,9 @@ func NewAuthService(userRepo repositories.UserRepository, redisClient *redis.Cli
 	}
 }
 
-func (s *authService) Register(req *models.UserCreateRequest) (*models.UserResponse, string, error) {
+func (s *authService) Register(ctx context.Context, req *models.UserCreateRequest) (*models.UserResponse, string, error) {
 	// Check if user already exists
-	existingUser, err := s.userRepo.GetByEmail(req.Email)
+	existingUser, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, "", err
 	}
@@ -57,7 +58,7 @@ func (s *authService) Register(req *models.UserCreateRequest) (*models.UserRespo
 		Password: hashedPassword,
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
+	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, "", err
 	}
 
@@ -70,9 +71,9 @@ func (s *authService) Register(req *models.UserCreateRequest) (*models.UserRespo
 	return user.ToResponse(), token, nil
 }
 
-func (s *authService) Login(req *models.UserLoginRequest) (*models.UserResponse, string, error) {
+func (s *authService) Login(ctx context.Context, req *models.UserLoginRequest) (*models.UserResponse, string, error) {
 	// Get user by email
-	user, err := s.userRepo.GetByEmail(req.Email)
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, "", errors.New("invalid credentials")
@@ -94,15 +95,13 @@ func (s *authService) Login(req *models.UserLoginRequest) (*models.UserResponse,
 	return user.ToResponse(), token, nil
 }
 
-func (s *authService) Logout(token string) error {
+func (s *authService) Logout(ctx context.Context, token string) error {
 	// Add token to blacklist in Redis with expiration
-	ctx := redis.Context()
 	return s.redis.Set(ctx, "blacklist:"+token, "true", 24*time.Hour).Err()
 }
 
-func (s *authService) ValidateToken(token string) (*auth.Claims, error) {
+func (s *authService) ValidateToken(ctx context.Context, token string) (*auth.Claims, error) {
 	// Check if token is blacklisted
-	ctx := redis.Context()
 	isBlacklisted, err := s.redis.Exists(ctx, "blacklist:"+token).Result()
 	if err != nil {
 		return nil, err
@@ -114,8 +113,8 @@ func (s *authService) ValidateToken(token string) (*auth.Claims, error) {
 	return s.jwtManager.ValidateToken(token)
 }
 
-func (s *authService) GetUserByID(id uint) (*models.UserResponse, error) {
-	user, err := s.userRepo.GetByID(id)
+func (s *authService) GetUserByID(ctx context.Context, id uint) (*models.UserResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"testing"
 
 	"news-to-text/internal/models"
@@ -18,7 +19,15 @@ func setupTestDB() (*gorm.DB, error) {
 		return nil, err
 	}
 
-	err = db.AutoMigrate(&models.User{}, &models.Alert{})
+	// Every connection to ":memory:" opens its own empty database, so keep the
+	// pool to one connection for the concurrent scheduler workers
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	err = db.AutoMigrate(&models.User{}, &models.Alert{}, &models.AlertHistory{}, &models.SeenArticle{}, &models.NewsSource{}, &models.Article{}, &models.Notification{}, &models.SchedulerLease{})
 	if err != nil {
 		return nil, err
 	}
@@ -36,12 +45,13 @@ func setupTestRedis() *redis.Client {
 	})
 
 	// Clear test database
-	client.FlushDB(redis.Context())
+	client.FlushDB(context.Background())
 
 	return client
 }
 
 func TestAuthService_Register(t *testing.T) {
+	ctx := context.Background()
 	db, err := setupTestDB()
 	if err != nil {
 		t.Fatalf("Failed to setup test database: %v", err)
@@ -84,7 +94,7 @@ func TestAuthService_Register(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			user, token, err := authService.Register(tt.request)
+			user, token, err := authService.Register(ctx, tt.request)
 
 			if tt.wantErr {
 				if err == nil {
@@ -116,6 +126,7 @@ func TestAuthService_Register(t *testing.T) {
 }
 
 func TestAuthService_Login(t *testing.T) {
+	ctx := context.Background()
 	db, err := setupTestDB()
 	if err != nil {
 		t.Fatalf("Failed to setup test database: %v", err)
@@ -131,7 +142,7 @@ func TestAuthService_Login(t *testing.T) {
 		Email:    "test@example.com",
 		Password: hashedPassword,
 	}
-	userRepo.Create(testUser)
+	userRepo.Create(ctx, testUser)
 
 	tests := []struct {
 		name    string
@@ -166,7 +177,7 @@ func TestAuthService_Login(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			user, token, err := authService.Login(tt.request)
+			user, token, err := authService.Login(ctx, tt.request)
 
 			if tt.wantErr {
 				if err == nil {
@@ -2,23 +2,63 @@ package services
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"news-to-text/internal/models"
+	"news-to-text/internal/query"
 	"news-to-text/pkg/logger"
 )
 
+const (
+	// initialLookback is how far back a newly created alert looks for stored
+	// articles on its first run
+	initialLookback = 24 * time.Hour
+
+	// lookbackOverlap re-reads articles ingested shortly before the last
+	// check, so nothing stored while an alert was being processed is missed.
+	// The seen-set keeps them from being sent twice.
+	lookbackOverlap = 15 * time.Minute
+
+	// schedulerBatchSize is how many due alerts the scheduler claims at once
+	schedulerBatchSize = 500
+
+	// upcomingRunsLimit is how many of the next alerts to run the scheduler
+	// state lists
+	upcomingRunsLimit = 20
+)
+
+// SchedulerConfig tunes the alert scheduler
+type SchedulerConfig struct {
+	// Interval is how often the scheduler looks for alerts due to run
+	Interval time.Duration
+	// MaxCatchUp is how late a missed run can be and still be run
+	MaxCatchUp time.Duration
+	// Workers is how many alerts are delivered at once
+	Workers int
+	// AlertTimeout bounds the delivery of a single alert
+	AlertTimeout time.Duration
+}
+
 type BackgroundService interface {
 	Start()
 	Stop()
 	ProcessAlerts() error
+	State(ctx context.Context) (*models.SchedulerState, error)
 }
 
 type backgroundService struct {
 	alertService        AlertService
 	newsService         NewsService
+	ingestionService    IngestionService
+	seenService         SeenArticleService
 	notificationService NotificationService
+	config              SchedulerConfig
+	leader              LeaderElection
+	lastRun             *models.SchedulerRun
+	queued              int64 // alerts waiting for a worker
 	ctx                 context.Context
 	cancel              context.CancelFunc
 	wg                  sync.WaitGroup
@@ -29,14 +69,22 @@ type backgroundService struct {
 func NewBackgroundService(
 	alertService AlertService,
 	newsService NewsService,
+	ingestionService IngestionService,
+	seenService SeenArticleService,
 	notificationService NotificationService,
+	config SchedulerConfig,
+	leader LeaderElection,
 ) BackgroundService {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &backgroundService{
 		alertService:        alertService,
 		newsService:         newsService,
+		ingestionService:    ingestionService,
+		seenService:         seenService,
 		notificationService: notificationService,
+		config:              config,
+		leader:              leader,
 		ctx:                 ctx,
 		cancel:              cancel,
 	}
@@ -53,11 +101,17 @@ func (s *backgroundService) Start() {
 
 	logger.Info("Starting background service...")
 
-	// Start different job processors
-	s.wg.Add(3)
-	go s.realtimeProcessor()
-	go s.hourlyProcessor()
-	go s.dailyProcessor()
+	// Only the elected replica runs alerts
+	if s.leader != nil {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.leader.Run(s.ctx)
+		}()
+	}
+
+	s.wg.Add(1)
+	go s.scheduler()
 
 	s.wg.Wait()
 }
@@ -77,140 +131,436 @@ func (s *backgroundService) Stop() {
 	logger.Info("Background service stopped")
 }
 
-func (s *backgroundService) realtimeProcessor() {
+// scheduler runs the alerts whose next run has come and queues the
+// notifications held in quiet hours that have ended, on the leader replica
+// only. It makes a pass as soon as it starts, so runs missed while the
+// service was down are caught up, then one every interval.
+func (s *backgroundService) scheduler() {
 	defer s.wg.Done()
 
-	ticker := time.NewTicker(5 * time.Minute) // Check every 5 minutes for real-time alerts
+	ticker := time.NewTicker(s.config.Interval)
 	defer ticker.Stop()
 
 	for {
+		if s.isLeader() {
+			if err := s.processDueAlerts(); err != nil {
+				logger.Error("Error processing scheduled alerts:", err)
+			}
+			if err := s.releaseHeldNotifications(); err != nil {
+				logger.Error("Error releasing held notifications:", err)
+			}
+		} else {
+			logger.Debug("Not the leader, skipping scheduler pass")
+		}
+
 		select {
 		case <-s.ctx.Done():
 			return
 		case <-ticker.C:
-			logger.Debug("Processing real-time alerts...")
-			if err := s.processAlertsByFrequency(models.FrequencyRealTime); err != nil {
-				logger.Error("Error processing real-time alerts:", err)
-			}
 		}
 	}
 }
 
-func (s *backgroundService) hourlyProcessor() {
-	defer s.wg.Done()
+// processDueAlerts claims the alerts due to run, moving each one's next run
+// on first so one that fails waits for its next slot rather than being
+// retried every pass. An alert runs once however many of its slots were
+// missed; when the first missed slot is older than the catch-up window the
+// run is skipped and the alert marked checked, so the stale backlog is not
+// sent. Alerts that had no next run yet are only scheduled.
+func (s *backgroundService) processDueAlerts() error {
+	token, leader := s.fencingToken()
+	if !leader {
+		return errors.New("not the leader")
+	}
 
-	ticker := time.NewTicker(1 * time.Hour)
-	defer ticker.Stop()
+	now := time.Now()
+	run := &models.SchedulerRun{StartedAt: now, FencingToken: token}
+	lag := &queueLag{}
+	defer func() {
+		run.FinishedAt = time.Now()
+		lag.record(run)
+		s.mu.Lock()
+		s.lastRun = run
+		s.mu.Unlock()
+	}()
 
 	for {
-		select {
-		case <-s.ctx.Done():
-			return
-		case <-ticker.C:
-			logger.Debug("Processing hourly alerts...")
-			if err := s.processAlertsByFrequency(models.FrequencyHourly); err != nil {
-				logger.Error("Error processing hourly alerts:", err)
+		claimed, err := s.alertService.ClaimDueAlerts(s.ctx, now, schedulerBatchSize, token)
+		if err != nil {
+			run.Error = err.Error()
+			return err
+		}
+		run.Claimed += len(claimed)
+
+		var due []DueAlert
+		for _, c := range claimed {
+			switch {
+			case c.DueAt == nil:
+				run.Scheduled++
+			case now.Sub(*c.DueAt) > s.config.MaxCatchUp:
+				logger.Info("Skipping run of alert", c.Alert.ID, "due at", *c.DueAt, "outside the catch-up window")
+				run.Skipped++
+				if err := s.alertService.UpdateLastChecked(s.ctx, c.Alert.ID, token); err != nil {
+					logger.Error("Error updating last checked time for alert", c.Alert.ID, ":", err)
+				}
+			default:
+				due = append(due, c)
 			}
 		}
+
+		if err := s.runAlerts(due, run, lag); err != nil {
+			run.Error = err.Error()
+			return err
+		}
+
+		if len(claimed) < schedulerBatchSize {
+			return nil
+		}
 	}
 }
 
-func (s *backgroundService) dailyProcessor() {
-	defer s.wg.Done()
-
-	// Calculate time until next 9 AM
-	now := time.Now()
-	next9AM := time.Date(now.Year(), now.Month(), now.Day(), 9, 0, 0, 0, now.Location())
-	if now.After(next9AM) {
-		next9AM = next9AM.Add(24 * time.Hour)
+// State reports the scheduler's configuration, its last pass and the alerts
+// that run next
+func (s *backgroundService) State(ctx context.Context) (*models.SchedulerState, error) {
+	s.mu.RLock()
+	state := &models.SchedulerState{
+		Running:      s.running,
+		Leader:       s.isLeader(),
+		Interval:     s.config.Interval.String(),
+		MaxCatchUp:   s.config.MaxCatchUp.String(),
+		Workers:      s.workers(),
+		AlertTimeout: s.config.AlertTimeout.String(),
+		Queued:       atomic.LoadInt64(&s.queued),
+		LastRun:      s.lastRun,
+		Upcoming:     []models.ScheduledAlert{},
 	}
+	s.mu.RUnlock()
 
-	timer := time.NewTimer(time.Until(next9AM))
-	defer timer.Stop()
+	due, err := s.alertService.CountDueAlerts(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	state.Due = due
 
-	for {
-		select {
-		case <-s.ctx.Done():
-			return
-		case <-timer.C:
-			logger.Debug("Processing daily alerts...")
-			if err := s.processAlertsByFrequency(models.FrequencyDaily); err != nil {
-				logger.Error("Error processing daily alerts:", err)
-			}
-			// Reset timer for next day
-			timer.Reset(24 * time.Hour)
-		}
+	alerts, err := s.alertService.GetScheduledAlerts(ctx, upcomingRunsLimit)
+	if err != nil {
+		return nil, err
+	}
+	for _, alert := range alerts {
+		state.Upcoming = append(state.Upcoming, models.ScheduledAlert{
+			AlertID:     alert.ID,
+			UserID:      alert.UserID,
+			Topic:       alert.Topic,
+			Frequency:   alert.Frequency,
+			Schedule:    alert.Schedule,
+			TimeZone:    alert.TimeZone,
+			NextRunAt:   *alert.NextRunAt,
+			LastChecked: alert.LastChecked,
+		})
 	}
+
+	return state, nil
 }
 
-func (s *backgroundService) processAlertsByFrequency(frequency models.AlertFrequency) error {
-	alerts, err := s.alertService.GetActiveAlerts()
+// releaseHeldNotifications queues, for each user, the notifications held
+// during quiet hours that have now ended, coalesced into one message
+func (s *backgroundService) releaseHeldNotifications() error {
+	token, leader := s.fencingToken()
+	if !leader {
+		return errors.New("not the leader")
+	}
+
+	now := time.Now()
+	held, err := s.seenService.HeldDeliveries(s.ctx, now)
 	if err != nil {
 		return err
 	}
 
-	for _, alert := range alerts {
-		if alert.Frequency != frequency {
-			continue
+	var users []uint
+	byUser := make(map[uint][]models.AlertHistory)
+	for _, history := range held {
+		userID := history.Alert.UserID
+		if _, ok := byUser[userID]; !ok {
+			users = append(users, userID)
 		}
+		byUser[userID] = append(byUser[userID], history)
+	}
 
-		// Check if we should process this alert based on last checked time
-		if !s.shouldProcessAlert(&alert, frequency) {
-			continue
+	for _, userID := range users {
+		// Stop if another replica has taken over, so it doesn't queue the
+		// same held notifications
+		if !s.isLeader() {
+			return errors.New("lost leadership while releasing held notifications")
 		}
 
-		if err := s.processAlert(&alert); err != nil {
-			logger.Error("Error processing alert", alert.ID, ":", err)
+		histories := byUser[userID]
+		notification := &models.Notification{
+			UserID:        userID,
+			Message:       s.notificationService.FormatHeldMessage(histories),
+			NextAttemptAt: now,
+		}
+		if err := s.seenService.RecordRelease(s.ctx, histories, notification, token); err != nil {
+			logger.Error("Failed to release held notifications for user", userID, ":", err)
 			continue
 		}
+		logger.Info("Queued", len(histories), "held notifications for user", userID)
+	}
+
+	return nil
+}
+
+// isLeader reports whether this replica should run alerts: always, when
+// there is no leader election
+func (s *backgroundService) isLeader() bool {
+	return s.leader == nil || s.leader.IsLeader()
+}
+
+// fencingToken is the leader's token for its current term and whether this
+// replica is the leader. Without an election the token is 0, which the
+// repositories don't check.
+func (s *backgroundService) fencingToken() (int64, bool) {
+	if s.leader == nil {
+		return 0, true
+	}
+	token := s.leader.Token()
+	return token, token != 0
+}
+
+// runAlerts scans the stored articles once for all the due alerts, then
+// hands them to a pool of workers that notify each one's owner and mark the
+// alerts processed without error as checked. Each delivery has its own
+// deadline. The outcomes are counted in run and the time alerts waited past
+// their due time in lag.
+func (s *backgroundService) runAlerts(due []DueAlert, run *models.SchedulerRun, lag *queueLag) error {
+	if len(due) == 0 {
+		return nil
+	}
+
+	alerts := make([]models.Alert, len(due))
+	dueAt := make(map[uint]time.Time, len(due))
+	for i, d := range due {
+		alerts[i] = d.Alert
+		dueAt[d.Alert.ID] = *d.DueAt
+	}
 
-		// Update last checked time
-		if err := s.alertService.UpdateLastChecked(alert.ID); err != nil {
-			logger.Error("Error updating last checked time for alert", alert.ID, ":", err)
+	routed, err := s.routeArticles(s.ctx, alerts)
+	if err != nil {
+		run.Failed += len(due)
+		return err
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan routedAlert)
+
+	for i := 0; i < s.workers() && i < len(routed); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range queue {
+				atomic.AddInt64(&s.queued, -1)
+				waited := time.Since(dueAt[r.alert.ID])
+				err := s.runAlert(r, run.FencingToken)
+
+				mu.Lock()
+				lag.add(waited)
+				switch {
+				case err == nil:
+					run.Run++
+				case errors.Is(err, context.DeadlineExceeded):
+					run.TimedOut++
+					fallthrough
+				default:
+					run.Failed++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	atomic.AddInt64(&s.queued, int64(len(routed)))
+	sent := 0
+enqueue:
+	for _, r := range routed {
+		select {
+		case queue <- r:
+			sent++
+		case <-s.ctx.Done():
+			break enqueue
 		}
 	}
+	close(queue)
+	wg.Wait()
+
+	// Alerts never handed to a worker because the service is stopping
+	atomic.AddInt64(&s.queued, -int64(len(routed)-sent))
+	run.Failed += len(routed) - sent
+
+	return s.ctx.Err()
+}
+
+// queueLag measures how long alerts waited past their due time before a
+// worker picked them up
+type queueLag struct {
+	total time.Duration
+	max   time.Duration
+	count int
+}
+
+func (l *queueLag) add(waited time.Duration) {
+	l.total += waited
+	l.count++
+	if waited > l.max {
+		l.max = waited
+	}
+}
+
+func (l *queueLag) record(run *models.SchedulerRun) {
+	run.MaxQueueLagMs = l.max.Milliseconds()
+	if l.count > 0 {
+		run.AvgQueueLagMs = (l.total / time.Duration(l.count)).Milliseconds()
+	}
+}
+
+// runAlert delivers one routed alert under its deadline and marks it checked
+// when that succeeds. It gives up when this replica is no longer the leader,
+// and the writes of a leader that has been replaced fail on its stale token.
+func (s *backgroundService) runAlert(r routedAlert, token int64) error {
+	ctx, cancel := s.alertContext()
+	defer cancel()
+
+	if !s.isLeader() {
+		logger.Error("Lost leadership before processing alert", r.alert.ID)
+		return errors.New("lost leadership before processing the alert")
+	}
+
+	if err := s.deliver(ctx, r, token); err != nil {
+		logger.Error("Error processing alert", r.alert.ID, ":", err)
+		return err
+	}
 
+	// Update last checked time
+	if err := s.alertService.UpdateLastChecked(s.ctx, r.alert.ID, token); err != nil {
+		logger.Error("Error updating last checked time for alert", r.alert.ID, ":", err)
+	}
 	return nil
 }
 
-func (s *backgroundService) shouldProcessAlert(alert *models.Alert, frequency models.AlertFrequency) bool {
-	if alert.LastChecked == nil {
-		return true
+// alertContext is the context one alert is processed under: cancelled when
+// the service stops and after the alert timeout
+func (s *backgroundService) alertContext() (context.Context, context.CancelFunc) {
+	if s.config.AlertTimeout <= 0 {
+		return context.WithCancel(s.ctx)
+	}
+	return context.WithTimeout(s.ctx, s.config.AlertTimeout)
+}
+
+// workers is the size of the delivery pool, at least one
+func (s *backgroundService) workers() int {
+	if s.config.Workers < 1 {
+		return 1
 	}
+	return s.config.Workers
+}
+
+// routedAlert is an alert with the stored articles that match its query
+type routedAlert struct {
+	alert    *models.Alert
+	query    *query.Query
+	articles []models.NewsArticle
+	err      error
+}
 
+// routeArticles reads the articles ingested since the earliest last check of
+// the alerts and routes each one to every alert whose query it matches,
+// through an index of all the alerts' terms. An article only goes to the
+// alerts that had not yet seen it at their last check.
+func (s *backgroundService) routeArticles(ctx context.Context, alerts []models.Alert) ([]routedAlert, error) {
 	now := time.Now()
-	timeSinceLastCheck := now.Sub(*alert.LastChecked)
+	routed := make([]routedAlert, len(alerts))
+	since := make(map[uint]time.Time, len(alerts))
+	earliest := now
+
+	ix := query.NewIndex()
+	for i := range alerts {
+		alert := &alerts[i]
+		routed[i].alert = alert
+
+		// Match articles against the alert query, or its keywords
+		q, err := alertQuery(alert)
+		if err != nil {
+			routed[i].err = err
+			continue
+		}
+		routed[i].query = q
+		ix.Add(alert.ID, q)
 
-	switch frequency {
-	case models.FrequencyRealTime:
-		return timeSinceLastCheck >= 5*time.Minute
-	case models.FrequencyHourly:
-		return timeSinceLastCheck >= 1*time.Hour
-	case models.FrequencyDaily:
-		return timeSinceLastCheck >= 24*time.Hour
-	default:
-		return false
+		// Read the articles ingested since the last check
+		since[alert.ID] = now.Add(-initialLookback)
+		if alert.LastChecked != nil {
+			since[alert.ID] = alert.LastChecked.Add(-lookbackOverlap)
+		}
+		if since[alert.ID].Before(earliest) {
+			earliest = since[alert.ID]
+		}
 	}
-}
 
-func (s *backgroundService) processAlert(alert *models.Alert) error {
-	logger.Debug("Processing alert:", alert.ID, "Topic:", alert.Topic)
+	if ix.Len() == 0 {
+		return routed, nil
+	}
 
-	// Fetch news articles based on keywords
-	articles, err := s.newsService.FetchNewsByKeywords(alert.Keywords)
+	articles, err := s.ingestionService.GetArticlesSince(ctx, earliest)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Filter articles that were published since last check
-	if alert.LastChecked != nil {
-		var recentArticles []models.NewsArticle
-		for _, article := range articles {
-			if article.PublishedAt.After(*alert.LastChecked) {
-				recentArticles = append(recentArticles, article)
+	matches := make(map[uint][]models.NewsArticle)
+	for _, article := range articles {
+		for _, id := range ix.Match(article) {
+			if article.IngestedAt.IsZero() || article.IngestedAt.After(since[id]) {
+				matches[id] = append(matches[id], article)
 			}
 		}
-		articles = recentArticles
+	}
+
+	for i := range routed {
+		routed[i].articles = matches[routed[i].alert.ID]
+	}
+
+	logger.Debug("Routed", len(articles), "articles to", ix.Len(), "alerts")
+	return routed, nil
+}
+
+// deliver ranks the articles routed to an alert and queues a notification
+// to its owner of the ones not sent before, under the leader's fencing
+// token. The dispatcher sends it, so a failed send is retried rather than
+// lost.
+func (s *backgroundService) deliver(ctx context.Context, r routedAlert, token int64) error {
+	if r.err != nil {
+		return r.err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	alert := r.alert
+	logger.Debug("Processing alert:", alert.ID, "Topic:", alert.Topic)
+
+	// Nothing is texted until the owner has verified their phone number
+	if !alert.User.HasVerifiedPhone() {
+		logger.Debug("Skipping alert", alert.ID, "until user", alert.UserID, "verifies a phone number")
+		return nil
+	}
+
+	// Drop articles outside the alert's sources and categories, then those
+	// ranked below its threshold
+	articles := filterAlertArticles(alert, r.articles)
+	articles = filterByScore(s.newsService.ExplainQuery(articles, r.query), alert.MinScore)
+
+	// Skip stories already delivered for this alert
+	articles, err := s.seenService.FilterUnseen(ctx, alert.ID, articles)
+	if err != nil {
+		return err
 	}
 
 	// If no new articles, skip notification
@@ -221,16 +571,31 @@ func (s *backgroundService) processAlert(alert *models.Alert) error {
 
 	logger.Info("Found", len(articles), "new articles for alert:", alert.ID)
 
-	// Send notification
-	if err := s.notificationService.SendNewsAlert(&alert.User, alert, articles); err != nil {
-		logger.Error("Failed to send notification for alert", alert.ID, ":", err)
+	// Hold the notification while the owner has quiet hours, unless the
+	// alert is urgent
+	if until, quiet := quietHoursEnd(&alert.User, time.Now()); quiet && !alert.Urgent {
+		if err := s.seenService.RecordHeld(ctx, alert.ID, articles, until, token); err != nil {
+			return err
+		}
+		logger.Info("Holding notification for alert", alert.ID, "until", until)
+		return nil
+	}
+
+	// Queue the notification with its history rows and seen-set entries
+	notification := &models.Notification{
+		UserID:        alert.UserID,
+		Message:       s.notificationService.FormatNewsMessage(alert, articles),
+		NextAttemptAt: time.Now(),
+	}
+	if err := s.seenService.RecordDelivery(ctx, alert.ID, articles, notification, token); err != nil {
 		return err
 	}
 
-	logger.Info("Successfully sent notification for alert:", alert.ID)
+	logger.Info("Queued notification", notification.ID, "for alert:", alert.ID)
 	return nil
 }
 
+// ProcessAlerts runs the alerts that are due now
 func (s *backgroundService) ProcessAlerts() error {
-	return s.processAlertsByFrequency(models.FrequencyRealTime)
+	return s.processDueAlerts()
 }
\ No newline at end of file
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/repositories"
+)
+
+func TestBackgroundService_RouteArticles(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	provider := &stubProvider{name: "rss", articles: []models.NewsArticle{
+		{Title: "Apple unveils new iPhone", URL: "https://example.com/iphone"},
+		{Title: "Tesla recalls vehicles", URL: "https://example.com/tesla"},
+		{Title: "Apple and Tesla shares rise", URL: "https://example.com/shares"},
+	}}
+	ingestionService := NewIngestionService([]NewsProvider{provider}, repositories.NewArticleRepository(db), nil, time.Minute, 0)
+	if _, err := ingestionService.Ingest(ctx); err != nil {
+		t.Fatalf("Failed to ingest: %v", err)
+	}
+
+	background := &backgroundService{ingestionService: ingestionService}
+
+	// The last alert was checked after the articles were ingested
+	later := time.Now().Add(time.Hour)
+	alerts := []models.Alert{
+		{ID: 1, Keywords: models.Keywords{"Apple"}},
+		{ID: 2, Query: "Tesla NOT Apple"},
+		{ID: 3, Query: "Apple AND"},
+		{ID: 4, Keywords: models.Keywords{"Tesla"}, LastChecked: &later},
+	}
+
+	routed, err := background.routeArticles(ctx, alerts)
+	if err != nil {
+		t.Fatalf("Failed to route articles: %v", err)
+	}
+
+	expected := map[uint][]string{
+		1: {"https://example.com/iphone", "https://example.com/shares"},
+		2: {"https://example.com/tesla"},
+		3: nil,
+		4: nil,
+	}
+
+	if len(routed) != len(alerts) {
+		t.Fatalf("Expected %d routed alerts but got %d", len(alerts), len(routed))
+	}
+	for _, r := range routed {
+		var urls []string
+		for _, article := range r.articles {
+			urls = append(urls, article.URL)
+		}
+
+		want := expected[r.alert.ID]
+		if len(urls) != len(want) {
+			t.Errorf("Alert %d: expected %v but got %v", r.alert.ID, want, urls)
+			continue
+		}
+		for _, url := range want {
+			if !containsString(urls, url) {
+				t.Errorf("Alert %d: expected %v but got %v", r.alert.ID, want, urls)
+				break
+			}
+		}
+	}
+
+	if routed[2].err == nil {
+		t.Errorf("Expected the invalid query to be reported")
+	}
+}
+
+// slowSeenService blocks deliveries of the slow alert until their context
+// is done, and records how many deliveries run at once
+type slowSeenService struct {
+	SeenArticleService
+	slowAlertID uint
+	active      int32
+	maxActive   int32
+}
+
+func (s *slowSeenService) FilterUnseen(ctx context.Context, alertID uint, articles []models.NewsArticle) ([]models.NewsArticle, error) {
+	active := atomic.AddInt32(&s.active, 1)
+	defer atomic.AddInt32(&s.active, -1)
+	for {
+		max := atomic.LoadInt32(&s.maxActive)
+		if active <= max || atomic.CompareAndSwapInt32(&s.maxActive, max, active) {
+			break
+		}
+	}
+
+	if alertID == s.slowAlertID {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	time.Sleep(10 * time.Millisecond)
+	return s.SeenArticleService.FilterUnseen(ctx, alertID, articles)
+}
+
+func TestBackgroundService_WorkerPool(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	alertRepo := repositories.NewAlertRepository(db)
+	provider := &stubProvider{name: "rss", articles: []models.NewsArticle{
+		{Title: "Stocks rally", URL: "https://example.com/stocks"},
+	}}
+	ingestionService := NewIngestionService([]NewsProvider{provider}, repositories.NewArticleRepository(db), nil, time.Minute, 0)
+	if _, err := ingestionService.Ingest(ctx); err != nil {
+		t.Fatalf("Failed to ingest: %v", err)
+	}
+	owner := createVerifiedUser(t, db, "owner@example.com")
+	notifier := &recordingNotifier{}
+	seenService := &slowSeenService{SeenArticleService: NewSeenArticleService(alertRepo, nil)}
+
+	background := &backgroundService{
+		alertService:        NewAlertService(alertRepo, nil),
+		newsService:         NewNewsService(nil, nil),
+		ingestionService:    ingestionService,
+		seenService:         seenService,
+		notificationService: notifier,
+		config:              SchedulerConfig{MaxCatchUp: time.Hour, Workers: 2, AlertTimeout: 100 * time.Millisecond},
+		ctx:                 context.Background(),
+	}
+
+	due := time.Now().Add(-time.Minute)
+	topics := []string{"Slow", "Fast 1", "Fast 2", "Fast 3", "Fast 4"}
+	for _, topic := range topics {
+		alert := &models.Alert{UserID: owner.ID, Topic: topic, Keywords: models.Keywords{"stocks"}, Frequency: models.FrequencyRealTime, NextRunAt: &due, Active: true}
+		if err := alertRepo.Create(ctx, alert); err != nil {
+			t.Fatalf("Failed to create alert: %v", err)
+		}
+		if topic == "Slow" {
+			seenService.slowAlertID = alert.ID
+		}
+	}
+
+	started := time.Now()
+	if err := background.ProcessAlerts(); err != nil {
+		t.Fatalf("Failed to process alerts: %v", err)
+	}
+
+	// The slow alert times out without holding up the others
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("Expected the pass to finish soon after the slow alert's deadline but it took %v", elapsed)
+	}
+	if len(notifier.sent) != 4 {
+		t.Errorf("Expected the 4 fast alerts to be queued but got %d", len(notifier.sent))
+	}
+	if seenService.maxActive > 2 {
+		t.Errorf("Expected at most 2 deliveries at once but got %d", seenService.maxActive)
+	}
+
+	run := background.lastRun
+	if run.Run != 4 || run.Failed != 1 || run.TimedOut != 1 {
+		t.Errorf("Expected 4 run and 1 timed out but got %+v", run)
+	}
+	if run.MaxQueueLagMs < time.Minute.Milliseconds() || run.AvgQueueLagMs < time.Minute.Milliseconds() || run.AvgQueueLagMs > run.MaxQueueLagMs {
+		t.Errorf("Expected queue lag of at least a minute but got %+v", run)
+	}
+	if background.queued != 0 {
+		t.Errorf("Expected the queue to be empty but %d alerts are waiting", background.queued)
+	}
+
+	alerts, err := alertRepo.GetActiveAlerts(ctx)
+	if err != nil {
+		t.Fatalf("Failed to load alerts: %v", err)
+	}
+	for _, alert := range alerts {
+		if (alert.LastChecked == nil) != (alert.Topic == "Slow") {
+			t.Errorf("Expected only the timed out alert to be left unchecked but %s has last checked %v", alert.Topic, alert.LastChecked)
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
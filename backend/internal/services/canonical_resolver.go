@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"news-to-text/internal/cache"
+	"news-to-text/internal/dedup"
+)
+
+const (
+	// canonicalLinkRetention is how long a page's declared canonical URL is
+	// remembered; publishers rarely change it once an article is live
+	canonicalLinkRetention = 7 * 24 * time.Hour
+
+	// canonicalLinkMaxBytes limits how much of the page is read looking for
+	// <link rel="canonical">, which lives in the <head>
+	canonicalLinkMaxBytes = 256 << 10
+)
+
+// URLResolver looks up the canonical URL an article page declares. It
+// returns "" when the page does not declare one.
+type URLResolver interface {
+	Resolve(ctx context.Context, articleURL string) (string, error)
+}
+
+type canonicalLinkResolver struct {
+	client *http.Client
+	cache  cache.Cache
+}
+
+// NewCanonicalLinkResolver creates a resolver that downloads article pages
+// and reads their <link rel="canonical">. Results, including pages without
+// one, are cached so each article is fetched once.
+func NewCanonicalLinkResolver(linkCache cache.Cache) URLResolver {
+	return &canonicalLinkResolver{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		cache: linkCache,
+	}
+}
+
+func (r *canonicalLinkResolver) Resolve(ctx context.Context, articleURL string) (string, error) {
+	cacheKey := "canonical:" + articleURL
+	if r.cache != nil {
+		if canonical, err := r.cache.Get(cacheKey); err == nil {
+			return canonical, nil
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", articleURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return "", fmt.Errorf("article page returned status code: %d", resp.StatusCode)
+	}
+
+	var canonical string
+	if resp.StatusCode == http.StatusOK && strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		page, err := io.ReadAll(io.LimitReader(resp.Body, canonicalLinkMaxBytes))
+		if err != nil {
+			return "", err
+		}
+		canonical = dedup.ExtractCanonicalLink(page, resp.Request.URL.String())
+	}
+
+	if r.cache != nil {
+		r.cache.Set(cacheKey, canonical, canonicalLinkRetention)
+	}
+
+	return canonical, nil
+}
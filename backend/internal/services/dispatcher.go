@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/repositories"
+	"news-to-text/pkg/logger"
+)
+
+const (
+	// dispatchBatchSize is how many due notifications the dispatcher claims
+	// at once
+	dispatchBatchSize = 50
+
+	// dispatchLease is how long claimed notifications are kept from other
+	// dispatchers. Sends still in flight when it runs out are cancelled, so
+	// the next claim doesn't send them twice.
+	dispatchLease = 5 * time.Minute
+)
+
+// DispatcherConfig tunes the delivery of queued notifications
+type DispatcherConfig struct {
+	// Interval is how often the dispatcher looks for notifications to send
+	Interval time.Duration
+	// MaxAttempts is how many times a notification is tried before it is
+	// dead-lettered
+	MaxAttempts int
+	// RetryBase is the delay before the first retry; it doubles with each
+	// failed attempt
+	RetryBase time.Duration
+	// RetryMax caps the delay between attempts
+	RetryMax time.Duration
+}
+
+// NotificationDispatcher sends the notifications queued in the outbox,
+// retrying failed sends with exponential backoff and jitter until they
+// succeed or run out of attempts
+type NotificationDispatcher interface {
+	Start()
+	Stop()
+	Dispatch(ctx context.Context) (int, error)
+}
+
+type notificationDispatcher struct {
+	notificationRepo    repositories.NotificationRepository
+	notificationService NotificationService
+	config              DispatcherConfig
+	now                 func() time.Time
+	jitter              func(n int64) int64 // random in [0, n)
+	ctx                 context.Context
+	cancel              context.CancelFunc
+	wg                  sync.WaitGroup
+	running             bool
+	mu                  sync.RWMutex
+}
+
+// NewNotificationDispatcher creates a dispatcher that sends due
+// notifications every interval. Several replicas can dispatch at once; each
+// notification is claimed by one of them at a time.
+func NewNotificationDispatcher(
+	notificationRepo repositories.NotificationRepository,
+	notificationService NotificationService,
+	config DispatcherConfig,
+) NotificationDispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &notificationDispatcher{
+		notificationRepo:    notificationRepo,
+		notificationService: notificationService,
+		config:              config,
+		now:                 time.Now,
+		jitter:              rand.Int63n,
+		ctx:                 ctx,
+		cancel:              cancel,
+	}
+}
+
+func (d *notificationDispatcher) Start() {
+	d.mu.Lock()
+	if d.running {
+		d.mu.Unlock()
+		return
+	}
+	d.running = true
+	d.mu.Unlock()
+
+	logger.Info("Starting notification dispatcher...")
+
+	d.wg.Add(1)
+	go d.poller()
+
+	d.wg.Wait()
+}
+
+func (d *notificationDispatcher) Stop() {
+	d.mu.Lock()
+	if !d.running {
+		d.mu.Unlock()
+		return
+	}
+	d.running = false
+	d.mu.Unlock()
+
+	logger.Info("Stopping notification dispatcher...")
+	d.cancel()
+	d.wg.Wait()
+	logger.Info("Notification dispatcher stopped")
+}
+
+func (d *notificationDispatcher) poller() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Dispatch(d.ctx); err != nil {
+			logger.Error("Error dispatching notifications:", err)
+		}
+
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch sends the notifications that are due and returns how many went
+// out. Each attempt is recorded on the notification and its history rows: a
+// failed one is retried after a backoff delay, or dead-lettered once
+// MaxAttempts have failed or when the gateway refuses it permanently.
+func (d *notificationDispatcher) Dispatch(ctx context.Context) (int, error) {
+	notifications, err := d.notificationRepo.ClaimDue(ctx, d.now(), dispatchBatchSize, dispatchLease)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dispatchLease)
+	defer cancel()
+
+	sent := 0
+	for i := range notifications {
+		// Unsent notifications are claimed again once the lease runs out
+		if ctx.Err() != nil {
+			break
+		}
+
+		notification := &notifications[i]
+		if d.send(ctx, notification) {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// send makes one delivery attempt and records its outcome, reporting
+// whether the notification went out
+func (d *notificationDispatcher) send(ctx context.Context, notification *models.Notification) bool {
+	// Users deleted since the notification was queued have no number left
+	var sid string
+	var sendErr error
+	if notification.User.HasVerifiedPhone() {
+		sid, sendErr = d.notificationService.SendSMS(ctx, notification.User.Phone, notification.Message)
+	} else {
+		sendErr = &SMSError{Message: "phone number not verified", Permanent: true}
+	}
+
+	now := d.now()
+	notification.Attempts++
+	switch {
+	case sendErr == nil:
+		notification.Status = models.NotificationSent
+		notification.LastError = ""
+		notification.MessageSID = sid
+		notification.SentAt = &now
+	case isPermanentSMSError(sendErr) || notification.Attempts >= d.config.MaxAttempts:
+		notification.Status = models.NotificationDead
+		notification.LastError = sendErr.Error()
+	default:
+		notification.LastError = sendErr.Error()
+		notification.NextAttemptAt = now.Add(d.backoff(notification.Attempts))
+	}
+
+	// Record the outcome even when ctx ends straight after the send, so a
+	// notification that went out is not sent again
+	if err := d.notificationRepo.RecordAttempt(context.WithoutCancel(ctx), notification); err != nil {
+		logger.Error("Failed to record attempt for notification", notification.ID, ":", err)
+	}
+
+	switch notification.Status {
+	case models.NotificationSent:
+		logger.Info("Sent notification", notification.ID, "to user", notification.UserID)
+	case models.NotificationDead:
+		logger.Error("Giving up on notification", notification.ID, "after", notification.Attempts, "attempts:", sendErr)
+	default:
+		logger.Error("Failed to send notification", notification.ID, ", retrying at", notification.NextAttemptAt, ":", sendErr)
+	}
+
+	return sendErr == nil
+}
+
+// backoff is the delay before retrying after the given number of failed
+// attempts: RetryBase doubled for each attempt after the first, capped at
+// RetryMax, of which a random half is dropped so retries spread out
+func (d *notificationDispatcher) backoff(attempts int) time.Duration {
+	delay := d.config.RetryBase
+	for i := 1; i < attempts && delay < d.config.RetryMax; i++ {
+		delay *= 2
+	}
+	if delay > d.config.RetryMax {
+		delay = d.config.RetryMax
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + time.Duration(d.jitter(int64(delay-half)))
+}
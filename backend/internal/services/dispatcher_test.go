@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/repositories"
+)
+
+func TestNotificationDispatcher_Backoff(t *testing.T) {
+	config := DispatcherConfig{RetryBase: 10 * time.Second, RetryMax: time.Minute}
+
+	tests := []struct {
+		name     string
+		attempts int
+		min      time.Duration
+		max      time.Duration
+	}{
+		{"First retry", 1, 5 * time.Second, 10 * time.Second},
+		{"Doubles", 2, 10 * time.Second, 20 * time.Second},
+		{"Doubles again", 3, 20 * time.Second, 40 * time.Second},
+		{"Capped", 4, 30 * time.Second, time.Minute},
+		{"Stays capped", 50, 30 * time.Second, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			low := &notificationDispatcher{config: config, jitter: func(n int64) int64 { return 0 }}
+			high := &notificationDispatcher{config: config, jitter: func(n int64) int64 { return n - 1 }}
+
+			if delay := low.backoff(tt.attempts); delay != tt.min {
+				t.Errorf("Expected the shortest delay to be %v but got %v", tt.min, delay)
+			}
+			if delay := high.backoff(tt.attempts); delay < tt.max-time.Nanosecond || delay > tt.max {
+				t.Errorf("Expected the longest delay to be %v but got %v", tt.max, delay)
+			}
+		})
+	}
+}
+
+func TestNotificationDispatcher_RetriesAndDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	notificationRepo := repositories.NewNotificationRepository(db)
+	seenService := NewSeenArticleService(repositories.NewAlertRepository(db), nil)
+	notifier := &recordingNotifier{err: errors.New("sms gateway down")}
+
+	now := time.Now()
+	dispatcher := NewNotificationDispatcher(notificationRepo, notifier, DispatcherConfig{MaxAttempts: 3, RetryBase: time.Minute, RetryMax: time.Hour}).(*notificationDispatcher)
+	dispatcher.now = func() time.Time { return now }
+	dispatcher.jitter = func(n int64) int64 { return 0 }
+
+	owner := createVerifiedUser(t, db, "owner@example.com")
+	articles := []models.NewsArticle{
+		{Title: "Fed holds rates", URL: "https://example.com/fed"},
+		{Title: "Tesla recalls vehicles", URL: "https://example.com/tesla"},
+	}
+	notification := &models.Notification{UserID: owner.ID, Message: "Markets", NextAttemptAt: now}
+	if err := seenService.RecordDelivery(ctx, 1, articles, notification, 0); err != nil {
+		t.Fatalf("Failed to record delivery: %v", err)
+	}
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		if sent, err := dispatcher.Dispatch(ctx); err != nil || sent != 0 {
+			t.Fatalf("Attempt %d: expected the send to fail but got %d, %v", attempt, sent, err)
+		}
+
+		stored, err := notificationRepo.GetByID(ctx, notification.ID)
+		if err != nil {
+			t.Fatalf("Failed to load notification: %v", err)
+		}
+		if stored.Attempts != attempt || stored.LastError != "sms gateway down" {
+			t.Fatalf("Attempt %d: expected the failure to be recorded but got %+v", attempt, stored)
+		}
+
+		// Every attempt shows on the history rows
+		var history []models.AlertHistory
+		db.Where("notification_id = ?", notification.ID).Find(&history)
+		if len(history) != 2 {
+			t.Fatalf("Expected 2 history rows but got %d", len(history))
+		}
+		for _, h := range history {
+			if h.Success || h.ErrorMsg != "sms gateway down" || h.Attempts != attempt {
+				t.Errorf("Attempt %d: expected a failed history row but got %+v", attempt, h)
+			}
+		}
+
+		if attempt == 3 {
+			if stored.Status != models.NotificationDead {
+				t.Errorf("Expected the notification to be dead-lettered but got %+v", stored)
+			}
+			break
+		}
+
+		// Nothing is retried before the backoff has passed
+		retryAt := now.Add(time.Minute << (attempt - 1) / 2)
+		if stored.Status != models.NotificationPending || !stored.NextAttemptAt.Equal(retryAt) {
+			t.Fatalf("Attempt %d: expected a retry at %v but got %+v", attempt, retryAt, stored)
+		}
+		if sent, err := dispatcher.Dispatch(ctx); err != nil || sent != 0 {
+			t.Fatalf("Expected nothing to be due but got %d, %v", sent, err)
+		}
+		if stored, _ := notificationRepo.GetByID(ctx, notification.ID); stored.Attempts != attempt {
+			t.Fatalf("Expected no attempt before the backoff passed but got %+v", stored)
+		}
+		now = retryAt
+	}
+
+	// Dead-lettered notifications are not tried again
+	notifier.err = nil
+	now = now.Add(24 * time.Hour)
+	if sent, err := dispatcher.Dispatch(ctx); err != nil || sent != 0 {
+		t.Errorf("Expected the dead-lettered notification to stay unsent but got %d, %v", sent, err)
+	}
+}
+
+func TestNotificationDispatcher_RecordsSuccess(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	notificationRepo := repositories.NewNotificationRepository(db)
+	seenService := NewSeenArticleService(repositories.NewAlertRepository(db), nil)
+	notifier := &recordingNotifier{err: errors.New("sms gateway down")}
+
+	now := time.Now()
+	dispatcher := NewNotificationDispatcher(notificationRepo, notifier, DispatcherConfig{MaxAttempts: 3, RetryBase: time.Minute, RetryMax: time.Hour}).(*notificationDispatcher)
+	dispatcher.now = func() time.Time { return now }
+
+	owner := createVerifiedUser(t, db, "owner@example.com")
+	articles := []models.NewsArticle{{Title: "Fed holds rates", URL: "https://example.com/fed"}}
+	notification := &models.Notification{UserID: owner.ID, Message: "Markets", NextAttemptAt: now}
+	if err := seenService.RecordDelivery(ctx, 1, articles, notification, 0); err != nil {
+		t.Fatalf("Failed to record delivery: %v", err)
+	}
+
+	if sent, err := dispatcher.Dispatch(ctx); err != nil || sent != 0 {
+		t.Fatalf("Expected the first send to fail but got %d, %v", sent, err)
+	}
+
+	// The retry goes out once the gateway is back
+	notifier.err = nil
+	now = now.Add(time.Hour)
+	if sent, err := dispatcher.Dispatch(ctx); err != nil || sent != 1 {
+		t.Fatalf("Expected the retry to be sent but got %d, %v", sent, err)
+	}
+	if len(notifier.messages) != 1 || notifier.messages[0] != "Markets" {
+		t.Errorf("Expected the queued message to be sent but got %v", notifier.messages)
+	}
+
+	stored, err := notificationRepo.GetByID(ctx, notification.ID)
+	if err != nil {
+		t.Fatalf("Failed to load notification: %v", err)
+	}
+	if stored.Status != models.NotificationSent || stored.Attempts != 2 || stored.LastError != "" || stored.SentAt == nil || stored.MessageSID != "SM1" {
+		t.Errorf("Expected the notification to be sent on the second attempt but got %+v", stored)
+	}
+
+	var history models.AlertHistory
+	db.Where("notification_id = ?", notification.ID).First(&history)
+	if !history.Success || history.ErrorMsg != "" || history.Attempts != 2 || !history.SentAt.Equal(now) {
+		t.Errorf("Expected a successful history row sent at %v but got %+v", now, history)
+	}
+
+	// Sent notifications are not sent again
+	if sent, err := dispatcher.Dispatch(ctx); err != nil || sent != 0 {
+		t.Errorf("Expected nothing left to send but got %d, %v", sent, err)
+	}
+}
+
+func TestNotificationDispatcher_PermanentErrorDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	notificationRepo := repositories.NewNotificationRepository(db)
+	notifier := &recordingNotifier{err: &SMSError{Code: 21610, Status: 400, Message: "Attempt to send to unsubscribed recipient", Permanent: true}}
+	dispatcher := NewNotificationDispatcher(notificationRepo, notifier, DispatcherConfig{MaxAttempts: 5, RetryBase: time.Minute, RetryMax: time.Hour})
+
+	owner := createVerifiedUser(t, db, "owner@example.com")
+	notification := &models.Notification{UserID: owner.ID, Message: "Markets", NextAttemptAt: time.Now()}
+	articles := []models.NewsArticle{{Title: "Fed holds rates", URL: "https://example.com/fed"}}
+	if err := NewSeenArticleService(repositories.NewAlertRepository(db), nil).RecordDelivery(ctx, 1, articles, notification, 0); err != nil {
+		t.Fatalf("Failed to record delivery: %v", err)
+	}
+
+	if sent, err := dispatcher.Dispatch(ctx); err != nil || sent != 0 {
+		t.Fatalf("Expected the send to fail but got %d, %v", sent, err)
+	}
+
+	// A number the gateway refuses for good is not tried again
+	stored, err := notificationRepo.GetByID(ctx, notification.ID)
+	if err != nil {
+		t.Fatalf("Failed to load notification: %v", err)
+	}
+	if stored.Status != models.NotificationDead || stored.Attempts != 1 || stored.LastError != notifier.err.Error() {
+		t.Errorf("Expected the notification to be dead-lettered after one attempt but got %+v", stored)
+	}
+
+	var history models.AlertHistory
+	db.Where("notification_id = ?", notification.ID).First(&history)
+	if history.Success || history.ErrorMsg != notifier.err.Error() || history.Attempts != 1 {
+		t.Errorf("Expected a failed history row but got %+v", history)
+	}
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"news-to-text/internal/cache"
+	"news-to-text/internal/feed"
+	"news-to-text/internal/models"
+	"news-to-text/pkg/logger"
+)
+
+const (
+	// feedCacheRetention is how long validators and articles are kept for
+	// conditional requests after the last successful fetch
+	feedCacheRetention = 24 * time.Hour
+
+	// maxFeedFreshness caps Cache-Control and <ttl> hints so a feed asking to
+	// be cached for a day doesn't hide breaking news from realtime alerts
+	maxFeedFreshness = time.Hour
+)
+
+// feedCacheEntry is what the fetcher remembers about a feed between polls
+type feedCacheEntry struct {
+	ETag         string               `json:"etag,omitempty"`
+	LastModified string               `json:"last_modified,omitempty"`
+	FreshUntil   time.Time            `json:"fresh_until"`
+	Articles     []models.NewsArticle `json:"articles"`
+}
+
+// feedFetcher downloads feeds using conditional GET. When a cache is set it
+// stores each feed's ETag / Last-Modified validators and parsed articles,
+// sends If-None-Match / If-Modified-Since on the next poll, serves the cached
+// articles on 304 Not Modified, and skips the request entirely while the
+// feed's Cache-Control max-age or <ttl> says it is still fresh.
+type feedFetcher struct {
+	client *http.Client
+	cache  cache.Cache
+	now    func() time.Time
+}
+
+func newFeedFetcher(client *http.Client, feedCache cache.Cache) *feedFetcher {
+	return &feedFetcher{
+		client: client,
+		cache:  feedCache,
+		now:    time.Now,
+	}
+}
+
+func (f *feedFetcher) Fetch(ctx context.Context, url string) ([]models.NewsArticle, error) {
+	entry := f.cached(url)
+	now := f.now()
+
+	if entry != nil && now.Before(entry.FreshUntil) {
+		logger.Debug("Serving fresh cached feed:", url)
+		return entry.Articles, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		logger.Debug("Feed not modified:", url)
+		entry.FreshUntil = now.Add(freshness(resp.Header, 0))
+		f.store(url, entry)
+		return entry.Articles, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feed returned status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := feed.Parse(body)
+	if err != nil {
+		return nil, err
+	}
+
+	inferPublishedAt(parsed.Articles, now)
+
+	f.store(url, &feedCacheEntry{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FreshUntil:   now.Add(freshness(resp.Header, parsed.TTL)),
+		Articles:     parsed.Articles,
+	})
+
+	return parsed.Articles, nil
+}
+
+func (f *feedFetcher) cached(url string) *feedCacheEntry {
+	if f.cache == nil {
+		return nil
+	}
+
+	var entry feedCacheEntry
+	if err := f.cache.GetJSON(feedCacheKey(url), &entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+func (f *feedFetcher) store(url string, entry *feedCacheEntry) {
+	if f.cache == nil {
+		return
+	}
+
+	if err := f.cache.SetJSON(feedCacheKey(url), entry, feedCacheRetention); err != nil {
+		logger.Error("Failed to cache feed:", url, err)
+	}
+}
+
+func feedCacheKey(url string) string {
+	return "feed:" + url
+}
+
+// freshness works out how long a response may be served without asking the
+// publisher again, from Cache-Control, Expires and the feed's own <ttl>
+func freshness(header http.Header, ttl time.Duration) time.Duration {
+	var fresh time.Duration
+
+	cacheControl := strings.ToLower(header.Get("Cache-Control"))
+	if strings.Contains(cacheControl, "no-cache") || strings.Contains(cacheControl, "no-store") {
+		return 0
+	}
+
+	maxAgeFound := false
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if value, ok := strings.CutPrefix(directive, "max-age="); ok {
+			if seconds, err := strconv.Atoi(value); err == nil {
+				fresh = time.Duration(seconds) * time.Second
+				maxAgeFound = true
+			}
+		}
+	}
+
+	if !maxAgeFound {
+		if expires, err := http.ParseTime(header.Get("Expires")); err == nil {
+			if date, err := http.ParseTime(header.Get("Date")); err == nil {
+				fresh = expires.Sub(date)
+			} else {
+				fresh = time.Until(expires)
+			}
+		}
+	}
+
+	if ttl > fresh {
+		fresh = ttl
+	}
+	if fresh < 0 {
+		fresh = 0
+	}
+	if fresh > maxFeedFreshness {
+		fresh = maxFeedFreshness
+	}
+
+	return fresh
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryCache is an in-memory cache.Cache for tests
+type memoryCache struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{data: map[string]string{}}
+}
+
+func (c *memoryCache) Set(key string, value interface{}, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch v := value.(type) {
+	case []byte:
+		c.data[key] = string(v)
+	case string:
+		c.data[key] = v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		c.data[key] = string(data)
+	}
+	return nil
+}
+
+func (c *memoryCache) Get(key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.data[key]
+	if !ok {
+		return "", errors.New("cache miss")
+	}
+	return value, nil
+}
+
+func (c *memoryCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, key)
+	return nil
+}
+
+func (c *memoryCache) Exists(key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.data[key]
+	return ok, nil
+}
+
+func (c *memoryCache) SetJSON(key string, value interface{}, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.Set(key, data, expiration)
+}
+
+func (c *memoryCache) GetJSON(key string, dest interface{}) error {
+	data, err := c.Get(key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(data), dest)
+}
+
+const testFeed = `<rss version="2.0"><channel><title>Test Feed</title>
+	<item><title>First story</title><link>https://example.com/1</link></item>
+</channel></rss>`
+
+func TestFeedFetcher_ConditionalGet(t *testing.T) {
+	ctx := context.Background()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` && r.Header.Get("If-Modified-Since") == "Tue, 02 Jan 2024 15:04:05 GMT" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Tue, 02 Jan 2024 15:04:05 GMT")
+		w.Write([]byte(testFeed))
+	}))
+	defer server.Close()
+
+	fetcher := newFeedFetcher(server.Client(), newMemoryCache())
+
+	first, err := fetcher.Fetch(ctx, server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	second, err := fetcher.Fetch(ctx, server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error on conditional request: %v", err)
+	}
+
+	if requests != 2 {
+		t.Errorf("Expected 2 requests but got %d", requests)
+	}
+
+	if len(second) != 1 || second[0].Title != first[0].Title {
+		t.Errorf("Expected cached articles on 304 but got %v", second)
+	}
+
+	if !second[0].PublishedAt.Equal(first[0].PublishedAt) {
+		t.Errorf("Expected inferred date to stay stable across polls")
+	}
+}
+
+func TestFeedFetcher_RespectsFreshness(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name             string
+		cacheControl     string
+		feed             string
+		advance          time.Duration
+		expectedRequests int
+	}{
+		{
+			name:             "max-age still fresh",
+			cacheControl:     "public, max-age=600",
+			feed:             testFeed,
+			advance:          5 * time.Minute,
+			expectedRequests: 1,
+		},
+		{
+			name:             "max-age expired",
+			cacheControl:     "max-age=60",
+			feed:             testFeed,
+			advance:          2 * time.Minute,
+			expectedRequests: 2,
+		},
+		{
+			name:             "no-cache always revalidates",
+			cacheControl:     "no-cache",
+			feed:             testFeed,
+			advance:          time.Second,
+			expectedRequests: 2,
+		},
+		{
+			name:             "ttl element",
+			feed:             `<rss version="2.0"><channel><title>TTL Feed</title><ttl>15</ttl></channel></rss>`,
+			advance:          10 * time.Minute,
+			expectedRequests: 1,
+		},
+		{
+			name:             "long hints are capped",
+			cacheControl:     "max-age=86400",
+			feed:             testFeed,
+			advance:          2 * time.Hour,
+			expectedRequests: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if tt.cacheControl != "" {
+					w.Header().Set("Cache-Control", tt.cacheControl)
+				}
+				w.Write([]byte(tt.feed))
+			}))
+			defer server.Close()
+
+			now := time.Now()
+			fetcher := newFeedFetcher(server.Client(), newMemoryCache())
+			fetcher.now = func() time.Time { return now }
+
+			if _, err := fetcher.Fetch(ctx, server.URL); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			now = now.Add(tt.advance)
+			if _, err := fetcher.Fetch(ctx, server.URL); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if requests != tt.expectedRequests {
+				t.Errorf("Expected %d requests but got %d", tt.expectedRequests, requests)
+			}
+		})
+	}
+}
+
+func TestFeedFetcher_WithoutCache(t *testing.T) {
+	ctx := context.Background()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") != "" {
+			t.Errorf("Did not expect a conditional request without a cache")
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "max-age=600")
+		w.Write([]byte(testFeed))
+	}))
+	defer server.Close()
+
+	fetcher := newFeedFetcher(server.Client(), nil)
+	for i := 0; i < 2; i++ {
+		if _, err := fetcher.Fetch(ctx, server.URL); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if requests != 2 {
+		t.Errorf("Expected every fetch to hit the server but got %d requests", requests)
+	}
+}
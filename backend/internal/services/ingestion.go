@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"news-to-text/internal/dedup"
+	"news-to-text/internal/models"
+	"news-to-text/internal/repositories"
+	"news-to-text/pkg/logger"
+)
+
+const (
+	// storyWindow is how far back newly ingested articles are compared with
+	// stored ones when grouping copies of the same story
+	storyWindow = 48 * time.Hour
+
+	// canonicalResolveWorkers bounds concurrent article page downloads when
+	// resolving <link rel="canonical">
+	canonicalResolveWorkers = 8
+)
+
+// IngestionService polls every news provider on its own schedule and stores
+// the articles in the database, so alert evaluation reads stored articles
+// instead of fetching from the network once per alert.
+type IngestionService interface {
+	Start()
+	Stop()
+	Ingest(ctx context.Context) (int64, error)
+	GetArticlesSince(ctx context.Context, since time.Time) ([]models.NewsArticle, error)
+}
+
+type ingestionService struct {
+	providers   []NewsProvider
+	articleRepo repositories.ArticleRepository
+	resolver    URLResolver
+	interval    time.Duration
+	retention   time.Duration
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+	running     bool
+	mu          sync.RWMutex
+}
+
+// NewIngestionService creates an ingestion service that polls the providers
+// every interval. When a resolver is given, each article's canonical URL is
+// looked up from the page itself. Articles ingested longer than retention ago
+// are pruned; a zero retention keeps them forever.
+func NewIngestionService(
+	providers []NewsProvider,
+	articleRepo repositories.ArticleRepository,
+	resolver URLResolver,
+	interval time.Duration,
+	retention time.Duration,
+) IngestionService {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &ingestionService{
+		providers:   providers,
+		articleRepo: articleRepo,
+		resolver:    resolver,
+		interval:    interval,
+		retention:   retention,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+func (s *ingestionService) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	logger.Info("Starting ingestion service...")
+
+	s.wg.Add(1)
+	go s.poller()
+
+	s.wg.Wait()
+}
+
+func (s *ingestionService) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	s.mu.Unlock()
+
+	logger.Info("Stopping ingestion service...")
+	s.cancel()
+	s.wg.Wait()
+	logger.Info("Ingestion service stopped")
+}
+
+func (s *ingestionService) poller() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Ingest(s.ctx); err != nil {
+			logger.Error("Error ingesting articles:", err)
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Ingest runs a single polling pass over all providers and returns the number
+// of newly stored articles. A failing provider is logged and skipped; an
+// error is only returned when every provider fails or storing fails.
+//
+// Each article is keyed by its canonical URL, and copies of the same story
+// (same canonical URL, or a near-duplicate title or description) share a
+// story key with the first copy ingested in the last storyWindow.
+func (s *ingestionService) Ingest(ctx context.Context) (int64, error) {
+	if len(s.providers) == 0 {
+		return 0, errors.New("no news providers configured")
+	}
+
+	var fetched []models.NewsArticle
+	var providerNames []string
+	var lastErr error
+	failed := 0
+
+	for _, provider := range s.providers {
+		articles, err := provider.FetchLatest(ctx)
+		if err != nil {
+			logger.Error("News provider", provider.Name(), "failed during ingestion:", err)
+			lastErr = err
+			failed++
+			continue
+		}
+
+		for _, article := range articles {
+			fetched = append(fetched, article)
+			providerNames = append(providerNames, provider.Name())
+		}
+	}
+
+	if failed == len(s.providers) {
+		return 0, lastErr
+	}
+
+	s.canonicalize(ctx, fetched)
+
+	stories, err := s.recentStories(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	seen := make(map[string]bool)
+	var articles []*models.Article
+	for i, article := range fetched {
+		key := articleKey(article)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		fingerprint := dedup.ArticleFingerprint(article)
+		storyKey, exists := stories.Find(article.CanonicalURL, fingerprint)
+		if !exists {
+			storyKey = key
+		}
+		stories.Add(storyKey, article.CanonicalURL, fingerprint)
+		article.StoryKey = storyKey
+
+		articles = append(articles, models.NewArticle(key, providerNames[i], article))
+	}
+
+	inserted, err := s.articleRepo.CreateIfNotExists(ctx, articles)
+	if err != nil {
+		return 0, err
+	}
+	logger.Debug("Ingested", inserted, "new articles out of", len(articles), "fetched")
+
+	if s.retention > 0 {
+		if _, err := s.articleRepo.DeleteIngestedBefore(ctx, time.Now().Add(-s.retention)); err != nil {
+			logger.Error("Failed to prune old articles:", err)
+		}
+	}
+
+	return inserted, nil
+}
+
+// GetArticlesSince returns the articles ingested after the given time, newest
+// publication date first
+func (s *ingestionService) GetArticlesSince(ctx context.Context, since time.Time) ([]models.NewsArticle, error) {
+	stored, err := s.articleRepo.GetIngestedSince(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	articles := make([]models.NewsArticle, 0, len(stored))
+	for i := range stored {
+		articles = append(articles, stored[i].ToNewsArticle())
+	}
+
+	return articles, nil
+}
+
+// canonicalize fills in each article's canonical URL, asking the resolver
+// for the page's <link rel="canonical"> when one is configured
+func (s *ingestionService) canonicalize(ctx context.Context, articles []models.NewsArticle) {
+	if s.resolver != nil {
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, canonicalResolveWorkers)
+
+		for i := range articles {
+			if articles[i].URL == "" {
+				continue
+			}
+
+			wg.Add(1)
+			slots <- struct{}{}
+			go func(article *models.NewsArticle) {
+				defer wg.Done()
+				defer func() { <-slots }()
+
+				canonical, err := s.resolver.Resolve(ctx, article.URL)
+				if err != nil {
+					logger.Debug("Failed to resolve canonical URL:", article.URL, err)
+					return
+				}
+				article.CanonicalURL = canonical
+			}(&articles[i])
+		}
+
+		wg.Wait()
+	}
+
+	for i := range articles {
+		if articles[i].CanonicalURL != "" {
+			articles[i].CanonicalURL = dedup.CanonicalURL(articles[i].CanonicalURL)
+		} else if articles[i].URL != "" {
+			articles[i].CanonicalURL = dedup.CanonicalURL(articles[i].URL)
+		}
+	}
+}
+
+// recentStories indexes the articles stored in the last storyWindow so new
+// copies of a story join the existing one
+func (s *ingestionService) recentStories(ctx context.Context) (*dedup.Index, error) {
+	stored, err := s.articleRepo.GetIngestedSince(ctx, time.Now().Add(-storyWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	stories := dedup.NewIndex()
+	for i := range stored {
+		article := stored[i].ToNewsArticle()
+		stories.Add(article.StoryKey, article.CanonicalURL, dedup.ArticleFingerprint(article))
+	}
+
+	return stories, nil
+}
+
+// articleKey identifies an article by its canonical URL, falling back to the
+// feed GUID. Articles without either cannot be told apart and get an empty
+// key.
+func articleKey(article models.NewsArticle) string {
+	identity := article.CanonicalURL
+	if identity == "" {
+		identity = dedup.CanonicalURL(article.URL)
+	}
+	if identity == "" {
+		identity = strings.TrimSpace(article.GUID)
+	}
+	if identity == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(identity))
+	return hex.EncodeToString(sum[:])
+}
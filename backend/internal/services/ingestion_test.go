@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/repositories"
+)
+
+func TestIngestionService_Ingest(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	rss := &stubProvider{name: "rss", articles: []models.NewsArticle{
+		{Title: "Apple earnings beat estimates", URL: "https://example.com/apple"},
+		{Title: "Fed holds rates", URL: "https://example.com/fed"},
+		{Title: "No link", GUID: "urn:example:1"},
+		{Title: "Nothing to identify it by"},
+	}}
+	api := &stubProvider{name: "api", articles: []models.NewsArticle{
+		{Title: "Apple earnings beat estimates", URL: "https://example.com/apple"},
+		{Title: "Tesla recalls vehicles", URL: "https://example.com/tesla"},
+	}}
+	failing := &stubProvider{name: "failing", err: errors.New("boom")}
+
+	start := time.Now().Add(-time.Second)
+	ingestionService := NewIngestionService(
+		[]NewsProvider{rss, failing, api},
+		repositories.NewArticleRepository(db),
+		nil,
+		time.Minute,
+		0,
+	)
+
+	inserted, err := ingestionService.Ingest(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if inserted != 4 {
+		t.Errorf("Expected 4 new articles but got %d", inserted)
+	}
+
+	// Polling again must not store the same stories twice
+	inserted, err = ingestionService.Ingest(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error on second ingest: %v", err)
+	}
+	if inserted != 0 {
+		t.Errorf("Expected no new articles on second ingest but got %d", inserted)
+	}
+
+	articles, err := ingestionService.GetArticlesSince(ctx, start)
+	if err != nil {
+		t.Fatalf("Failed to get stored articles: %v", err)
+	}
+	if len(articles) != 4 {
+		t.Errorf("Expected 4 stored articles but got %d", len(articles))
+	}
+
+	articles, err = ingestionService.GetArticlesSince(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to get stored articles: %v", err)
+	}
+	if len(articles) != 0 {
+		t.Errorf("Expected no articles ingested in the future but got %d", len(articles))
+	}
+}
+
+func TestIngestionService_AllProvidersFail(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	ingestionService := NewIngestionService(
+		[]NewsProvider{&stubProvider{name: "failing", err: errors.New("boom")}},
+		repositories.NewArticleRepository(db),
+		nil,
+		time.Minute,
+		0,
+	)
+
+	if _, err := ingestionService.Ingest(ctx); err == nil || err.Error() != "boom" {
+		t.Errorf("Expected provider error but got %v", err)
+	}
+}
+
+func TestIngestionService_PrunesOldArticles(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	old := &models.Article{Key: "old", Title: "Old story", URL: "https://example.com/old"}
+	if err := db.Create(old).Error; err != nil {
+		t.Fatalf("Failed to create article: %v", err)
+	}
+	db.Model(old).Update("created_at", time.Now().Add(-48*time.Hour))
+
+	ingestionService := NewIngestionService(
+		[]NewsProvider{&stubProvider{name: "rss", articles: []models.NewsArticle{
+			{Title: "New story", URL: "https://example.com/new"},
+		}}},
+		repositories.NewArticleRepository(db),
+		nil,
+		time.Minute,
+		24*time.Hour,
+	)
+
+	if _, err := ingestionService.Ingest(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var count int64
+	db.Model(&models.Article{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected old article to be pruned, %d articles left", count)
+	}
+}
+
+func TestIngestionService_GroupsStoriesAcrossSources(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	reuters := &stubProvider{name: "rss", articles: []models.NewsArticle{
+		{Title: "Fed holds interest rates steady, signals cuts later this year", URL: "https://www.reuters.com/markets/fed/?utm_source=rss"},
+	}}
+	api := &stubProvider{name: "api", articles: []models.NewsArticle{
+		{Title: "Fed holds interest rates steady, signals cuts later this year", URL: "https://reuters.com/markets/fed?utm_campaign=api#top"},
+		{Title: "Tesla recalls vehicles over Autopilot", URL: "https://example.com/tesla"},
+	}}
+
+	start := time.Now().Add(-time.Second)
+	ingestionService := NewIngestionService(
+		[]NewsProvider{reuters, api},
+		repositories.NewArticleRepository(db),
+		nil,
+		time.Minute,
+		0,
+	)
+
+	inserted, err := ingestionService.Ingest(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if inserted != 2 {
+		t.Errorf("Expected tracking-parameter variants to be stored once, got %d new articles", inserted)
+	}
+
+	// A later copy of the story from another outlet joins the existing story
+	cnbc := &stubProvider{name: "cnbc", articles: []models.NewsArticle{
+		{Title: "Fed holds interest rates steady, signals cuts later this year - CNBC", URL: "https://www.cnbc.com/amp/2024/fed.html"},
+	}}
+	ingestionService = NewIngestionService(
+		[]NewsProvider{cnbc},
+		repositories.NewArticleRepository(db),
+		nil,
+		time.Minute,
+		0,
+	)
+	if _, err := ingestionService.Ingest(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	articles, err := ingestionService.GetArticlesSince(ctx, start)
+	if err != nil {
+		t.Fatalf("Failed to get stored articles: %v", err)
+	}
+	if len(articles) != 3 {
+		t.Fatalf("Expected 3 stored articles but got %d", len(articles))
+	}
+
+	stories := map[string]int{}
+	for _, article := range articles {
+		stories[article.StoryKey]++
+	}
+	if len(stories) != 2 {
+		t.Errorf("Expected 2 stories but got %d", len(stories))
+	}
+
+	matches := NewNewsService(nil, nil).MatchArticles(articles, []string{"Fed"})
+	if len(matches) != 1 {
+		t.Errorf("Expected one article per story but got %d", len(matches))
+	}
+}
+
+func TestCanonicalLinkResolver(t *testing.T) {
+	ctx := context.Background()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><link rel="canonical" href="/news/story"></head><body></body></html>`))
+	}))
+	defer server.Close()
+
+	resolver := NewCanonicalLinkResolver(newMemoryCache())
+
+	for i := 0; i < 2; i++ {
+		canonical, err := resolver.Resolve(ctx, server.URL+"/amp/news/story")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if canonical != server.URL+"/news/story" {
+			t.Errorf("Expected canonical link to be resolved but got %q", canonical)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("Expected the page to be fetched once but got %d requests", requests)
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"news-to-text/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// LeaderElection picks one of the server replicas to run the alert
+// scheduler, so each run is processed and sent by a single instance
+type LeaderElection interface {
+	Run(ctx context.Context)
+	IsLeader() bool
+	Token() int64
+}
+
+// acquireLeaseScript takes the lease when nobody holds it, under a fencing
+// token one higher than any given out before
+var acquireLeaseScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return 0
+end
+local token = redis.call("INCR", KEYS[2])
+redis.call("SET", KEYS[1], ARGV[1] .. ":" .. token, "PX", ARGV[2])
+return token
+`)
+
+// renewLeaseScript extends the lease only while this instance still holds it
+var renewLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLeaseScript gives the lease up only when this instance holds it
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type redisLeaderElection struct {
+	redis      *redis.Client
+	key        string
+	instanceID string
+	ttl        time.Duration
+	now        func() time.Time
+
+	mu      sync.RWMutex
+	token   int64
+	expires time.Time
+}
+
+// NewLeaderElection elects a leader among the instances campaigning under
+// name, through a lease in Redis that the leader renews every third of ttl.
+// Each new leader gets a larger fencing token, and an instance stops
+// considering itself leader when its lease would have run out by its own
+// clock, even if Redis cannot be reached to renew it.
+func NewLeaderElection(redisClient *redis.Client, name string, ttl time.Duration) LeaderElection {
+	return &redisLeaderElection{
+		redis:      redisClient,
+		key:        "leader:" + name,
+		instanceID: instanceID(),
+		ttl:        ttl,
+		now:        time.Now,
+	}
+}
+
+// Run campaigns for the lease and renews it while held, until ctx is done,
+// then gives it up
+func (e *redisLeaderElection) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		e.campaign(ctx)
+
+		select {
+		case <-ctx.Done():
+			e.resign()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *redisLeaderElection) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.token != 0 && e.now().Before(e.expires)
+}
+
+// Token returns the fencing token of the current term, 0 when not leader
+func (e *redisLeaderElection) Token() int64 {
+	if !e.IsLeader() {
+		return 0
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.token
+}
+
+// campaign renews the lease when this instance holds it and tries to take
+// it otherwise. It reports whether this instance is leader afterwards.
+func (e *redisLeaderElection) campaign(ctx context.Context) bool {
+	// The lease runs from before the request, so the local deadline is never
+	// later than the one in Redis
+	started := e.now()
+	ttl := strconv.FormatInt(e.ttl.Milliseconds(), 10)
+
+	e.mu.RLock()
+	token := e.token
+	e.mu.RUnlock()
+
+	if token != 0 {
+		renewed, err := renewLeaseScript.Run(ctx, e.redis, []string{e.key}, e.leaseValue(token), ttl).Int()
+		if err != nil {
+			logger.Error("Failed to renew leader lease", e.key, ":", err)
+			return e.IsLeader()
+		}
+		if renewed == 1 {
+			e.setLease(token, started.Add(e.ttl))
+			return true
+		}
+
+		logger.Info("Lost leader lease", e.key, "with token", token)
+		e.setLease(0, time.Time{})
+	}
+
+	token, err := acquireLeaseScript.Run(ctx, e.redis, []string{e.key, e.key + ":token"}, e.instanceID, ttl).Int64()
+	if err != nil {
+		logger.Error("Failed to campaign for leader lease", e.key, ":", err)
+		return false
+	}
+	if token == 0 {
+		return false
+	}
+
+	logger.Info("Became leader", e.key, "with token", token)
+	e.setLease(token, started.Add(e.ttl))
+	return true
+}
+
+// resign gives up the lease so another instance can take over without
+// waiting for it to expire
+func (e *redisLeaderElection) resign() {
+	e.mu.Lock()
+	token := e.token
+	e.token, e.expires = 0, time.Time{}
+	e.mu.Unlock()
+
+	if token == 0 {
+		return
+	}
+	if err := releaseLeaseScript.Run(context.Background(), e.redis, []string{e.key}, e.leaseValue(token)).Err(); err != nil {
+		logger.Error("Failed to release leader lease", e.key, ":", err)
+	}
+}
+
+func (e *redisLeaderElection) setLease(token int64, expires time.Time) {
+	e.mu.Lock()
+	e.token, e.expires = token, expires
+	e.mu.Unlock()
+}
+
+func (e *redisLeaderElection) leaseValue(token int64) string {
+	return fmt.Sprintf("%s:%d", e.instanceID, token)
+}
+
+// instanceID names this process among the replicas: its host, process ID
+// and a random suffix in case both repeat
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
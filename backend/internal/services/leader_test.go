@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/repositories"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeClock is a settable clock for lease deadlines
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestElection(client *redis.Client, clock *fakeClock) *redisLeaderElection {
+	election := NewLeaderElection(client, "scheduler", 15*time.Second).(*redisLeaderElection)
+	election.now = clock.Now
+	return election
+}
+
+func TestLeaderElection(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+
+	first := newTestElection(client, clock)
+	second := newTestElection(client, clock)
+
+	if !first.campaign(ctx) || !first.IsLeader() {
+		t.Fatal("Expected the first instance to become leader")
+	}
+	if second.campaign(ctx) || second.IsLeader() {
+		t.Fatal("Expected only one leader")
+	}
+	firstToken := first.Token()
+	if firstToken == 0 || second.Token() != 0 {
+		t.Fatalf("Expected only the leader to have a fencing token but got %d and %d", firstToken, second.Token())
+	}
+
+	// Renewing keeps the lease past its original expiry
+	mr.FastForward(10 * time.Second)
+	clock.now = clock.now.Add(10 * time.Second)
+	if !first.campaign(ctx) {
+		t.Fatal("Expected the leader to renew its lease")
+	}
+	mr.FastForward(10 * time.Second)
+	clock.now = clock.now.Add(10 * time.Second)
+	if !first.IsLeader() || second.campaign(ctx) {
+		t.Fatal("Expected the renewed lease to keep the leader")
+	}
+
+	// A leader that stops renewing loses the lease by its own clock, and
+	// another instance takes over under a larger token
+	mr.FastForward(20 * time.Second)
+	clock.now = clock.now.Add(20 * time.Second)
+	if first.IsLeader() {
+		t.Fatal("Expected the expired lease to end the leadership")
+	}
+	if !second.campaign(ctx) || second.Token() <= firstToken {
+		t.Fatalf("Expected the second instance to take over with a larger token than %d but got %d", firstToken, second.Token())
+	}
+
+	// The old leader cannot renew the lease it lost
+	if first.campaign(ctx) || first.IsLeader() {
+		t.Fatal("Expected the old leader not to get the lease back")
+	}
+
+	// Resigning hands the lease on without waiting for it to expire
+	secondToken := second.Token()
+	second.resign()
+	if second.IsLeader() {
+		t.Fatal("Expected the resigned instance not to be leader")
+	}
+	if !first.campaign(ctx) || first.Token() <= secondToken {
+		t.Fatalf("Expected the first instance to take over with a larger token than %d but got %d", secondToken, first.Token())
+	}
+}
+
+func TestLeaderElection_RedisDown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+
+	election := newTestElection(client, clock)
+	if !election.campaign(ctx) {
+		t.Fatal("Expected to become leader")
+	}
+
+	// Without Redis the lease can't be renewed; leadership lasts until the
+	// lease would have expired
+	mr.Close()
+	clock.now = clock.now.Add(5 * time.Second)
+	if !election.campaign(ctx) {
+		t.Error("Expected to stay leader until the lease runs out")
+	}
+	clock.now = clock.now.Add(15 * time.Second)
+	if election.campaign(ctx) || election.IsLeader() {
+		t.Error("Expected the leadership to end with the lease")
+	}
+}
+
+func TestBackgroundService_OnlyLeaderSends(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	clock := &fakeClock{now: time.Now()}
+
+	alertRepo := repositories.NewAlertRepository(db)
+	provider := &stubProvider{name: "rss", articles: []models.NewsArticle{
+		{Title: "Stocks rally", URL: "https://example.com/stocks"},
+	}}
+	ingestionService := NewIngestionService([]NewsProvider{provider}, repositories.NewArticleRepository(db), nil, time.Minute, 0)
+	if _, err := ingestionService.Ingest(ctx); err != nil {
+		t.Fatalf("Failed to ingest: %v", err)
+	}
+
+	owner := createVerifiedUser(t, db, "owner@example.com")
+	past := time.Now().Add(-time.Minute)
+	alert := &models.Alert{UserID: owner.ID, Topic: "Markets", Keywords: models.Keywords{"stocks"}, Frequency: models.FrequencyRealTime, NextRunAt: &past, Active: true}
+	if err := alertRepo.Create(ctx, alert); err != nil {
+		t.Fatalf("Failed to create alert: %v", err)
+	}
+
+	// Two replicas sharing the database and Redis
+	var replicas []*backgroundService
+	var notifiers []*recordingNotifier
+	for i := 0; i < 2; i++ {
+		notifier := &recordingNotifier{}
+		replica := NewBackgroundService(
+			NewAlertService(alertRepo, nil),
+			NewNewsService(nil, nil),
+			ingestionService,
+			NewSeenArticleService(alertRepo, client),
+			notifier,
+			SchedulerConfig{Interval: 20 * time.Millisecond, MaxCatchUp: time.Hour, Workers: 2, AlertTimeout: time.Second},
+			newTestElection(client, clock),
+		).(*backgroundService)
+
+		replicas = append(replicas, replica)
+		notifiers = append(notifiers, notifier)
+	}
+
+	for _, replica := range replicas {
+		go replica.Start()
+	}
+	time.Sleep(200 * time.Millisecond)
+	for _, replica := range replicas {
+		replica.Stop()
+	}
+
+	leaders, sent := 0, 0
+	for i, replica := range replicas {
+		if replica.lastRun != nil {
+			leaders++
+			if replica.lastRun.FencingToken == 0 {
+				t.Errorf("Expected the leader's passes to carry its fencing token but got %+v", replica.lastRun)
+			}
+		}
+		sent += len(notifiers[i].sent)
+	}
+	if leaders != 1 {
+		t.Errorf("Expected exactly one replica to run the scheduler but %d did", leaders)
+	}
+	if sent != 1 {
+		t.Errorf("Expected the alert to be sent once but it was sent %d times", sent)
+	}
+}
+
+func TestBackgroundService_StaleLeaderCannotWrite(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	clock := &fakeClock{now: time.Now()}
+
+	alertRepo := repositories.NewAlertRepository(db)
+	alertService := NewAlertService(alertRepo, nil)
+	seenService := NewSeenArticleService(alertRepo, nil)
+	provider := &stubProvider{name: "rss", articles: []models.NewsArticle{
+		{Title: "Stocks rally", URL: "https://example.com/stocks"},
+	}}
+	ingestionService := NewIngestionService([]NewsProvider{provider}, repositories.NewArticleRepository(db), nil, time.Minute, 0)
+	if _, err := ingestionService.Ingest(ctx); err != nil {
+		t.Fatalf("Failed to ingest: %v", err)
+	}
+
+	owner := createVerifiedUser(t, db, "owner@example.com")
+	past := time.Now().Add(-time.Minute)
+	alert := &models.Alert{UserID: owner.ID, Topic: "Markets", Keywords: models.Keywords{"stocks"}, Frequency: models.FrequencyRealTime, NextRunAt: &past, Active: true}
+	if err := alertRepo.Create(ctx, alert); err != nil {
+		t.Fatalf("Failed to create alert: %v", err)
+	}
+
+	newReplica := func(leader LeaderElection) *backgroundService {
+		return &backgroundService{
+			alertService:        alertService,
+			newsService:         NewNewsService(nil, nil),
+			ingestionService:    ingestionService,
+			seenService:         seenService,
+			notificationService: &recordingNotifier{},
+			config:              SchedulerConfig{MaxCatchUp: time.Hour},
+			leader:              leader,
+			ctx:                 context.Background(),
+		}
+	}
+
+	// The first leader's lease runs out and the second takes over, then runs
+	// the alert under its larger token
+	first := newTestElection(client, clock)
+	second := newTestElection(client, clock)
+	if !first.campaign(ctx) {
+		t.Fatal("Expected the first instance to become leader")
+	}
+	staleToken := first.Token()
+	mr.FastForward(20 * time.Second)
+	clock.now = clock.now.Add(20 * time.Second)
+	if !second.campaign(ctx) {
+		t.Fatal("Expected the second instance to take over")
+	}
+
+	if err := newReplica(second).ProcessAlerts(); err != nil {
+		t.Fatalf("Failed to process alerts: %v", err)
+	}
+
+	// The old leader can no longer claim alerts or queue notifications
+	if err := db.Model(alert).Update("next_run_at", past).Error; err != nil {
+		t.Fatalf("Failed to make the alert due again: %v", err)
+	}
+	if _, err := alertService.ClaimDueAlerts(ctx, time.Now(), 10, staleToken); !errors.Is(err, repositories.ErrStaleFencingToken) {
+		t.Errorf("Expected the claim to be fenced off but got %v", err)
+	}
+	if due, _ := alertService.CountDueAlerts(ctx, time.Now()); due != 1 {
+		t.Errorf("Expected the alert to stay due for the leader but got %d due", due)
+	}
+	if err := alertService.UpdateLastChecked(ctx, alert.ID, staleToken); !errors.Is(err, repositories.ErrStaleFencingToken) {
+		t.Errorf("Expected marking the alert checked to be fenced off but got %v", err)
+	}
+
+	articles := []models.NewsArticle{{Title: "Stocks fall", URL: "https://example.com/fall"}}
+	notification := &models.Notification{UserID: owner.ID, Message: "stale", NextAttemptAt: time.Now()}
+	if err := seenService.RecordDelivery(ctx, alert.ID, articles, notification, staleToken); !errors.Is(err, repositories.ErrStaleFencingToken) {
+		t.Errorf("Expected the delivery to be fenced off but got %v", err)
+	}
+	if err := seenService.RecordHeld(ctx, alert.ID, articles, time.Now(), staleToken); !errors.Is(err, repositories.ErrStaleFencingToken) {
+		t.Errorf("Expected the held delivery to be fenced off but got %v", err)
+	}
+
+	// A replica that knows it lost the lease stops before delivering
+	stale := newReplica(first)
+	if err := stale.ProcessAlerts(); err == nil {
+		t.Error("Expected a replica that is not the leader not to run alerts")
+	}
+	if err := stale.runAlert(routedAlert{alert: alert, articles: articles}, staleToken); err == nil {
+		t.Error("Expected a replica that lost the lease not to deliver")
+	}
+
+	var queued int64
+	db.Model(&models.Notification{}).Count(&queued)
+	if queued != 1 {
+		t.Errorf("Expected only the leader's notification to be queued but got %d", queued)
+	}
+}
@@ -1,241 +1,121 @@
 package services
 
 import (
-	"encoding/json"
-	"encoding/xml"
-	"fmt"
-	"io"
+	"context"
+	"errors"
 	"net/http"
-	"net/url"
-	"strings"
 	"time"
 
+	"news-to-text/internal/dedup"
 	"news-to-text/internal/models"
+	"news-to-text/internal/query"
 	"news-to-text/pkg/logger"
 )
 
 type NewsService interface {
-	FetchNewsByKeywords(keywords []string) ([]models.NewsArticle, error)
-	FetchNewsByCategory(category string) ([]models.NewsArticle, error)
-	FetchRSSFeed(url string) ([]models.NewsArticle, error)
+	FetchNewsByKeywords(ctx context.Context, keywords []string, filter NewsFilter) ([]models.NewsArticle, error)
+	FetchNewsByCategory(ctx context.Context, category string) ([]models.NewsArticle, error)
+	FetchRSSFeed(ctx context.Context, url string) ([]models.NewsArticle, error)
 	MatchArticles(articles []models.NewsArticle, keywords []string) []models.NewsArticle
+	MatchQuery(articles []models.NewsArticle, q *query.Query) []models.NewsArticle
+	ExplainQuery(articles []models.NewsArticle, q *query.Query) []models.NewsArticle
 }
 
 type newsService struct {
-	apiKey string
-	client *http.Client
+	providers []NewsProvider
+	ranker    Ranker
 }
 
-func NewNewsService(apiKey string) NewsService {
-	return &newsService{
-		apiKey: apiKey,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-	}
-}
-
-func (s *newsService) FetchNewsByKeywords(keywords []string) ([]models.NewsArticle, error) {
-	if s.apiKey == "" {
-		// Fallback to RSS feeds if no API key
-		return s.fetchFromDefaultRSSFeeds(keywords)
-	}
-
-	// Use The News API for keyword search
-	query := strings.Join(keywords, " ")
-	apiURL := fmt.Sprintf("https://api.thenewsapi.com/v1/news/all?api_token=%s&search=%s&limit=50&sort=published_at",
-		s.apiKey, url.QueryEscape(query))
-
-	resp, err := s.client.Get(apiURL)
-	if err != nil {
-		// Fallback to RSS if API fails
-		logger.Error("The News API failed, falling back to RSS:", err)
-		return s.fetchFromDefaultRSSFeeds(keywords)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		// Fallback to RSS if API returns error
-		logger.Error("The News API returned non-200 status:", resp.StatusCode)
-		return s.fetchFromDefaultRSSFeeds(keywords)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return s.fetchFromDefaultRSSFeeds(keywords)
-	}
-
-	var newsResp models.TheNewsAPIResponse
-	if err := json.Unmarshal(body, &newsResp); err != nil {
-		logger.Error("Failed to parse The News API response:", err)
-		return s.fetchFromDefaultRSSFeeds(keywords)
+// NewNewsService creates a news service backed by the given provider chain.
+// Providers are tried in order until one of them succeeds. Matches are
+// ordered by the ranker, or by a default one without source weights when it
+// is nil.
+func NewNewsService(providers []NewsProvider, ranker Ranker) NewsService {
+	if ranker == nil {
+		ranker = NewRanker(nil, DefaultScoreHalfLife)
 	}
 
-	articles := make([]models.NewsArticle, 0, len(newsResp.Data))
-	for _, article := range newsResp.Data {
-		publishedAt, _ := time.Parse(time.RFC3339, article.PublishedAt)
-
-		articles = append(articles, models.NewsArticle{
-			Title:       article.Title,
-			URL:         article.URL,
-			Source:      article.Source,
-			Description: article.Description,
-			PublishedAt: publishedAt,
-			ImageURL:    article.ImageURL,
-		})
+	return &newsService{
+		providers: providers,
+		ranker:    ranker,
 	}
-
-	return articles, nil
 }
 
-func (s *newsService) FetchNewsByCategory(category string) ([]models.NewsArticle, error) {
-	if s.apiKey == "" {
-		// Fallback to RSS feeds if no API key
-		return s.fetchFromDefaultRSSFeeds([]string{category})
-	}
-
-	// Map common categories to The News API categories
-	categoryMap := map[string]string{
-		"technology": "tech",
-		"tech":       "tech",
-		"business":   "business",
-		"sports":     "sports",
-		"health":     "health",
-		"science":    "science",
-		"stocks":     "business", // Map stocks to business category
-		"finance":    "business",
-	}
-
-	apiCategory, exists := categoryMap[strings.ToLower(category)]
-	if !exists {
-		apiCategory = "general"
-	}
-
-	// Use The News API for category search
-	apiURL := fmt.Sprintf("https://api.thenewsapi.com/v1/news/top?api_token=%s&categories=%s&limit=50&sort=published_at",
-		s.apiKey, apiCategory)
-
-	resp, err := s.client.Get(apiURL)
+// FetchNewsByKeywords searches the providers for the keywords, keeping only
+// the articles in the filter's languages and locales even when a provider
+// can't filter by them itself
+func (s *newsService) FetchNewsByKeywords(ctx context.Context, keywords []string, filter NewsFilter) ([]models.NewsArticle, error) {
+	articles, err := s.fetchWithFallback(ctx, func(provider NewsProvider) ([]models.NewsArticle, error) {
+		return provider.FetchByKeywords(ctx, keywords, filter)
+	})
 	if err != nil {
-		// Fallback to RSS if API fails
-		logger.Error("The News API failed, falling back to RSS:", err)
-		return s.fetchFromDefaultRSSFeeds([]string{category})
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		// Fallback to RSS if API returns error
-		logger.Error("The News API returned non-200 status:", resp.StatusCode)
-		return s.fetchFromDefaultRSSFeeds([]string{category})
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return s.fetchFromDefaultRSSFeeds([]string{category})
-	}
-
-	var newsResp models.TheNewsAPIResponse
-	if err := json.Unmarshal(body, &newsResp); err != nil {
-		logger.Error("Failed to parse The News API response:", err)
-		return s.fetchFromDefaultRSSFeeds([]string{category})
-	}
-
-	articles := make([]models.NewsArticle, 0, len(newsResp.Data))
-	for _, article := range newsResp.Data {
-		publishedAt, _ := time.Parse(time.RFC3339, article.PublishedAt)
-
-		articles = append(articles, models.NewsArticle{
-			Title:       article.Title,
-			URL:         article.URL,
-			Source:      article.Source,
-			Description: article.Description,
-			PublishedAt: publishedAt,
-			ImageURL:    article.ImageURL,
-			Category:    category,
-		})
+		return nil, err
 	}
 
-	return articles, nil
+	return filterNews(articles, filter), nil
 }
 
-func (s *newsService) FetchRSSFeed(url string) ([]models.NewsArticle, error) {
-	resp, err := s.client.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+func (s *newsService) FetchNewsByCategory(ctx context.Context, category string) ([]models.NewsArticle, error) {
+	return s.fetchWithFallback(ctx, func(provider NewsProvider) ([]models.NewsArticle, error) {
+		return provider.FetchByCategory(ctx, category)
+	})
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+func (s *newsService) FetchRSSFeed(ctx context.Context, url string) ([]models.NewsArticle, error) {
+	return newFeedFetcher(&http.Client{Timeout: 30 * time.Second}, nil).Fetch(ctx, url)
+}
 
-	var feed models.RSSFeed
-	if err := xml.Unmarshal(body, &feed); err != nil {
-		return nil, err
-	}
+// MatchArticles returns the articles matching any of the keywords, best
+// scored first, keeping only one article per story when several sources
+// carry the same one
+func (s *newsService) MatchArticles(articles []models.NewsArticle, keywords []string) []models.NewsArticle {
+	return s.MatchQuery(articles, query.FromKeywords(keywords))
+}
 
-	articles := make([]models.NewsArticle, 0, len(feed.Items))
-	for _, item := range feed.Items {
-		publishedAt, _ := time.Parse(time.RFC1123Z, item.PubDate)
-
-		articles = append(articles, models.NewsArticle{
-			Title:       item.Title,
-			URL:         item.Link,
-			Source:      feed.Title,
-			Description: item.Description,
-			PublishedAt: publishedAt,
-		})
-	}
+// MatchQuery returns the articles matching the query expression, best scored
+// first, keeping only the best scored article of each story
+func (s *newsService) MatchQuery(articles []models.NewsArticle, q *query.Query) []models.NewsArticle {
+	return dedup.Deduplicate(s.ranker.Rank(filterMatches(articles, q), q))
+}
 
-	return articles, nil
+// ExplainQuery is MatchQuery with an explanation of the matched terms and
+// the score attached to each article
+func (s *newsService) ExplainQuery(articles []models.NewsArticle, q *query.Query) []models.NewsArticle {
+	return dedup.Deduplicate(s.ranker.Explain(filterMatches(articles, q), q))
 }
 
-func (s *newsService) MatchArticles(articles []models.NewsArticle, keywords []string) []models.NewsArticle {
+func filterMatches(articles []models.NewsArticle, q *query.Query) []models.NewsArticle {
 	var matched []models.NewsArticle
-
 	for _, article := range articles {
-		if s.articleMatches(article, keywords) {
+		if q.Match(article) {
 			matched = append(matched, article)
 		}
 	}
-
 	return matched
 }
 
-func (s *newsService) articleMatches(article models.NewsArticle, keywords []string) bool {
-	content := strings.ToLower(article.Title + " " + article.Description)
-
-	for _, keyword := range keywords {
-		if strings.Contains(content, strings.ToLower(keyword)) {
-			return true
-		}
+// fetchWithFallback walks the provider chain and returns the result of the
+// first provider that does not fail. It gives up without trying the next
+// provider once ctx is done.
+func (s *newsService) fetchWithFallback(ctx context.Context, fetch func(NewsProvider) ([]models.NewsArticle, error)) ([]models.NewsArticle, error) {
+	if len(s.providers) == 0 {
+		return nil, errors.New("no news providers configured")
 	}
 
-	return false
-}
-
-func (s *newsService) fetchFromDefaultRSSFeeds(keywords []string) ([]models.NewsArticle, error) {
-	rssFeeds := []string{
-		"https://techcrunch.com/feed/",
-		"https://feeds.reuters.com/reuters/technologyNews",
-		"https://hnrss.org/frontpage",
-		"https://feeds.bloomberg.com/markets/news.rss",
-	}
-
-	var allArticles []models.NewsArticle
+	var lastErr error
+	for _, provider := range s.providers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 
-	for _, feedURL := range rssFeeds {
-		articles, err := s.FetchRSSFeed(feedURL)
+		articles, err := fetch(provider)
 		if err != nil {
-			logger.Error("Failed to fetch RSS feed:", feedURL, err)
+			logger.Error("News provider", provider.Name(), "failed, trying next provider:", err)
+			lastErr = err
 			continue
 		}
-
-		// Filter articles by keywords
-		matched := s.MatchArticles(articles, keywords)
-		allArticles = append(allArticles, matched...)
+		return articles, nil
 	}
 
-	return allArticles, nil
-}
\ No newline at end of file
+	return nil, lastErr
+}
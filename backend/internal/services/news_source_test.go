@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/repositories"
+)
+
+func TestNewsSourceService_CRUD(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	sourceService := NewNewsSourceService(repositories.NewNewsSourceRepository(db))
+
+	inactive := false
+	created, err := sourceService.CreateSource(ctx, &models.NewsSourceCreateRequest{
+		Name:       "Example Wire",
+		URL:        "https://example.com",
+		RSSFeedURL: "https://example.com/feed.xml",
+		Category:   "Tech",
+		Active:     &inactive,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+
+	stored, err := sourceService.GetSourceByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Failed to get source: %v", err)
+	}
+	if stored.Active {
+		t.Errorf("Expected source to be created inactive")
+	}
+
+	category := "Stocks"
+	active := true
+	updated, err := sourceService.UpdateSource(ctx, created.ID, &models.NewsSourceUpdateRequest{
+		Category: &category,
+		Active:   &active,
+	})
+	if err != nil {
+		t.Fatalf("Failed to update source: %v", err)
+	}
+	if updated.Category != "Stocks" || !updated.Active {
+		t.Errorf("Expected updated category and active flag but got %+v", updated)
+	}
+
+	if err := sourceService.DeleteSource(ctx, created.ID); err != nil {
+		t.Fatalf("Failed to delete source: %v", err)
+	}
+
+	if _, err := sourceService.GetSourceByID(ctx, created.ID); err == nil || err.Error() != "news source not found" {
+		t.Errorf("Expected not found error after delete but got %v", err)
+	}
+}
+
+func TestRSSProvider_ReadsActiveSources(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tech.xml":
+			w.Write([]byte(`<rss version="2.0"><channel><title>Tech Feed</title>
+				<item><title>New chip released</title><link>https://example.com/chip</link></item>
+			</channel></rss>`))
+		case "/markets.xml":
+			w.Write([]byte(`<rss version="2.0"><channel><title>Markets Feed</title>
+				<item><title>Stocks rally on chip demand</title><link>https://example.com/rally</link></item>
+			</channel></rss>`))
+		default:
+			t.Errorf("Unexpected request for disabled feed %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	sourceRepo := repositories.NewNewsSourceRepository(db)
+	sources := []*models.NewsSource{
+		{Name: "Tech Wire", URL: server.URL, RSSFeedURL: server.URL + "/tech.xml", Category: "Tech", Active: true},
+		{Name: "Market Wire", URL: server.URL, RSSFeedURL: server.URL + "/markets.xml", Category: "Stocks", Active: true},
+		{Name: "Disabled Wire", URL: server.URL, RSSFeedURL: server.URL + "/disabled.xml", Category: "Tech", Active: true},
+	}
+	for _, source := range sources {
+		if err := sourceRepo.Create(ctx, source); err != nil {
+			t.Fatalf("Failed to create source: %v", err)
+		}
+	}
+	sources[2].Active = false
+	if err := sourceRepo.Update(ctx, sources[2]); err != nil {
+		t.Fatalf("Failed to disable source: %v", err)
+	}
+
+	provider, err := NewProvider(ProviderConfig{Name: ProviderRSS, Sources: sourceRepo})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	byKeyword, err := provider.FetchByKeywords(ctx, []string{"chip"}, NewsFilter{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(byKeyword) != 2 {
+		t.Fatalf("Expected 2 keyword matches but got %d", len(byKeyword))
+	}
+	if byKeyword[0].Source != "Tech Wire" || byKeyword[0].Category != "Tech" {
+		t.Errorf("Expected source name and category from database but got %+v", byKeyword[0])
+	}
+
+	byCategory, err := provider.FetchByCategory(ctx, "stocks")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(byCategory) != 1 || byCategory[0].Source != "Market Wire" {
+		t.Errorf("Expected only the Stocks source articles but got %v", byCategory)
+	}
+}
@@ -7,7 +7,7 @@ import (
 )
 
 func TestNewsService_MatchArticles(t *testing.T) {
-	newsService := NewNewsService("") // Empty API key will use RSS fallback
+	newsService := NewNewsService(nil, nil) // Matching does not need any providers
 
 	articles := []models.NewsArticle{
 		{
@@ -58,6 +58,11 @@ func TestNewsService_MatchArticles(t *testing.T) {
 			keywords:        []string{"APPLE", "tesla"},
 			expectedMatches: 2, // Apple and Tesla articles
 		},
+		{
+			name:            "Whole words only",
+			keywords:        []string{"ice", "earn", "coin"},
+			expectedMatches: 0, // Not "Price", "Earnings" or "Bitcoin"
+		},
 	}
 
 	for _, tt := range tests {
@@ -74,8 +79,8 @@ func TestNewsService_MatchArticles(t *testing.T) {
 	}
 }
 
-func TestNewsService_articleMatches(t *testing.T) {
-	newsService := newsService{} // Access private method for testing
+func TestNewsService_MatchArticlesKeywords(t *testing.T) {
+	newsService := NewNewsService(nil, nil)
 
 	article := models.NewsArticle{
 		Title:       "Apple Announces New MacBook Pro with M3 Chip",
@@ -117,11 +122,26 @@ func TestNewsService_articleMatches(t *testing.T) {
 			keywords: []string{"Mac"},
 			expected: true, // Should match "MacBook"
 		},
+		{
+			name:     "Word inside another word",
+			keywords: []string{"Pr", "arn", "chine"},
+			expected: false,
+		},
+		{
+			name:     "Exact case match",
+			keywords: []string{"=Apple"},
+			expected: true,
+		},
+		{
+			name:     "Exact case mismatch",
+			keywords: []string{"=APPLE", "=macbook"},
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := newsService.articleMatches(article, tt.keywords)
+			result := len(newsService.MatchArticles([]models.NewsArticle{article}, tt.keywords)) == 1
 
 			if result != tt.expected {
 				t.Errorf("Expected %v but got %v for keywords %v", tt.expected, result, tt.keywords)
@@ -1,85 +1,42 @@
 package services
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
-	"time"
 
 	"news-to-text/internal/models"
 	"news-to-text/pkg/logger"
 )
 
 type NotificationService interface {
-	SendSMS(phoneNumber, message string) error
-	SendNewsAlert(user *models.User, alert *models.Alert, articles []models.NewsArticle) error
+	SendSMS(ctx context.Context, phoneNumber, message string) (string, error)
 	FormatNewsMessage(alert *models.Alert, articles []models.NewsArticle) string
+	FormatHeldMessage(held []models.AlertHistory) string
 }
 
 type notificationService struct {
-	smsAPIKey string
-	client    *http.Client
+	sms SMSProvider
 }
 
-func NewNotificationService(smsAPIKey string) NotificationService {
+// NewNotificationService creates a notification service that texts through
+// the SMS provider, or only logs the texts when it is nil
+func NewNotificationService(sms SMSProvider) NotificationService {
 	return &notificationService{
-		smsAPIKey: smsAPIKey,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		sms: sms,
 	}
 }
 
-func (s *notificationService) SendSMS(phoneNumber, message string) error {
-	if s.smsAPIKey == "" {
-		// Mock SMS sending for development
-		logger.Info("Mock SMS sent to", phoneNumber, ":", message)
-		return nil
+// SendSMS texts the message and returns the gateway's ID for it
+func (s *notificationService) SendSMS(ctx context.Context, phoneNumber, message string) (string, error) {
+	if s.sms == nil {
+		// Mock SMS sending for development. The text is logged so its
+		// verification codes can be read; production refuses to start
+		// without an SMS provider, so it never gets here.
+		logger.Info("Mock SMS sent to", maskPhoneNumber(phoneNumber), ":", message)
+		return "", nil
 	}
 
-	// Example implementation for Twilio
-	// You can replace this with your preferred SMS provider
-	payload := map[string]string{
-		"To":   phoneNumber,
-		"From": "+1234567890", // Your Twilio phone number
-		"Body": message,
-	}
-
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequest("POST", "https://api.twilio.com/2010-04-01/Accounts/YOUR_ACCOUNT_SID/Messages.json", bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.SetBasicAuth("YOUR_ACCOUNT_SID", s.smsAPIKey)
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("failed to send SMS, status code: %d", resp.StatusCode)
-	}
-
-	return nil
-}
-
-func (s *notificationService) SendNewsAlert(user *models.User, alert *models.Alert, articles []models.NewsArticle) error {
-	message := s.FormatNewsMessage(alert, articles)
-
-	// For now, we'll assume users have phone numbers in their email field
-	// In a real implementation, you'd have a separate phone field
-	phoneNumber := "+1234567890" // Placeholder
-
-	return s.SendSMS(phoneNumber, message)
+	return s.sms.Send(ctx, phoneNumber, message)
 }
 
 func (s *notificationService) FormatNewsMessage(alert *models.Alert, articles []models.NewsArticle) string {
@@ -104,4 +61,32 @@ func (s *notificationService) FormatNewsMessage(alert *models.Alert, articles []
 	}
 
 	return message
-}
\ No newline at end of file
+}
+
+// FormatHeldMessage lists the held articles under the topic of their alert,
+// up to three per alert. held is ordered by alert.
+func (s *notificationService) FormatHeldMessage(held []models.AlertHistory) string {
+	message := "🔔 News Alerts from your quiet hours\n"
+
+	maxArticles := 3
+	count := 0
+	for i, history := range held {
+		if i == 0 || history.AlertID != held[i-1].AlertID {
+			if count > maxArticles {
+				message += fmt.Sprintf("... and %d more\n", count-maxArticles)
+			}
+			message += fmt.Sprintf("\n%s\n", history.Alert.Topic)
+			count = 0
+		}
+
+		count++
+		if count <= maxArticles {
+			message += fmt.Sprintf("%d. %s\n%s\n", count, history.NewsTitle, history.NewsURL)
+		}
+	}
+	if count > maxArticles {
+		message += fmt.Sprintf("... and %d more\n", count-maxArticles)
+	}
+
+	return message
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// phoneCodeTTL is how long a texted verification code can be used
+	phoneCodeTTL = 10 * time.Minute
+
+	// phoneCodeMaxAttempts is how many wrong codes are accepted before the
+	// code is thrown away and a new one has to be requested
+	phoneCodeMaxAttempts = 5
+
+	// phoneCodeResendInterval is how long a user waits before another code
+	// is texted, so the endpoint can't be used to flood a number
+	phoneCodeResendInterval = time.Minute
+)
+
+// e164Pattern is a "+", a country code that doesn't start with 0, and at
+// most 15 digits in all
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// phoneSeparators are dropped from phone numbers before they are validated
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+
+// checkPhoneCodeScript counts an attempt at the pending code and returns
+// the number of attempts so far, or 0 when no code is pending
+var checkPhoneCodeScript = redis.NewScript(`
+if redis.call("HEXISTS", KEYS[1], "code") == 0 then
+	return 0
+end
+return redis.call("HINCRBY", KEYS[1], "attempts", 1)
+`)
+
+// normalizePhoneNumber returns number in E.164 form, without the spaces,
+// dashes, dots and parentheses it is often written with
+func normalizePhoneNumber(number string) (string, error) {
+	number = phoneSeparators.Replace(strings.TrimSpace(number))
+	if !e164Pattern.MatchString(number) {
+		return "", errors.New("invalid phone number: expected E.164 format such as +14155552671")
+	}
+	return number, nil
+}
+
+// maskPhoneNumber hides all but the last two digits of a number, for logs
+func maskPhoneNumber(number string) string {
+	if len(number) <= 2 {
+		return strings.Repeat("*", len(number))
+	}
+	return strings.Repeat("*", len(number)-2) + number[len(number)-2:]
+}
+
+// newPhoneCode returns a random six digit verification code
+func newPhoneCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashPhoneCode is what is stored in place of a verification code: an HMAC
+// under the server's secret, so the few million possible codes can't be
+// tried against it by anyone who can only read Redis. The user and number
+// are included so a code is only good for the number it was sent to.
+func hashPhoneCode(secret []byte, userID uint, phone, code string) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%d:%s:%s", userID, phone, code)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func phoneVerificationKey(userID uint) string {
+	return fmt.Sprintf("user:%d:phone_verification", userID)
+}
+
+func phoneResendKey(userID uint) string {
+	return fmt.Sprintf("user:%d:phone_verification:resend", userID)
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/repositories"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestNormalizePhoneNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		number   string
+		expected string
+	}{
+		{"E.164", "+14155552671", "+14155552671"},
+		{"With separators", "+1 (415) 555-2671", "+14155552671"},
+		{"With dots", " +44.20.7946.0958 ", "+442079460958"},
+		{"Without a plus", "14155552671", ""},
+		{"Country code starting with 0", "+04155552671", ""},
+		{"Too long", "+1415555267112345", ""},
+		{"Too short", "+12345", ""},
+		{"Letters", "+1415CALLNOW", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized, err := normalizePhoneNumber(tt.number)
+			if tt.expected == "" {
+				if err == nil || !strings.HasPrefix(err.Error(), "invalid phone number") {
+					t.Errorf("Expected an invalid phone number error but got %q, %v", normalized, err)
+				}
+				return
+			}
+			if err != nil || normalized != tt.expected {
+				t.Errorf("Expected %q but got %q, %v", tt.expected, normalized, err)
+			}
+		})
+	}
+}
+
+func TestMaskPhoneNumber(t *testing.T) {
+	for number, expected := range map[string]string{"+14155552671": "**********71", "+1": "**", "": ""} {
+		if masked := maskPhoneNumber(number); masked != expected {
+			t.Errorf("Expected %q to be masked as %q but got %q", number, expected, masked)
+		}
+	}
+}
+
+func TestHashPhoneCode(t *testing.T) {
+	hash := hashPhoneCode([]byte("secret"), 1, "+14155552671", "123456")
+	if hash != hashPhoneCode([]byte("secret"), 1, "+14155552671", "123456") {
+		t.Error("Expected the same code to hash the same")
+	}
+	if hash == hashPhoneCode([]byte("other"), 1, "+14155552671", "123456") {
+		t.Error("Expected the hash to depend on the server secret")
+	}
+	if hash == hashPhoneCode([]byte("secret"), 1, "+14155552672", "123456") {
+		t.Error("Expected the hash to depend on the number")
+	}
+}
+
+// sentCode is the verification code in the last message the notifier sent
+func sentCode(t *testing.T, notifier *recordingNotifier) string {
+	t.Helper()
+
+	if len(notifier.messages) == 0 {
+		t.Fatal("Expected a verification code to be sent")
+	}
+	code := regexp.MustCompile(`[0-9]{6}`).FindString(notifier.messages[len(notifier.messages)-1])
+	if code == "" {
+		t.Fatalf("Expected a six digit code in %q", notifier.messages[len(notifier.messages)-1])
+	}
+	return code
+}
+
+// wrongCode is a six digit code other than code
+func wrongCode(code string) string {
+	if code == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+func TestUserService_PhoneVerification(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	notifier := &recordingNotifier{}
+
+	userRepo := repositories.NewUserRepository(db)
+	user := &models.User{Email: "phone@example.com", Password: "hashed"}
+	if err := userRepo.Create(ctx, user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	userService := NewUserService(userRepo, client, notifier, "test-secret")
+
+	if err := userService.StartPhoneVerification(ctx, user.ID, &models.PhoneUpdateRequest{Phone: "415-555-2671"}); err == nil || !strings.HasPrefix(err.Error(), "invalid phone number") {
+		t.Errorf("Expected an invalid phone number error but got %v", err)
+	}
+	if _, err := userService.VerifyPhone(ctx, user.ID, &models.PhoneVerifyRequest{Code: "123456"}); err == nil || err.Error() != "no phone verification pending" {
+		t.Errorf("Expected no pending verification but got %v", err)
+	}
+
+	if err := userService.StartPhoneVerification(ctx, user.ID, &models.PhoneUpdateRequest{Phone: "+1 415 555 2671"}); err != nil {
+		t.Fatalf("Failed to start verification: %v", err)
+	}
+	code := sentCode(t, notifier)
+
+	// Only a hash of the code is stored
+	stored := mr.HGet(phoneVerificationKey(user.ID), "code")
+	if stored == "" || stored == code || mr.TTL(phoneVerificationKey(user.ID)) != phoneCodeTTL {
+		t.Errorf("Expected a hashed code that expires in %v but got %q expiring in %v", phoneCodeTTL, stored, mr.TTL(phoneVerificationKey(user.ID)))
+	}
+
+	// Codes can't be requested again straight away
+	if err := userService.StartPhoneVerification(ctx, user.ID, &models.PhoneUpdateRequest{Phone: "+14155552671"}); err == nil || err.Error() != "verification code recently sent" {
+		t.Errorf("Expected the resend to be refused but got %v", err)
+	}
+
+	// The number isn't used until it is verified
+	profile, err := userService.GetProfile(ctx, user.ID)
+	if err != nil || profile.Phone != "" || profile.PhoneVerified {
+		t.Errorf("Expected no phone number before verification but got %+v, %v", profile, err)
+	}
+
+	if _, err := userService.VerifyPhone(ctx, user.ID, &models.PhoneVerifyRequest{Code: wrongCode(code)}); err == nil || err.Error() != "invalid verification code" {
+		t.Errorf("Expected a wrong code to be refused but got %v", err)
+	}
+
+	profile, err = userService.VerifyPhone(ctx, user.ID, &models.PhoneVerifyRequest{Code: code})
+	if err != nil {
+		t.Fatalf("Failed to verify phone: %v", err)
+	}
+	if profile.Phone != "+14155552671" || !profile.PhoneVerified {
+		t.Errorf("Expected a verified phone number but got %+v", profile)
+	}
+
+	// A code is only good once
+	if _, err := userService.VerifyPhone(ctx, user.ID, &models.PhoneVerifyRequest{Code: code}); err == nil || err.Error() != "no phone verification pending" {
+		t.Errorf("Expected the used code to be gone but got %v", err)
+	}
+}
+
+func TestUserService_PhoneVerificationLimits(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	notifier := &recordingNotifier{}
+
+	userRepo := repositories.NewUserRepository(db)
+	user := &models.User{Email: "phone@example.com", Password: "hashed"}
+	if err := userRepo.Create(ctx, user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	userService := NewUserService(userRepo, client, notifier, "test-secret")
+
+	if err := userService.StartPhoneVerification(ctx, user.ID, &models.PhoneUpdateRequest{Phone: "+14155552671"}); err != nil {
+		t.Fatalf("Failed to start verification: %v", err)
+	}
+	code := sentCode(t, notifier)
+
+	for i := 0; i < phoneCodeMaxAttempts; i++ {
+		if _, err := userService.VerifyPhone(ctx, user.ID, &models.PhoneVerifyRequest{Code: wrongCode(code)}); err == nil || err.Error() != "invalid verification code" {
+			t.Fatalf("Attempt %d: expected a wrong code to be refused but got %v", i+1, err)
+		}
+	}
+
+	// Past the limit even the right code is refused, and the code is dropped
+	if _, err := userService.VerifyPhone(ctx, user.ID, &models.PhoneVerifyRequest{Code: code}); err == nil || err.Error() != "too many verification attempts" {
+		t.Errorf("Expected too many attempts but got %v", err)
+	}
+	if _, err := userService.VerifyPhone(ctx, user.ID, &models.PhoneVerifyRequest{Code: code}); err == nil || err.Error() != "no phone verification pending" {
+		t.Errorf("Expected the code to be dropped but got %v", err)
+	}
+
+	// A new code can be requested after the resend interval, and expires
+	mr.FastForward(phoneCodeResendInterval)
+	if err := userService.StartPhoneVerification(ctx, user.ID, &models.PhoneUpdateRequest{Phone: "+14155552671"}); err != nil {
+		t.Fatalf("Failed to start verification again: %v", err)
+	}
+	code = sentCode(t, notifier)
+
+	mr.FastForward(phoneCodeTTL)
+	if _, err := userService.VerifyPhone(ctx, user.ID, &models.PhoneVerifyRequest{Code: code}); err == nil || err.Error() != "no phone verification pending" {
+		t.Errorf("Expected the code to expire but got %v", err)
+	}
+
+	stored, err := userRepo.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Failed to load user: %v", err)
+	}
+	if stored.HasVerifiedPhone() {
+		t.Errorf("Expected the phone number to stay unverified but got %+v", stored)
+	}
+}
+
+func TestBackgroundService_UnverifiedPhone(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	user := &models.User{Email: "unverified@example.com", Password: "hashed"}
+	if err := repositories.NewUserRepository(db).Create(ctx, user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	alertRepo := repositories.NewAlertRepository(db)
+	provider := &stubProvider{name: "rss", articles: []models.NewsArticle{
+		{Title: "Stocks rally", URL: "https://example.com/stocks"},
+	}}
+	ingestionService := NewIngestionService([]NewsProvider{provider}, repositories.NewArticleRepository(db), nil, time.Minute, 0)
+	if _, err := ingestionService.Ingest(ctx); err != nil {
+		t.Fatalf("Failed to ingest: %v", err)
+	}
+	notifier := &recordingNotifier{}
+
+	background := &backgroundService{
+		alertService:        NewAlertService(alertRepo, nil),
+		newsService:         NewNewsService(nil, nil),
+		ingestionService:    ingestionService,
+		seenService:         NewSeenArticleService(alertRepo, nil),
+		notificationService: notifier,
+		config:              SchedulerConfig{MaxCatchUp: time.Hour},
+		ctx:                 context.Background(),
+	}
+
+	due := time.Now().Add(-time.Minute)
+	alert := &models.Alert{UserID: user.ID, Topic: "Markets", Keywords: models.Keywords{"stocks"}, Frequency: models.FrequencyRealTime, NextRunAt: &due, Active: true}
+	if err := alertRepo.Create(ctx, alert); err != nil {
+		t.Fatalf("Failed to create alert: %v", err)
+	}
+
+	if err := background.ProcessAlerts(); err != nil {
+		t.Fatalf("Failed to process alerts: %v", err)
+	}
+	if len(notifier.sent) != 0 {
+		t.Errorf("Expected nothing to be queued for an unverified number but got %v", notifier.sent)
+	}
+
+	// A notification queued for the user is not texted either
+	notificationRepo := repositories.NewNotificationRepository(db)
+	notification := &models.Notification{UserID: user.ID, Message: "Markets", NextAttemptAt: time.Now()}
+	if err := NewSeenArticleService(alertRepo, nil).RecordDelivery(ctx, alert.ID, provider.articles, notification, 0); err != nil {
+		t.Fatalf("Failed to record delivery: %v", err)
+	}
+
+	dispatcher := NewNotificationDispatcher(notificationRepo, notifier, DispatcherConfig{MaxAttempts: 3, RetryBase: time.Second, RetryMax: time.Minute})
+	if sent, err := dispatcher.Dispatch(ctx); err != nil || sent != 0 {
+		t.Errorf("Expected nothing to be sent but got %d, %v", sent, err)
+	}
+	if len(notifier.messages) != 0 {
+		t.Errorf("Expected no texts but got %v", notifier.messages)
+	}
+
+	stored, err := notificationRepo.GetByID(ctx, notification.ID)
+	if err != nil {
+		t.Fatalf("Failed to load notification: %v", err)
+	}
+	if stored.Status != models.NotificationDead || stored.LastError != "phone number not verified" {
+		t.Errorf("Expected the notification to be dead-lettered for the unverified number but got %+v", stored)
+	}
+}
+
+func TestUserService_PhoneVerificationRefusedNumber(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	userRepo := repositories.NewUserRepository(db)
+	user := &models.User{Email: "phone@example.com", Password: "hashed"}
+	if err := userRepo.Create(ctx, user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	// The gateway refuses the number for good
+	notifier := &recordingNotifier{err: &SMSError{Code: 21614, Status: 400, Message: "'To' number is not a valid mobile number", Permanent: true}}
+	userService := NewUserService(userRepo, client, notifier, "test-secret")
+
+	err = userService.StartPhoneVerification(ctx, user.ID, &models.PhoneUpdateRequest{Phone: "+14155552671"})
+	if err == nil || !strings.HasPrefix(err.Error(), "invalid phone number") {
+		t.Errorf("Expected an invalid phone number error but got %v", err)
+	}
+
+	// A failed send doesn't leave a code behind or hold up the next request
+	if mr.Exists(phoneVerificationKey(user.ID)) || mr.Exists(phoneResendKey(user.ID)) {
+		t.Errorf("Expected the pending verification to be dropped")
+	}
+	notifier.err = nil
+	if err := userService.StartPhoneVerification(ctx, user.ID, &models.PhoneUpdateRequest{Phone: "+14155552672"}); err != nil {
+		t.Errorf("Expected another number to be accepted straight away but got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"news-to-text/internal/models"
+)
+
+const (
+	defaultPreviewLookback = 24 * time.Hour
+	maxPreviewLookback     = 7 * 24 * time.Hour
+	defaultPreviewLimit    = 20
+)
+
+// AlertPreviewService runs an alert definition over recent news without
+// creating the alert or notifying anyone
+type AlertPreviewService interface {
+	Preview(ctx context.Context, req *models.AlertPreviewRequest) (*models.AlertPreviewResponse, error)
+}
+
+type alertPreviewService struct {
+	newsService      NewsService
+	ingestionService IngestionService
+	now              func() time.Time
+}
+
+func NewAlertPreviewService(newsService NewsService, ingestionService IngestionService) AlertPreviewService {
+	return &alertPreviewService{
+		newsService:      newsService,
+		ingestionService: ingestionService,
+		now:              time.Now,
+	}
+}
+
+// Preview returns the articles the alert would have sent over the lookback
+// window. Stored previews read the articles ingested in the window, as a
+// scheduled run would; live previews fetch from the providers and keep the
+// articles published in the window.
+func (s *alertPreviewService) Preview(ctx context.Context, req *models.AlertPreviewRequest) (*models.AlertPreviewResponse, error) {
+	alert := matchingAlert(&req.AlertMatchRequest)
+	alert.Topic = req.Topic
+	alert.Frequency = req.Frequency
+	if err := validateAlertQuery(alert); err != nil {
+		return nil, err
+	}
+
+	q, err := alertQuery(alert)
+	if err != nil {
+		return nil, err
+	}
+
+	lookback, err := previewLookback(req.Lookback)
+	if err != nil {
+		return nil, err
+	}
+	since := s.now().Add(-lookback)
+
+	source := req.Source
+	if source == "" {
+		source = models.PreviewSourceStored
+	}
+
+	var articles []models.NewsArticle
+	if source == models.PreviewSourceLive {
+		var keywords []string
+		for _, term := range q.Terms() {
+			keywords = append(keywords, term.Text)
+		}
+
+		fetched, err := s.newsService.FetchNewsByKeywords(ctx, keywords, alertNewsFilter(alert))
+		if err != nil {
+			return nil, err
+		}
+		for _, article := range fetched {
+			if !article.PublishedAt.Before(since) {
+				articles = append(articles, article)
+			}
+		}
+	} else {
+		articles, err = s.ingestionService.GetArticlesSince(ctx, since)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	articles = filterAlertArticles(alert, articles)
+	matches := filterByScore(s.newsService.ExplainQuery(articles, q), alert.MinScore)
+	if matches == nil {
+		matches = []models.NewsArticle{}
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultPreviewLimit
+	}
+
+	response := &models.AlertPreviewResponse{
+		Query:    q.String(),
+		Source:   source,
+		Since:    since,
+		Total:    len(matches),
+		Articles: matches,
+	}
+	if len(matches) > limit {
+		response.Articles = matches[:limit]
+	}
+
+	return response, nil
+}
+
+func previewLookback(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultPreviewLookback, nil
+	}
+
+	lookback, err := time.ParseDuration(value)
+	if err != nil || lookback <= 0 {
+		return 0, errors.New("lookback must be a positive duration such as 24h")
+	}
+	if lookback > maxPreviewLookback {
+		return 0, errors.New("lookback must be at most 168h")
+	}
+	return lookback, nil
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/query"
+	"news-to-text/internal/repositories"
+)
+
+func TestAlertPreviewService_Preview(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	now := time.Now()
+	provider := &stubProvider{name: "rss", articles: []models.NewsArticle{
+		{Title: "Apple unveils new iPhone", Description: "Apple shows the iPhone", URL: "https://example.com/iphone", PublishedAt: now.Add(-time.Hour)},
+		{Title: "Apple earnings beat", URL: "https://example.com/earnings", PublishedAt: now.Add(-2 * time.Hour)},
+		{Title: "Old Apple rumor", URL: "https://example.com/rumor", PublishedAt: now.Add(-72 * time.Hour)},
+		{Title: "Tesla recalls vehicles", URL: "https://example.com/tesla", PublishedAt: now.Add(-time.Hour)},
+	}}
+	ingestionService := NewIngestionService([]NewsProvider{provider}, repositories.NewArticleRepository(db), nil, time.Minute, 0)
+	if _, err := ingestionService.Ingest(ctx); err != nil {
+		t.Fatalf("Failed to ingest: %v", err)
+	}
+
+	previewService := NewAlertPreviewService(NewNewsService([]NewsProvider{provider}, nil), ingestionService)
+
+	tests := []struct {
+		name     string
+		request  models.AlertPreviewRequest
+		total    int
+		expected []string
+	}{
+		{
+			name:     "stored articles ranked best first",
+			request:  models.AlertPreviewRequest{AlertMatchRequest: models.AlertMatchRequest{Keywords: []string{"Apple"}}},
+			total:    3,
+			expected: []string{"Apple unveils new iPhone", "Apple earnings beat", "Old Apple rumor"},
+		},
+		{
+			name:     "query",
+			request:  models.AlertPreviewRequest{AlertMatchRequest: models.AlertMatchRequest{Query: "Apple NOT rumor"}},
+			total:    2,
+			expected: []string{"Apple unveils new iPhone", "Apple earnings beat"},
+		},
+		{
+			name:     "limit",
+			request:  models.AlertPreviewRequest{Limit: 1, AlertMatchRequest: models.AlertMatchRequest{Keywords: []string{"Apple"}}},
+			total:    3,
+			expected: []string{"Apple unveils new iPhone"},
+		},
+		{
+			name:     "minimum score",
+			request:  models.AlertPreviewRequest{AlertMatchRequest: models.AlertMatchRequest{Keywords: []string{"Apple"}, MinScore: 50}},
+			total:    1,
+			expected: []string{"Apple unveils new iPhone"},
+		},
+		{
+			name:     "live articles within the lookback",
+			request:  models.AlertPreviewRequest{Source: models.PreviewSourceLive, Lookback: "48h", AlertMatchRequest: models.AlertMatchRequest{Keywords: []string{"Apple"}}},
+			total:    2,
+			expected: []string{"Apple unveils new iPhone", "Apple earnings beat"},
+		},
+		{
+			name:     "no matches",
+			request:  models.AlertPreviewRequest{AlertMatchRequest: models.AlertMatchRequest{Keywords: []string{"Bitcoin"}}},
+			total:    0,
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preview, err := previewService.Preview(ctx, &tt.request)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if preview.Total != tt.total || len(preview.Articles) != len(tt.expected) {
+				t.Fatalf("Expected %d of %d articles but got %d of %d", len(tt.expected), tt.total, len(preview.Articles), preview.Total)
+			}
+			for i, article := range preview.Articles {
+				if article.Title != tt.expected[i] {
+					t.Errorf("Expected %q at %d but got %q", tt.expected[i], i, article.Title)
+				}
+				if article.Explanation == nil || len(article.Explanation.Terms) == 0 {
+					t.Errorf("Expected %q to be explained", article.Title)
+				}
+			}
+		})
+	}
+
+	var alerts, histories int64
+	db.Model(&models.Alert{}).Count(&alerts)
+	db.Model(&models.AlertHistory{}).Count(&histories)
+	if alerts != 0 || histories != 0 {
+		t.Errorf("Expected a preview to store nothing, found %d alerts and %d history rows", alerts, histories)
+	}
+}
+
+func TestAlertPreviewService_InvalidRequests(t *testing.T) {
+	ctx := context.Background()
+	failing := &stubProvider{name: "rss", err: errors.New("provider down")}
+	previewService := NewAlertPreviewService(NewNewsService([]NewsProvider{failing}, nil), nil)
+
+	_, err := previewService.Preview(ctx, &models.AlertPreviewRequest{AlertMatchRequest: models.AlertMatchRequest{Query: "Apple AND"}})
+	var syntaxErr *query.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("Expected a query syntax error but got %v", err)
+	}
+
+	for _, lookback := range []string{"yesterday", "-1h", "200h"} {
+		if _, err := previewService.Preview(ctx, &models.AlertPreviewRequest{Lookback: lookback, AlertMatchRequest: models.AlertMatchRequest{Keywords: []string{"Apple"}}}); err == nil {
+			t.Errorf("Expected lookback %q to be rejected", lookback)
+		}
+	}
+
+	live := &models.AlertPreviewRequest{Source: models.PreviewSourceLive, AlertMatchRequest: models.AlertMatchRequest{Keywords: []string{"Apple"}}}
+	if _, err := previewService.Preview(ctx, live); err == nil || err.Error() != "provider down" {
+		t.Errorf("Expected the provider error but got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"news-to-text/internal/cache"
+	"news-to-text/internal/models"
+	"news-to-text/internal/repositories"
+	"news-to-text/pkg/logger"
+)
+
+// Built-in provider names, usable in the NEWS_PROVIDERS chain
+const (
+	ProviderTheNewsAPI = "thenewsapi"
+	ProviderNewsAPI    = "newsapi"
+	ProviderRSS        = "rss"
+	ProviderFile       = "file"
+)
+
+// ErrProviderNotConfigured is returned by a provider factory when the
+// provider is listed in the chain but lacks the settings it needs to run
+// (for example an API key). Such providers are skipped, not treated as fatal.
+var ErrProviderNotConfigured = errors.New("news provider not configured")
+
+// NewsProvider is a single source of news articles. FetchLatest returns
+// the newest articles without any filtering and is what the ingestion
+// pipeline polls.
+type NewsProvider interface {
+	Name() string
+	FetchLatest(ctx context.Context) ([]models.NewsArticle, error)
+	FetchByKeywords(ctx context.Context, keywords []string, filter NewsFilter) ([]models.NewsArticle, error)
+	FetchByCategory(ctx context.Context, category string) ([]models.NewsArticle, error)
+}
+
+// NewsFilter narrows a search to articles in the given languages, as ISO
+// 639-1 codes such as "en", and locales, as country codes such as "us".
+// Providers pass it on to their API where it is supported; the news service
+// drops whatever else they return outside it.
+type NewsFilter struct {
+	Languages []string
+	Locales   []string
+}
+
+// Match reports whether the article is in one of the filter's languages and
+// locales. Articles whose language or locale is unknown are kept.
+func (f NewsFilter) Match(article models.NewsArticle) bool {
+	return listedOrUnknown(f.Languages, article.Language) && listedOrUnknown(f.Locales, article.Locale)
+}
+
+func listedOrUnknown(list []string, value string) bool {
+	if len(list) == 0 || value == "" {
+		return true
+	}
+	for _, entry := range list {
+		if strings.EqualFold(entry, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterNews keeps the articles matching the filter
+func filterNews(articles []models.NewsArticle, filter NewsFilter) []models.NewsArticle {
+	if len(filter.Languages) == 0 && len(filter.Locales) == 0 {
+		return articles
+	}
+
+	var kept []models.NewsArticle
+	for _, article := range articles {
+		if filter.Match(article) {
+			kept = append(kept, article)
+		}
+	}
+	return kept
+}
+
+// ProviderConfig holds the settings for one provider. Each provider only
+// reads the fields that apply to it.
+type ProviderConfig struct {
+	Name    string
+	APIKey  string
+	BaseURL string
+	Feeds   []string
+	Sources repositories.NewsSourceRepository
+	Cache   cache.Cache
+	Dir     string
+	Timeout time.Duration
+}
+
+// ProviderFactory builds a provider from its configuration
+type ProviderFactory func(cfg ProviderConfig) (NewsProvider, error)
+
+var (
+	providerFactoriesMu sync.RWMutex
+	providerFactories   = map[string]ProviderFactory{}
+)
+
+func init() {
+	RegisterProvider(ProviderTheNewsAPI, newTheNewsAPIProvider)
+	RegisterProvider(ProviderNewsAPI, newNewsAPIProvider)
+	RegisterProvider(ProviderRSS, newRSSProvider)
+	RegisterProvider(ProviderFile, newFileProvider)
+}
+
+// RegisterProvider makes a provider available under the given name.
+// Registering the same name twice replaces the previous factory.
+func RegisterProvider(name string, factory ProviderFactory) {
+	providerFactoriesMu.Lock()
+	defer providerFactoriesMu.Unlock()
+	providerFactories[strings.ToLower(name)] = factory
+}
+
+// NewProvider builds a single registered provider
+func NewProvider(cfg ProviderConfig) (NewsProvider, error) {
+	providerFactoriesMu.RLock()
+	factory, exists := providerFactories[strings.ToLower(cfg.Name)]
+	providerFactoriesMu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("unknown news provider: %s", cfg.Name)
+	}
+
+	return factory(cfg)
+}
+
+// NewProviderChain builds the providers in order. The resulting slice is the
+// fallback chain used by NewsService: earlier providers are tried first.
+// Providers that report ErrProviderNotConfigured are left out of the chain.
+func NewProviderChain(configs []ProviderConfig) ([]NewsProvider, error) {
+	providers := make([]NewsProvider, 0, len(configs))
+
+	for _, cfg := range configs {
+		provider, err := NewProvider(cfg)
+		if err != nil {
+			if errors.Is(err, ErrProviderNotConfigured) {
+				logger.Info("Skipping news provider", cfg.Name, ":", err)
+				continue
+			}
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+
+	if len(providers) == 0 {
+		return nil, errors.New("no news providers configured")
+	}
+
+	return providers, nil
+}
+
+// inferPublishedAt fills in missing publication dates with the fetch time and
+// flags the articles, so date-based filtering doesn't silently drop them
+func inferPublishedAt(articles []models.NewsArticle, fetchedAt time.Time) {
+	for i := range articles {
+		if articles[i].PublishedAt.IsZero() {
+			articles[i].PublishedAt = fetchedAt
+			articles[i].PublishedAtInferred = true
+		}
+	}
+}
+
+func newProviderClient(cfg ProviderConfig) *http.Client {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	return &http.Client{Timeout: timeout}
+}
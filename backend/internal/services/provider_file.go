@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/query"
+)
+
+// fileProvider serves articles from local JSON fixtures. Every *.json file in
+// the directory must contain an array of models.NewsArticle. It is meant for
+// development and tests where hitting real news sources is undesirable.
+type fileProvider struct {
+	dir string
+}
+
+func newFileProvider(cfg ProviderConfig) (NewsProvider, error) {
+	if cfg.Dir == "" {
+		return nil, ErrProviderNotConfigured
+	}
+
+	return &fileProvider{dir: cfg.Dir}, nil
+}
+
+func (p *fileProvider) Name() string {
+	return ProviderFile
+}
+
+func (p *fileProvider) FetchLatest(ctx context.Context) ([]models.NewsArticle, error) {
+	return p.load()
+}
+
+func (p *fileProvider) FetchByKeywords(ctx context.Context, keywords []string, filter NewsFilter) ([]models.NewsArticle, error) {
+	articles, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return filterNews(filterMatches(articles, query.FromKeywords(keywords)), filter), nil
+}
+
+func (p *fileProvider) FetchByCategory(ctx context.Context, category string) ([]models.NewsArticle, error) {
+	articles, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []models.NewsArticle
+	for _, article := range articles {
+		if strings.EqualFold(article.Category, category) {
+			matched = append(matched, article)
+		}
+	}
+
+	return matched, nil
+}
+
+func (p *fileProvider) load() ([]models.NewsArticle, error) {
+	files, err := filepath.Glob(filepath.Join(p.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var articles []models.NewsArticle
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var fileArticles []models.NewsArticle
+		if err := json.Unmarshal(data, &fileArticles); err != nil {
+			return nil, err
+		}
+		articles = append(articles, fileArticles...)
+	}
+
+	inferPublishedAt(articles, time.Now())
+	return articles, nil
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/pkg/dateparse"
+)
+
+const newsAPIBaseURL = "https://newsapi.org/v2"
+
+// Map common categories to NewsAPI.org top-headline categories
+var newsAPICategories = map[string]string{
+	"technology":    "technology",
+	"tech":          "technology",
+	"business":      "business",
+	"sports":        "sports",
+	"health":        "health",
+	"science":       "science",
+	"entertainment": "entertainment",
+	"stocks":        "business",
+	"finance":       "business",
+}
+
+// newsAPIProvider fetches articles from newsapi.org
+type newsAPIProvider struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+func newNewsAPIProvider(cfg ProviderConfig) (NewsProvider, error) {
+	if cfg.APIKey == "" {
+		return nil, ErrProviderNotConfigured
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = newsAPIBaseURL
+	}
+
+	return &newsAPIProvider{
+		apiKey:  cfg.APIKey,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  newProviderClient(cfg),
+	}, nil
+}
+
+func (p *newsAPIProvider) Name() string {
+	return ProviderNewsAPI
+}
+
+func (p *newsAPIProvider) FetchLatest(ctx context.Context) ([]models.NewsArticle, error) {
+	apiURL := fmt.Sprintf("%s/top-headlines?country=us&pageSize=100", p.baseURL)
+
+	articles, err := p.fetch(ctx, apiURL, "")
+	for i := range articles {
+		articles[i].Locale = "us"
+	}
+	return articles, err
+}
+
+// FetchByKeywords passes a single language on to NewsAPI.org, which can't
+// search several at once or by country
+func (p *newsAPIProvider) FetchByKeywords(ctx context.Context, keywords []string, filter NewsFilter) ([]models.NewsArticle, error) {
+	query := strings.Join(keywords, " OR ")
+	apiURL := fmt.Sprintf("%s/everything?q=%s&pageSize=50&sortBy=publishedAt",
+		p.baseURL, url.QueryEscape(query))
+
+	var language string
+	if len(filter.Languages) == 1 {
+		language = strings.ToLower(filter.Languages[0])
+		apiURL += "&language=" + url.QueryEscape(language)
+	}
+
+	articles, err := p.fetch(ctx, apiURL, "")
+	for i := range articles {
+		articles[i].Language = language
+	}
+	return articles, err
+}
+
+func (p *newsAPIProvider) FetchByCategory(ctx context.Context, category string) ([]models.NewsArticle, error) {
+	apiCategory, exists := newsAPICategories[strings.ToLower(category)]
+	if !exists {
+		apiCategory = "general"
+	}
+
+	apiURL := fmt.Sprintf("%s/top-headlines?category=%s&pageSize=50",
+		p.baseURL, apiCategory)
+
+	return p.fetch(ctx, apiURL, category)
+}
+
+func (p *newsAPIProvider) fetch(ctx context.Context, apiURL, category string) ([]models.NewsArticle, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Api-Key", p.apiKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("newsapi.org returned status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var newsResp models.NewsAPIResponse
+	if err := json.Unmarshal(body, &newsResp); err != nil {
+		return nil, err
+	}
+
+	if newsResp.Status != "ok" {
+		return nil, fmt.Errorf("newsapi.org returned status: %s", newsResp.Status)
+	}
+
+	articles := make([]models.NewsArticle, 0, len(newsResp.Articles))
+	for _, article := range newsResp.Articles {
+		publishedAt, _ := dateparse.Parse(article.PublishedAt)
+
+		articles = append(articles, models.NewsArticle{
+			Title:       article.Title,
+			URL:         article.URL,
+			Source:      article.Source.Name,
+			Description: article.Description,
+			PublishedAt: publishedAt,
+			ImageURL:    article.URLToImage,
+			Category:    category,
+		})
+	}
+
+	inferPublishedAt(articles, time.Now())
+	return articles, nil
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/query"
+	"news-to-text/internal/repositories"
+	"news-to-text/pkg/logger"
+)
+
+// Feeds polled by the RSS provider when neither a static list nor the
+// news_sources table is available
+var defaultRSSFeeds = []string{
+	"https://techcrunch.com/feed/",
+	"https://feeds.reuters.com/reuters/technologyNews",
+	"https://hnrss.org/frontpage",
+	"https://feeds.bloomberg.com/markets/news.rss",
+}
+
+// feedSource is a single source polled by the RSS provider
+type feedSource struct {
+	URL      string
+	Name     string
+	Category string
+}
+
+// rssProvider polls RSS feeds and filters items locally. Feeds come from the
+// active rows of the news_sources table unless a static list is configured.
+// Feeds are fetched with conditional GET when a cache is configured.
+type rssProvider struct {
+	feeds   []string
+	sources repositories.NewsSourceRepository
+	fetcher *feedFetcher
+}
+
+func newRSSProvider(cfg ProviderConfig) (NewsProvider, error) {
+	feeds := cfg.Feeds
+	if len(feeds) == 0 && cfg.Sources == nil {
+		feeds = defaultRSSFeeds
+	}
+
+	return &rssProvider{
+		feeds:   feeds,
+		sources: cfg.Sources,
+		fetcher: newFeedFetcher(newProviderClient(cfg), cfg.Cache),
+	}, nil
+}
+
+func (p *rssProvider) Name() string {
+	return ProviderRSS
+}
+
+func (p *rssProvider) FetchLatest(ctx context.Context) ([]models.NewsArticle, error) {
+	feeds, err := p.activeFeeds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.fetchFeeds(ctx, feeds)
+}
+
+// FetchByKeywords filters the items locally, by their declared or detected
+// language
+func (p *rssProvider) FetchByKeywords(ctx context.Context, keywords []string, filter NewsFilter) ([]models.NewsArticle, error) {
+	articles, err := p.FetchLatest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterNews(filterMatches(articles, query.FromKeywords(keywords)), filter), nil
+}
+
+// FetchByCategory returns every item from the sources tagged with the
+// category. When no source carries that category, the category name is
+// matched as a keyword across all feeds instead.
+func (p *rssProvider) FetchByCategory(ctx context.Context, category string) ([]models.NewsArticle, error) {
+	feeds, err := p.activeFeeds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var categoryFeeds []feedSource
+	for _, source := range feeds {
+		if source.Category != "" && strings.EqualFold(source.Category, category) {
+			categoryFeeds = append(categoryFeeds, source)
+		}
+	}
+
+	if len(categoryFeeds) == 0 {
+		return p.FetchByKeywords(ctx, []string{category}, NewsFilter{})
+	}
+
+	return p.fetchFeeds(ctx, categoryFeeds)
+}
+
+// fetchFeeds fetches every feed, logging and skipping the ones that fail. It
+// gives up once ctx is done.
+func (p *rssProvider) fetchFeeds(ctx context.Context, feeds []feedSource) ([]models.NewsArticle, error) {
+	var allArticles []models.NewsArticle
+	for _, source := range feeds {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		articles, err := p.fetchFeed(ctx, source)
+		if err != nil {
+			logger.Error("Failed to fetch RSS feed:", source.URL, err)
+			continue
+		}
+		allArticles = append(allArticles, articles...)
+	}
+
+	return allArticles, nil
+}
+
+func (p *rssProvider) activeFeeds(ctx context.Context) ([]feedSource, error) {
+	if len(p.feeds) > 0 {
+		feeds := make([]feedSource, 0, len(p.feeds))
+		for _, feedURL := range p.feeds {
+			feeds = append(feeds, feedSource{URL: feedURL})
+		}
+		return feeds, nil
+	}
+
+	sources, err := p.sources.GetActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	feeds := make([]feedSource, 0, len(sources))
+	for _, source := range sources {
+		if source.RSSFeedURL == "" {
+			continue
+		}
+		feeds = append(feeds, feedSource{
+			URL:      source.RSSFeedURL,
+			Name:     source.Name,
+			Category: source.Category,
+		})
+	}
+
+	return feeds, nil
+}
+
+func (p *rssProvider) fetchFeed(ctx context.Context, source feedSource) ([]models.NewsArticle, error) {
+	articles, err := p.fetcher.Fetch(ctx, source.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range articles {
+		if source.Name != "" {
+			articles[i].Source = source.Name
+		}
+		if source.Category != "" {
+			articles[i].Category = source.Category
+		}
+	}
+
+	return articles, nil
+}
@@ -0,0 +1,395 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"news-to-text/internal/models"
+)
+
+type stubProvider struct {
+	name     string
+	articles []models.NewsArticle
+	err      error
+	calls    int
+}
+
+func (p *stubProvider) Name() string {
+	return p.name
+}
+
+func (p *stubProvider) FetchLatest(ctx context.Context) ([]models.NewsArticle, error) {
+	p.calls++
+	return p.articles, p.err
+}
+
+func (p *stubProvider) FetchByKeywords(ctx context.Context, keywords []string, filter NewsFilter) ([]models.NewsArticle, error) {
+	p.calls++
+	return p.articles, p.err
+}
+
+func (p *stubProvider) FetchByCategory(ctx context.Context, category string) ([]models.NewsArticle, error) {
+	p.calls++
+	return p.articles, p.err
+}
+
+func TestNewsService_FallbackChain(t *testing.T) {
+	failing := &stubProvider{name: "failing", err: errors.New("boom")}
+	working := &stubProvider{name: "working", articles: []models.NewsArticle{{Title: "From working"}}}
+	unused := &stubProvider{name: "unused", articles: []models.NewsArticle{{Title: "From unused"}}}
+
+	newsService := NewNewsService([]NewsProvider{failing, working, unused}, nil)
+
+	articles, err := newsService.FetchNewsByKeywords(context.Background(), []string{"anything"}, NewsFilter{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(articles) != 1 || articles[0].Title != "From working" {
+		t.Errorf("Expected article from working provider but got %v", articles)
+	}
+
+	if failing.calls != 1 || working.calls != 1 || unused.calls != 0 {
+		t.Errorf("Unexpected call counts: failing=%d working=%d unused=%d", failing.calls, working.calls, unused.calls)
+	}
+}
+
+func TestNewsService_AllProvidersFail(t *testing.T) {
+	newsService := NewNewsService([]NewsProvider{
+		&stubProvider{name: "first", err: errors.New("first failed")},
+		&stubProvider{name: "second", err: errors.New("second failed")},
+	}, nil)
+
+	if _, err := newsService.FetchNewsByCategory(context.Background(), "tech"); err == nil || err.Error() != "second failed" {
+		t.Errorf("Expected last provider error but got %v", err)
+	}
+}
+
+func TestNewProviderChain(t *testing.T) {
+	tests := []struct {
+		name          string
+		configs       []ProviderConfig
+		expectedNames []string
+		wantErr       bool
+	}{
+		{
+			name: "Ordered chain",
+			configs: []ProviderConfig{
+				{Name: ProviderNewsAPI, APIKey: "key"},
+				{Name: ProviderRSS},
+			},
+			expectedNames: []string{ProviderNewsAPI, ProviderRSS},
+		},
+		{
+			name: "Unconfigured provider is skipped",
+			configs: []ProviderConfig{
+				{Name: ProviderTheNewsAPI},
+				{Name: ProviderRSS},
+			},
+			expectedNames: []string{ProviderRSS},
+		},
+		{
+			name:    "Unknown provider",
+			configs: []ProviderConfig{{Name: "carrier-pigeon"}},
+			wantErr: true,
+		},
+		{
+			name:    "Nothing configured",
+			configs: []ProviderConfig{{Name: ProviderFile}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers, err := NewProviderChain(tt.configs)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(providers) != len(tt.expectedNames) {
+				t.Fatalf("Expected %d providers but got %d", len(tt.expectedNames), len(providers))
+			}
+
+			for i, provider := range providers {
+				if provider.Name() != tt.expectedNames[i] {
+					t.Errorf("Expected provider %s at position %d but got %s", tt.expectedNames[i], i, provider.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestTheNewsAPIProvider_FetchByKeywords(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/news/all" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("api_token") != "token" {
+			t.Errorf("Expected api token to be sent")
+		}
+		if r.URL.Query().Get("search") != "tesla earnings" {
+			t.Errorf("Unexpected search query %q", r.URL.Query().Get("search"))
+		}
+
+		w.Write([]byte(`{"meta":{"found":2,"returned":2},"data":[{
+			"title":"Tesla beats estimates",
+			"url":"https://example.com/tesla",
+			"source":"example.com",
+			"published_at":"2024-01-02T15:04:05Z",
+			"relevance_score":31.87
+		},{
+			"title":"Tesla earnings call",
+			"url":"https://example.com/tesla-call",
+			"source":"example.com",
+			"published_at":"2024-01-02T16:04:05Z",
+			"relevance_score":null
+		}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(ProviderConfig{Name: ProviderTheNewsAPI, APIKey: "token", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	articles, err := provider.FetchByKeywords(ctx, []string{"tesla", "earnings"}, NewsFilter{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(articles) != 2 {
+		t.Fatalf("Expected 2 articles but got %d", len(articles))
+	}
+
+	if articles[0].Title != "Tesla beats estimates" || articles[0].PublishedAt.IsZero() {
+		t.Errorf("Unexpected article: %+v", articles[0])
+	}
+
+	if articles[0].ProviderRelevance == nil || *articles[0].ProviderRelevance != 31.87 || articles[1].ProviderRelevance != nil {
+		t.Errorf("Expected the relevance score of the first article only, got %v and %v", articles[0].ProviderRelevance, articles[1].ProviderRelevance)
+	}
+}
+
+func TestNewsService_CancelStopsFetch(t *testing.T) {
+	// The API answers only once the request is abandoned
+	aborted := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(aborted)
+	}))
+	defer server.Close()
+
+	api, err := NewProvider(ProviderConfig{Name: ProviderTheNewsAPI, APIKey: "token", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	fallback := &stubProvider{name: "fallback", articles: []models.NewsArticle{{Title: "From fallback"}}}
+	newsService := NewNewsService([]NewsProvider{api, fallback}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	started := time.Now()
+	_, err = newsService.FetchNewsByKeywords(ctx, []string{"tesla"}, NewsFilter{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the fetch to be cancelled but got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("Expected the fetch to stop when cancelled but it took %v", elapsed)
+	}
+
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Error("Expected the request to the API to be aborted")
+	}
+	if fallback.calls != 0 {
+		t.Errorf("Expected no fallback after cancelling but it was called %d times", fallback.calls)
+	}
+}
+
+func TestProviders_LanguageFilter(t *testing.T) {
+	ctx := context.Background()
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		if r.URL.Path == "/news/all" {
+			w.Write([]byte(`{"data":[{"title":"Tesla","language":"en","locale":"US"}]}`))
+			return
+		}
+		w.Write([]byte(`{"status":"ok","articles":[{"title":"Tesla"}]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		provider string
+		filter   NewsFilter
+		params   map[string]string
+		language string
+		locale   string
+	}{
+		{
+			provider: ProviderTheNewsAPI,
+			filter:   NewsFilter{Languages: []string{"en", "DE"}, Locales: []string{"us", "gb"}},
+			params:   map[string]string{"language": "en,de", "locale": "us,gb"},
+			language: "en",
+			locale:   "us",
+		},
+		{
+			provider: ProviderTheNewsAPI,
+			params:   map[string]string{"language": "", "locale": ""},
+			language: "en",
+			locale:   "us",
+		},
+		{
+			provider: ProviderNewsAPI,
+			filter:   NewsFilter{Languages: []string{"fr"}, Locales: []string{"ca"}},
+			params:   map[string]string{"language": "fr", "country": ""},
+			language: "fr",
+		},
+		{
+			provider: ProviderNewsAPI,
+			filter:   NewsFilter{Languages: []string{"fr", "en"}},
+			params:   map[string]string{"language": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		provider, err := NewProvider(ProviderConfig{Name: tt.provider, APIKey: "key", BaseURL: server.URL})
+		if err != nil {
+			t.Fatalf("Failed to create provider: %v", err)
+		}
+
+		articles, err := provider.FetchByKeywords(ctx, []string{"tesla"}, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.provider, err)
+		}
+
+		for param, expected := range tt.params {
+			if got := query.Get(param); got != expected {
+				t.Errorf("%s %v: expected %s=%q but got %q", tt.provider, tt.filter, param, expected, got)
+			}
+		}
+		if len(articles) != 1 || articles[0].Language != tt.language || articles[0].Locale != tt.locale {
+			t.Errorf("%s %v: expected language %q and locale %q but got %+v", tt.provider, tt.filter, tt.language, tt.locale, articles)
+		}
+	}
+}
+
+func TestNewsService_FetchNewsByKeywordsFiltersLanguage(t *testing.T) {
+	provider := &stubProvider{name: "rss", articles: []models.NewsArticle{
+		{Title: "Tesla recalls cars", Language: "en"},
+		{Title: "Tesla rappelle des voitures", Language: "fr"},
+		{Title: "Tesla"},
+	}}
+
+	articles, err := NewNewsService([]NewsProvider{provider}, nil).FetchNewsByKeywords(context.Background(), []string{"tesla"}, NewsFilter{Languages: []string{"en"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(articles) != 2 || articles[0].Language != "en" || articles[1].Language != "" {
+		t.Errorf("Expected the English and the undetected article but got %v", articles)
+	}
+}
+
+func TestNewsAPIProvider_ErrorStatus(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "key" {
+			t.Errorf("Expected api key header to be sent")
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(ProviderConfig{Name: ProviderNewsAPI, APIKey: "key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	if _, err := provider.FetchByCategory(ctx, "tech"); err == nil {
+		t.Errorf("Expected error for non-200 response")
+	}
+}
+
+func TestFileProvider(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	fixture := `[
+		{"title":"Bitcoin rallies","description":"Crypto markets up","category":"finance"},
+		{"title":"New GPU announced","description":"Chipmaker unveils hardware","category":"tech"}
+	]`
+	if err := os.WriteFile(filepath.Join(dir, "articles.json"), []byte(fixture), 0o644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	provider, err := NewProvider(ProviderConfig{Name: ProviderFile, Dir: dir})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	byKeyword, err := provider.FetchByKeywords(ctx, []string{"bitcoin"}, NewsFilter{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(byKeyword) != 1 || byKeyword[0].Title != "Bitcoin rallies" {
+		t.Errorf("Unexpected keyword results: %v", byKeyword)
+	}
+
+	byCategory, err := provider.FetchByCategory(ctx, "Tech")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(byCategory) != 1 || byCategory[0].Title != "New GPU announced" {
+		t.Errorf("Unexpected category results: %v", byCategory)
+	}
+}
+
+func TestFileProvider_InfersMissingDates(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	fixture := `[
+		{"title":"Dated story","published_at":"2024-01-02T15:04:05Z"},
+		{"title":"Undated story"}
+	]`
+	if err := os.WriteFile(filepath.Join(dir, "articles.json"), []byte(fixture), 0o644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	provider, err := NewProvider(ProviderConfig{Name: ProviderFile, Dir: dir})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	before := time.Now()
+	articles, err := provider.FetchByKeywords(ctx, []string{"story"}, NewsFilter{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if articles[0].PublishedAtInferred {
+		t.Errorf("Expected dated article not to be flagged as inferred")
+	}
+
+	if !articles[1].PublishedAtInferred || articles[1].PublishedAt.Before(before) {
+		t.Errorf("Expected undated article to get the fetch time but got %+v", articles[1])
+	}
+}
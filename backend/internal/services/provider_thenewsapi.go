@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/pkg/dateparse"
+)
+
+const theNewsAPIBaseURL = "https://api.thenewsapi.com/v1"
+
+// Map common categories to The News API categories
+var theNewsAPICategories = map[string]string{
+	"technology": "tech",
+	"tech":       "tech",
+	"business":   "business",
+	"sports":     "sports",
+	"health":     "health",
+	"science":    "science",
+	"stocks":     "business", // Map stocks to business category
+	"finance":    "business",
+}
+
+// theNewsAPIProvider fetches articles from thenewsapi.com
+type theNewsAPIProvider struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+func newTheNewsAPIProvider(cfg ProviderConfig) (NewsProvider, error) {
+	if cfg.APIKey == "" {
+		return nil, ErrProviderNotConfigured
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = theNewsAPIBaseURL
+	}
+
+	return &theNewsAPIProvider{
+		apiKey:  cfg.APIKey,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  newProviderClient(cfg),
+	}, nil
+}
+
+func (p *theNewsAPIProvider) Name() string {
+	return ProviderTheNewsAPI
+}
+
+func (p *theNewsAPIProvider) FetchLatest(ctx context.Context) ([]models.NewsArticle, error) {
+	apiURL := fmt.Sprintf("%s/news/all?api_token=%s&limit=50&sort=published_at",
+		p.baseURL, p.apiKey)
+
+	return p.fetch(ctx, apiURL, "")
+}
+
+func (p *theNewsAPIProvider) FetchByKeywords(ctx context.Context, keywords []string, filter NewsFilter) ([]models.NewsArticle, error) {
+	query := strings.Join(keywords, " ")
+	apiURL := fmt.Sprintf("%s/news/all?api_token=%s&search=%s&limit=50&sort=published_at",
+		p.baseURL, p.apiKey, url.QueryEscape(query))
+
+	// The News API takes comma-separated languages and locales
+	if len(filter.Languages) > 0 {
+		apiURL += "&language=" + url.QueryEscape(strings.ToLower(strings.Join(filter.Languages, ",")))
+	}
+	if len(filter.Locales) > 0 {
+		apiURL += "&locale=" + url.QueryEscape(strings.ToLower(strings.Join(filter.Locales, ",")))
+	}
+
+	return p.fetch(ctx, apiURL, "")
+}
+
+func (p *theNewsAPIProvider) FetchByCategory(ctx context.Context, category string) ([]models.NewsArticle, error) {
+	apiCategory, exists := theNewsAPICategories[strings.ToLower(category)]
+	if !exists {
+		apiCategory = "general"
+	}
+
+	apiURL := fmt.Sprintf("%s/news/top?api_token=%s&categories=%s&limit=50&sort=published_at",
+		p.baseURL, p.apiKey, apiCategory)
+
+	return p.fetch(ctx, apiURL, category)
+}
+
+func (p *theNewsAPIProvider) fetch(ctx context.Context, apiURL, category string) ([]models.NewsArticle, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the news api returned status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var newsResp models.TheNewsAPIResponse
+	if err := json.Unmarshal(body, &newsResp); err != nil {
+		return nil, err
+	}
+
+	articles := make([]models.NewsArticle, 0, len(newsResp.Data))
+	for _, article := range newsResp.Data {
+		publishedAt, _ := dateparse.Parse(article.PublishedAt)
+
+		articles = append(articles, models.NewsArticle{
+			Title:       article.Title,
+			URL:         article.URL,
+			Source:      article.Source,
+			Description: article.Description,
+			PublishedAt: publishedAt,
+			ImageURL:    article.ImageURL,
+			Category:    category,
+			Language:    strings.ToLower(article.Language),
+			Locale:      strings.ToLower(article.Locale),
+
+			ProviderRelevance: article.Relevance,
+		})
+	}
+
+	inferPublishedAt(articles, time.Now())
+	return articles, nil
+}
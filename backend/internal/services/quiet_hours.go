@@ -0,0 +1,75 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"news-to-text/internal/models"
+)
+
+// quietHours returns the start and end of the user's quiet hours as minutes
+// after midnight, and the location they are in. start equals end when the
+// user has none. Users without a time zone use the server's.
+func quietHours(user *models.User) (start, end int, loc *time.Location, err error) {
+	loc = time.Local
+	if user.TimeZone != "" {
+		if loc, err = time.LoadLocation(user.TimeZone); err != nil {
+			return 0, 0, nil, errors.New("invalid time zone: " + user.TimeZone)
+		}
+	}
+
+	if user.QuietHoursStart == "" && user.QuietHoursEnd == "" {
+		return 0, 0, loc, nil
+	}
+	if user.QuietHoursStart == "" || user.QuietHoursEnd == "" {
+		return 0, 0, nil, errors.New("invalid quiet hours: both a start and an end are needed")
+	}
+
+	if start, err = clockMinutes(user.QuietHoursStart); err != nil {
+		return 0, 0, nil, err
+	}
+	if end, err = clockMinutes(user.QuietHoursEnd); err != nil {
+		return 0, 0, nil, err
+	}
+	if start == end {
+		return 0, 0, nil, errors.New("invalid quiet hours: the start and end must differ")
+	}
+	return start, end, loc, nil
+}
+
+// clockMinutes parses an "HH:MM" wall clock time into minutes after midnight
+func clockMinutes(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, errors.New("invalid quiet hours: " + clock + " is not an HH:MM time")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// quietHoursEnd reports whether now falls in the user's quiet hours and, if
+// so, when they end. Users with invalid settings are never quiet, so their
+// notifications still go out.
+func quietHoursEnd(user *models.User, now time.Time) (time.Time, bool) {
+	start, end, loc, err := quietHours(user)
+	if err != nil || start == end {
+		return time.Time{}, false
+	}
+
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	endOn := func(day int) time.Time {
+		return time.Date(local.Year(), local.Month(), day, end/60, end%60, 0, 0, loc)
+	}
+
+	switch {
+	case start < end && minute >= start && minute < end:
+		return endOn(local.Day()), true
+	case start > end && minute >= start:
+		// Overnight window, before midnight
+		return endOn(local.Day() + 1), true
+	case start > end && minute < end:
+		// Overnight window, after midnight
+		return endOn(local.Day()), true
+	}
+	return time.Time{}, false
+}
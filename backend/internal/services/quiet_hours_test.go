@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/repositories"
+)
+
+func TestQuietHoursEnd(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %v", err)
+	}
+
+	overnight := models.User{QuietHoursStart: "22:00", QuietHoursEnd: "07:00", TimeZone: "Europe/London"}
+	daytime := models.User{QuietHoursStart: "09:00", QuietHoursEnd: "17:30", TimeZone: "Europe/London"}
+
+	tests := []struct {
+		name  string
+		user  models.User
+		now   time.Time
+		until time.Time
+	}{
+		{"Before an overnight window", overnight, time.Date(2024, 1, 1, 21, 59, 0, 0, london), time.Time{}},
+		{"Overnight window before midnight", overnight, time.Date(2024, 1, 1, 23, 0, 0, 0, london), time.Date(2024, 1, 2, 7, 0, 0, 0, london)},
+		{"Overnight window after midnight", overnight, time.Date(2024, 1, 2, 3, 0, 0, 0, london), time.Date(2024, 1, 2, 7, 0, 0, 0, london)},
+		{"End of an overnight window", overnight, time.Date(2024, 1, 2, 7, 0, 0, 0, london), time.Time{}},
+		{"Overnight window at month end", overnight, time.Date(2024, 1, 31, 22, 30, 0, 0, london), time.Date(2024, 2, 1, 7, 0, 0, 0, london)},
+		{"In a daytime window", daytime, time.Date(2024, 1, 1, 12, 0, 0, 0, london), time.Date(2024, 1, 1, 17, 30, 0, 0, london)},
+		{"After a daytime window", daytime, time.Date(2024, 1, 1, 18, 0, 0, 0, london), time.Time{}},
+		{"In the user's time zone", overnight, time.Date(2024, 7, 1, 21, 30, 0, 0, time.UTC), time.Date(2024, 7, 2, 7, 0, 0, 0, london)},
+		{"No quiet hours", models.User{TimeZone: "Europe/London"}, time.Date(2024, 1, 1, 3, 0, 0, 0, london), time.Time{}},
+		{"Invalid time zone", models.User{QuietHoursStart: "22:00", QuietHoursEnd: "07:00", TimeZone: "Nowhere"}, time.Date(2024, 1, 1, 3, 0, 0, 0, london), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until, quiet := quietHoursEnd(&tt.user, tt.now)
+			if quiet != !tt.until.IsZero() || !until.Equal(tt.until) {
+				t.Errorf("Expected quiet hours until %v but got %v (quiet: %t)", tt.until, until, quiet)
+			}
+		})
+	}
+}
+
+func TestUserService_UpdateQuietHours(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	userRepo := repositories.NewUserRepository(db)
+	user := &models.User{Email: "quiet@example.com", Password: "hashed"}
+	if err := userRepo.Create(ctx, user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	userService := NewUserService(userRepo, nil, nil, "test-secret")
+	start, end, zone := "22:00", "07:00", "America/Chicago"
+
+	updated, err := userService.UpdateQuietHours(ctx, user.ID, &models.QuietHoursUpdateRequest{Start: &start, End: &end, TimeZone: &zone})
+	if err != nil {
+		t.Fatalf("Failed to set quiet hours: %v", err)
+	}
+	if updated.QuietHoursStart != start || updated.QuietHoursEnd != end || updated.TimeZone != zone {
+		t.Errorf("Expected quiet hours 22:00-07:00 in America/Chicago but got %+v", updated)
+	}
+
+	invalid := []struct {
+		name    string
+		req     models.QuietHoursUpdateRequest
+		wantErr string
+	}{
+		{"Start without an end", models.QuietHoursUpdateRequest{End: new(string)}, "invalid quiet hours"},
+		{"Same start and end", models.QuietHoursUpdateRequest{End: &start}, "invalid quiet hours"},
+		{"Unknown time zone", models.QuietHoursUpdateRequest{TimeZone: &end}, "invalid time zone"},
+	}
+	for _, tt := range invalid {
+		if _, err := userService.UpdateQuietHours(ctx, user.ID, &tt.req); err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+			t.Errorf("%s: expected %q error but got %v", tt.name, tt.wantErr, err)
+		}
+	}
+
+	// Clearing both turns quiet hours off
+	off := ""
+	updated, err = userService.UpdateQuietHours(ctx, user.ID, &models.QuietHoursUpdateRequest{Start: &off, End: &off})
+	if err != nil {
+		t.Fatalf("Failed to clear quiet hours: %v", err)
+	}
+	if updated.QuietHoursStart != "" || updated.QuietHoursEnd != "" {
+		t.Errorf("Expected quiet hours to be off but got %+v", updated)
+	}
+
+	if _, err := userService.GetProfile(ctx, user.ID+1); err == nil || err.Error() != "user not found" {
+		t.Errorf("Expected user not found but got %v", err)
+	}
+}
+
+func TestBackgroundService_HoldsDuringQuietHours(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	// Quiet hours from an hour ago until an hour from now
+	now := time.Now().UTC()
+	user := &models.User{
+		Email:           "sleeper@example.com",
+		Password:        "hashed",
+		Phone:           "+14155552671",
+		PhoneVerifiedAt: &now,
+		QuietHoursStart: now.Add(-time.Hour).Format("15:04"),
+		QuietHoursEnd:   now.Add(time.Hour).Format("15:04"),
+		TimeZone:        "UTC",
+	}
+	if err := repositories.NewUserRepository(db).Create(ctx, user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	alertRepo := repositories.NewAlertRepository(db)
+	provider := &stubProvider{name: "rss", articles: []models.NewsArticle{
+		{Title: "Stocks rally", URL: "https://example.com/stocks"},
+		{Title: "Earthquake strikes coast", URL: "https://example.com/quake"},
+	}}
+	ingestionService := NewIngestionService([]NewsProvider{provider}, repositories.NewArticleRepository(db), nil, time.Minute, 0)
+	if _, err := ingestionService.Ingest(ctx); err != nil {
+		t.Fatalf("Failed to ingest: %v", err)
+	}
+	notifier := &recordingNotifier{}
+	seenService := NewSeenArticleService(alertRepo, nil)
+
+	background := &backgroundService{
+		alertService:        NewAlertService(alertRepo, nil),
+		newsService:         NewNewsService(nil, nil),
+		ingestionService:    ingestionService,
+		seenService:         seenService,
+		notificationService: notifier,
+		config:              SchedulerConfig{MaxCatchUp: time.Hour},
+		ctx:                 context.Background(),
+	}
+
+	due := time.Now().Add(-time.Minute)
+	markets := &models.Alert{UserID: user.ID, Topic: "Markets", Keywords: models.Keywords{"stocks"}, Frequency: models.FrequencyRealTime, NextRunAt: &due, Active: true}
+	quakes := &models.Alert{UserID: user.ID, Topic: "Quakes", Keywords: models.Keywords{"earthquake"}, Frequency: models.FrequencyRealTime, NextRunAt: &due, Urgent: true, Active: true}
+	for _, alert := range []*models.Alert{markets, quakes} {
+		if err := alertRepo.Create(ctx, alert); err != nil {
+			t.Fatalf("Failed to create alert: %v", err)
+		}
+	}
+
+	if err := background.ProcessAlerts(); err != nil {
+		t.Fatalf("Failed to process alerts: %v", err)
+	}
+
+	// Only the urgent alert goes out during quiet hours
+	if len(notifier.sent) != 1 || notifier.sent[0][0].Title != "Earthquake strikes coast" {
+		t.Fatalf("Expected only the urgent alert to be sent but got %v", notifier.sent)
+	}
+
+	history, err := alertRepo.GetHistoryByAlertID(ctx, markets.ID)
+	if err != nil {
+		t.Fatalf("Failed to load history: %v", err)
+	}
+	if len(history) != 1 || !history[0].Held || history[0].Success || history[0].HeldUntil == nil {
+		t.Fatalf("Expected a held history entry but got %+v", history)
+	}
+
+	// Held stories are not held again by later runs in the quiet hours
+	if err := db.Model(markets).Updates(map[string]interface{}{"next_run_at": due, "last_checked": nil}).Error; err != nil {
+		t.Fatalf("Failed to make the alert due again: %v", err)
+	}
+	if err := background.ProcessAlerts(); err != nil {
+		t.Fatalf("Failed to process alerts: %v", err)
+	}
+	if background.lastRun.Run != 1 {
+		t.Fatalf("Expected the alert to run again but got %+v", background.lastRun)
+	}
+	if history, _ := alertRepo.GetHistoryByAlertID(ctx, markets.ID); len(history) != 1 {
+		t.Fatalf("Expected the story to be held once but got %d entries", len(history))
+	}
+
+	// Nothing is released before the quiet hours end
+	if err := background.releaseHeldNotifications(); err != nil {
+		t.Fatalf("Failed to release held notifications: %v", err)
+	}
+	if len(notifier.held) != 0 {
+		t.Fatalf("Expected nothing to be released yet but got %v", notifier.held)
+	}
+
+	// Once they end, the held notifications are queued as one message
+	past := time.Now().Add(-time.Minute)
+	if err := db.Model(&models.AlertHistory{}).Where("held = ?", true).Update("held_until", past).Error; err != nil {
+		t.Fatalf("Failed to end quiet hours: %v", err)
+	}
+	if err := background.releaseHeldNotifications(); err != nil {
+		t.Fatalf("Failed to release held notifications: %v", err)
+	}
+	if len(notifier.held) != 1 || len(notifier.held[0]) != 1 || notifier.held[0][0].Alert.User.ID != user.ID {
+		t.Fatalf("Expected one coalesced message for the user but got %v", notifier.held)
+	}
+
+	dispatcher := NewNotificationDispatcher(repositories.NewNotificationRepository(db), notifier, DispatcherConfig{MaxAttempts: 3, RetryBase: time.Second, RetryMax: time.Minute})
+	if sent, err := dispatcher.Dispatch(ctx); err != nil || sent != 2 {
+		t.Fatalf("Expected the urgent and the held notifications to be sent but got %d, %v", sent, err)
+	}
+
+	history, err = alertRepo.GetHistoryByAlertID(ctx, markets.ID)
+	if err != nil {
+		t.Fatalf("Failed to load history: %v", err)
+	}
+	if history[0].Held || !history[0].Success || history[0].HeldUntil == nil || history[0].NotificationID == nil {
+		t.Errorf("Expected the entry to be sent after being held but got %+v", history[0])
+	}
+
+	if held, err := seenService.HeldDeliveries(ctx, time.Now()); err != nil || len(held) != 0 {
+		t.Errorf("Expected no held notifications left but got %v, %v", held, err)
+	}
+
+	// A replica that read the held rows before they were released can't
+	// queue them again
+	if err := seenService.RecordRelease(ctx, history, &models.Notification{UserID: user.ID, Message: "held", NextAttemptAt: time.Now()}, 0); err == nil {
+		t.Errorf("Expected released notifications not to be released again")
+	}
+	var queued int64
+	db.Model(&models.Notification{}).Count(&queued)
+	if queued != 2 {
+		t.Errorf("Expected 2 queued notifications but got %d", queued)
+	}
+}
+
+func TestSeenArticleService_HeldDeliveriesOfActiveAlerts(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	alertRepo := repositories.NewAlertRepository(db)
+	seenService := NewSeenArticleService(alertRepo, nil)
+	owner := createVerifiedUser(t, db, "owner@example.com")
+
+	active := &models.Alert{UserID: owner.ID, Topic: "Active", Keywords: models.Keywords{"stocks"}, Active: true}
+	paused := &models.Alert{UserID: owner.ID, Topic: "Paused", Keywords: models.Keywords{"stocks"}, Active: true}
+	deleted := &models.Alert{UserID: owner.ID, Topic: "Deleted", Keywords: models.Keywords{"stocks"}, Active: true}
+	past := time.Now().Add(-time.Minute)
+	for _, alert := range []*models.Alert{active, paused, deleted} {
+		if err := alertRepo.Create(ctx, alert); err != nil {
+			t.Fatalf("Failed to create alert: %v", err)
+		}
+		articles := []models.NewsArticle{{Title: "Stocks rally", URL: "https://example.com/stocks"}}
+		if err := seenService.RecordHeld(ctx, alert.ID, articles, past, 0); err != nil {
+			t.Fatalf("Failed to record held delivery: %v", err)
+		}
+	}
+
+	// Active defaults to true, so pause after creating
+	if err := db.Model(paused).Update("active", false).Error; err != nil {
+		t.Fatalf("Failed to pause alert: %v", err)
+	}
+	if err := alertRepo.Delete(ctx, deleted.ID); err != nil {
+		t.Fatalf("Failed to delete alert: %v", err)
+	}
+
+	held, err := seenService.HeldDeliveries(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(held) != 1 || held[0].AlertID != active.ID {
+		t.Errorf("Expected only the active alert's held notification but got %+v", held)
+	}
+}
+
+func TestNotificationService_FormatHeldMessage(t *testing.T) {
+	service := NewNotificationService(nil)
+
+	var held []models.AlertHistory
+	for i := 0; i < 4; i++ {
+		held = append(held, models.AlertHistory{AlertID: 1, NewsTitle: "Markets story", NewsURL: "https://example.com/m", Alert: models.Alert{Topic: "Markets"}})
+	}
+	held = append(held, models.AlertHistory{AlertID: 2, NewsTitle: "Weather story", NewsURL: "https://example.com/w", Alert: models.Alert{Topic: "Weather"}})
+
+	message := service.FormatHeldMessage(held)
+
+	for _, expected := range []string{"Markets\n1. Markets story", "3. Markets story", "... and 1 more\n", "Weather\n1. Weather story"} {
+		if !strings.Contains(message, expected) {
+			t.Errorf("Expected message to contain %q but got:\n%s", expected, message)
+		}
+	}
+	if strings.Contains(message, "4. Markets story") {
+		t.Errorf("Expected at most three stories per alert but got:\n%s", message)
+	}
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/query"
+	"news-to-text/internal/repositories"
+	"news-to-text/pkg/logger"
+)
+
+const (
+	// DefaultScoreHalfLife is how long it takes an article's score to halve
+	DefaultScoreHalfLife = 24 * time.Hour
+
+	// titleHitWeight counts a term in the title as this many in the description
+	titleHitWeight = 2.0
+
+	// termSaturation is the weighted term frequency at which the term score
+	// reaches one half, so repeating a keyword has diminishing returns
+	termSaturation = 2.0
+
+	// providerRelevanceScale is the provider relevance at which the provider
+	// score reaches one half, and providerRelevanceShare is how much of the
+	// relevance it makes up when the provider gives one
+	providerRelevanceScale = 20.0
+	providerRelevanceShare = 0.3
+
+	// undatedRecency is the recency of an article without a publish date
+	undatedRecency = 0.5
+
+	sourceWeightsTTL = 5 * time.Minute
+)
+
+// Ranker scores matched articles for a query and orders them best first.
+// Explain also attaches an explanation of the match and score to each one.
+type Ranker interface {
+	Rank(articles []models.NewsArticle, q *query.Query) []models.NewsArticle
+	Explain(articles []models.NewsArticle, q *query.Query) []models.NewsArticle
+}
+
+type ranker struct {
+	sourceRepo repositories.NewsSourceRepository
+	halfLife   time.Duration
+	now        func() time.Time
+
+	mu            sync.Mutex
+	weights       map[string]float64
+	weightsLoaded time.Time
+}
+
+// NewRanker creates a ranker. Source weights are read from the news sources
+// table when sourceRepo is set; otherwise every source weighs 1.
+func NewRanker(sourceRepo repositories.NewsSourceRepository, halfLife time.Duration) Ranker {
+	if halfLife <= 0 {
+		halfLife = DefaultScoreHalfLife
+	}
+
+	return &ranker{
+		sourceRepo: sourceRepo,
+		halfLife:   halfLife,
+		now:        time.Now,
+	}
+}
+
+// Rank sets the score of each article and sorts them by it, highest first.
+// Articles with the same score keep their order.
+//
+// The score is 100 × relevance × recency × source weight, where relevance
+// saturates with the number of term hits (title hits count double) and is
+// blended with the provider's relevance score when there is one, and
+// recency halves every half-life.
+func (r *ranker) Rank(articles []models.NewsArticle, q *query.Query) []models.NewsArticle {
+	return r.rank(articles, q, false)
+}
+
+func (r *ranker) Explain(articles []models.NewsArticle, q *query.Query) []models.NewsArticle {
+	return r.rank(articles, q, true)
+}
+
+func (r *ranker) rank(articles []models.NewsArticle, q *query.Query, explain bool) []models.NewsArticle {
+	weights := r.sourceWeights()
+	now := r.now()
+
+	ranked := make([]models.NewsArticle, len(articles))
+	for i, article := range articles {
+		breakdown := r.score(article, q, weights, now)
+		article.Score = breakdown.Score
+		if explain {
+			article.Explanation = &models.MatchExplanation{
+				Query:    q.String(),
+				Stemming: q.Stemming(),
+				Terms:    q.Explain(article),
+				Score:    breakdown,
+			}
+		}
+		ranked[i] = article
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	return ranked
+}
+
+func (r *ranker) score(article models.NewsArticle, q *query.Query, weights map[string]float64, now time.Time) models.ScoreBreakdown {
+	hits := q.Hits(article)
+	b := models.ScoreBreakdown{
+		TitleHits:         hits.Title,
+		DescriptionHits:   hits.Description,
+		OtherHits:         hits.Other,
+		ProviderRelevance: article.ProviderRelevance,
+		Recency:           undatedRecency,
+		SourceWeight:      sourceWeight(weights, article.Source),
+	}
+
+	tf := titleHitWeight*float64(hits.Title+hits.Other) + float64(hits.Description)
+	b.TermScore = tf / (tf + termSaturation)
+	b.Relevance = b.TermScore
+	if p := article.ProviderRelevance; p != nil && *p > 0 {
+		provider := *p / (*p + providerRelevanceScale)
+		b.Relevance = (1-providerRelevanceShare)*b.TermScore + providerRelevanceShare*provider
+	}
+
+	if !article.PublishedAt.IsZero() {
+		age := now.Sub(article.PublishedAt)
+		if age < 0 {
+			age = 0
+		}
+		b.AgeHours = math.Round(age.Hours()*100) / 100
+		b.Recency = math.Pow(0.5, float64(age)/float64(r.halfLife))
+	}
+
+	b.Score = math.Round(100*b.Relevance*b.Recency*b.SourceWeight*100) / 100
+	return b
+}
+
+// sourceWeights returns the configured weights keyed by lower-case source
+// name and by host, reloading them every few minutes
+func (r *ranker) sourceWeights() map[string]float64 {
+	if r.sourceRepo == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.weights != nil && time.Since(r.weightsLoaded) < sourceWeightsTTL {
+		return r.weights
+	}
+
+	// The weights are shared by every caller, so loading them isn't tied to
+	// the one that happens to find them stale
+	sources, err := r.sourceRepo.GetAll(context.Background())
+	if err != nil {
+		logger.Error("Failed to load source weights:", err)
+		return r.weights
+	}
+
+	weights := make(map[string]float64)
+	for _, source := range sources {
+		if source.Weight <= 0 {
+			continue
+		}
+		weights[strings.ToLower(source.Name)] = source.Weight
+		if u, err := url.Parse(source.URL); err == nil && u.Host != "" {
+			weights[sourceHost(u.Host)] = source.Weight
+		}
+	}
+
+	r.weights = weights
+	r.weightsLoaded = time.Now()
+	return weights
+}
+
+func sourceWeight(weights map[string]float64, source string) float64 {
+	source = strings.ToLower(strings.TrimSpace(source))
+	if weight, ok := weights[source]; ok {
+		return weight
+	}
+	if weight, ok := weights[sourceHost(source)]; ok {
+		return weight
+	}
+	return 1
+}
+
+func sourceHost(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
+
+// filterByScore keeps the articles scoring at least minScore
+func filterByScore(articles []models.NewsArticle, minScore float64) []models.NewsArticle {
+	if minScore <= 0 {
+		return articles
+	}
+
+	var kept []models.NewsArticle
+	for _, article := range articles {
+		if article.Score >= minScore {
+			kept = append(kept, article)
+		}
+	}
+	return kept
+}
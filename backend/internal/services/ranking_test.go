@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/query"
+	"news-to-text/internal/repositories"
+)
+
+func TestRanker_Rank(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	relevance := 40.0
+
+	tests := []struct {
+		name     string
+		articles []models.NewsArticle
+		expected []string
+	}{
+		{
+			name: "title hits outrank description hits",
+			articles: []models.NewsArticle{
+				{Title: "Markets close higher", Description: "Tesla shares led the gains", PublishedAt: now},
+				{Title: "Tesla shares jump", Description: "Markets close higher", PublishedAt: now},
+			},
+			expected: []string{"Tesla shares jump", "Markets close higher"},
+		},
+		{
+			name: "more hits outrank fewer",
+			articles: []models.NewsArticle{
+				{Title: "Tesla recall", PublishedAt: now},
+				{Title: "Tesla recall widens", Description: "Tesla owners are asked to return Tesla cars", PublishedAt: now},
+			},
+			expected: []string{"Tesla recall widens", "Tesla recall"},
+		},
+		{
+			name: "newer articles outrank older ones",
+			articles: []models.NewsArticle{
+				{Title: "Old Tesla news", PublishedAt: now.Add(-48 * time.Hour)},
+				{Title: "New Tesla news", PublishedAt: now.Add(-time.Hour)},
+				{Title: "Undated Tesla news"},
+			},
+			expected: []string{"New Tesla news", "Undated Tesla news", "Old Tesla news"},
+		},
+		{
+			name: "source weight",
+			articles: []models.NewsArticle{
+				{Title: "Tesla on a blog", Source: "Blog", PublishedAt: now},
+				{Title: "Tesla on the wire", Source: "reuters.com", PublishedAt: now},
+			},
+			expected: []string{"Tesla on the wire", "Tesla on a blog"},
+		},
+		{
+			name: "provider relevance",
+			articles: []models.NewsArticle{
+				{Title: "Tesla plain", PublishedAt: now},
+				{Title: "Tesla relevant", PublishedAt: now, ProviderRelevance: &relevance},
+			},
+			expected: []string{"Tesla relevant", "Tesla plain"},
+		},
+	}
+
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	sourceRepo := repositories.NewNewsSourceRepository(db)
+	sourceRepo.Create(ctx, &models.NewsSource{Name: "Reuters", URL: "https://www.reuters.com", Weight: 2})
+	sourceRepo.Create(ctx, &models.NewsSource{Name: "Blog", URL: "https://blog.example.com", Weight: 0.5})
+
+	r := NewRanker(sourceRepo, 24*time.Hour).(*ranker)
+	r.now = func() time.Time { return now }
+	q := query.FromKeywords([]string{"Tesla"})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranked := r.Rank(tt.articles, q)
+
+			if len(ranked) != len(tt.expected) {
+				t.Fatalf("Expected %d articles but got %d", len(tt.expected), len(ranked))
+			}
+			for i, article := range ranked {
+				if article.Title != tt.expected[i] {
+					t.Errorf("Expected %q at %d but got %q", tt.expected[i], i, article.Title)
+				}
+				if i > 0 && article.Score >= ranked[i-1].Score {
+					t.Errorf("Expected scores to decrease but got %v after %v", article.Score, ranked[i-1].Score)
+				}
+			}
+		})
+	}
+}
+
+func TestRanker_ScoreRange(t *testing.T) {
+	now := time.Now()
+	ranked := NewRanker(nil, 24*time.Hour).Rank([]models.NewsArticle{
+		{Title: "Tesla Tesla Tesla", Description: "Tesla Tesla", PublishedAt: now},
+		{Title: "Tesla", PublishedAt: now.Add(-24 * time.Hour)},
+	}, query.FromKeywords([]string{"Tesla"}))
+
+	// Three title and two description hits weigh 8, scoring 8 / (8 + 2)
+	if ranked[0].Score != 80 {
+		t.Errorf("Expected a fresh article full of hits to score 80, got %v", ranked[0].Score)
+	}
+	// One title hit scores 50, halved by a day of age
+	if ranked[1].Score < 24.9 || ranked[1].Score > 25.1 {
+		t.Errorf("Expected a day-old single hit to score 25, got %v", ranked[1].Score)
+	}
+}
+
+func TestNewsService_MatchArticlesRanked(t *testing.T) {
+	now := time.Now()
+	articles := []models.NewsArticle{
+		{Title: "Markets wrap", Description: "Fed minutes due", PublishedAt: now.Add(-72 * time.Hour)},
+		{Title: "Fed holds rates", Description: "The Fed kept rates unchanged", PublishedAt: now},
+		{Title: "Sports roundup", PublishedAt: now},
+	}
+
+	matches := NewNewsService(nil, nil).MatchArticles(articles, []string{"Fed"})
+
+	if len(matches) != 2 || matches[0].Title != "Fed holds rates" || matches[1].Title != "Markets wrap" {
+		t.Fatalf("Expected matches ranked best first, got %+v", matches)
+	}
+	if matches[0].Score <= matches[1].Score || matches[1].Score <= 0 {
+		t.Errorf("Expected positive decreasing scores, got %v and %v", matches[0].Score, matches[1].Score)
+	}
+
+	if kept := filterByScore(matches, matches[0].Score); len(kept) != 1 || kept[0].Title != "Fed holds rates" {
+		t.Errorf("Expected the threshold to keep only the best match, got %+v", kept)
+	}
+	if kept := filterByScore(matches, 0); len(kept) != 2 {
+		t.Errorf("Expected no threshold to keep every match, got %d", len(kept))
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/pkg/cron"
+)
+
+// invalidScheduleRetry is how long the scheduler waits before trying again
+// an alert whose schedule or time zone can no longer be loaded
+const invalidScheduleRetry = time.Hour
+
+// The schedules alerts without one of their own run on, by frequency, in the
+// alert's time zone
+var (
+	realtimeSchedule, _ = cron.Parse("*/5 * * * *")
+	hourlySchedule, _   = cron.Parse("0 * * * *")
+	dailySchedule, _    = cron.Parse("0 9 * * *")
+)
+
+// alertSchedule returns the cron schedule the alert runs on and the location
+// to evaluate it in. Alerts without a schedule of their own run on the one
+// for their frequency. Alerts without a time zone use the server's.
+func alertSchedule(alert *models.Alert) (*cron.Schedule, *time.Location, error) {
+	loc := time.Local
+	if alert.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(alert.TimeZone); err != nil {
+			return nil, nil, errors.New("invalid time zone: " + alert.TimeZone)
+		}
+	}
+
+	switch {
+	case alert.Schedule != "":
+		schedule, err := cron.Parse(alert.Schedule)
+		if err != nil {
+			return nil, nil, errors.New("invalid schedule: " + err.Error())
+		}
+		return schedule, loc, nil
+	case alert.Frequency == models.FrequencyRealTime:
+		return realtimeSchedule, loc, nil
+	case alert.Frequency == models.FrequencyHourly:
+		return hourlySchedule, loc, nil
+	case alert.Frequency == models.FrequencyDaily:
+		return dailySchedule, loc, nil
+	}
+
+	return nil, loc, errors.New("invalid schedule: an alert needs a schedule or a frequency")
+}
+
+// nextRun returns the first time after now that the alert is scheduled to run
+func nextRun(alert *models.Alert, now time.Time) (time.Time, error) {
+	schedule, loc, err := alertSchedule(alert)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	next := schedule.Next(now.In(loc))
+	if next.IsZero() {
+		return time.Time{}, errors.New("invalid schedule: " + schedule.String() + " never runs")
+	}
+	return next, nil
+}
+
+// applySchedule validates the alert's schedule and time zone and sets its
+// next run after now. Alerts with a schedule get the custom frequency.
+func applySchedule(alert *models.Alert, now time.Time) error {
+	if alert.Schedule != "" {
+		alert.Frequency = models.FrequencyCustom
+	} else if alert.Frequency == models.FrequencyCustom || alert.Frequency == "" {
+		return errors.New("invalid schedule: an alert needs a schedule or a frequency")
+	}
+
+	next, err := nextRun(alert, now)
+	if err != nil {
+		return err
+	}
+
+	alert.NextRunAt = &next
+	return nil
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/repositories"
+)
+
+func TestApplySchedule(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %v", err)
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) // 21:00 in Tokyo
+
+	tests := []struct {
+		name      string
+		alert     models.Alert
+		frequency models.AlertFrequency
+		nextRun   time.Time
+		wantErr   string
+	}{
+		{
+			name:      "Cron schedule in a time zone",
+			alert:     models.Alert{Frequency: models.FrequencyDaily, Schedule: "30 7 * * mon-fri", TimeZone: "Asia/Tokyo"},
+			frequency: models.FrequencyCustom,
+			nextRun:   time.Date(2024, 1, 2, 7, 30, 0, 0, tokyo),
+		},
+		{
+			name:      "Daily alert at 9 AM in its time zone",
+			alert:     models.Alert{Frequency: models.FrequencyDaily, TimeZone: "Asia/Tokyo"},
+			frequency: models.FrequencyDaily,
+			nextRun:   time.Date(2024, 1, 2, 9, 0, 0, 0, tokyo),
+		},
+		{
+			name:      "Hourly alerts run on the hour",
+			alert:     models.Alert{Frequency: models.FrequencyHourly, TimeZone: "UTC"},
+			frequency: models.FrequencyHourly,
+			nextRun:   time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "Real-time alerts run every five minutes",
+			alert:     models.Alert{Frequency: models.FrequencyRealTime, TimeZone: "UTC"},
+			frequency: models.FrequencyRealTime,
+			nextRun:   time.Date(2024, 1, 1, 12, 5, 0, 0, time.UTC),
+		},
+		{
+			name:    "Invalid cron expression",
+			alert:   models.Alert{Schedule: "0 25 * * *"},
+			wantErr: "invalid schedule: hour 25 is out of range 0-23",
+		},
+		{
+			name:    "Schedule that never runs",
+			alert:   models.Alert{Schedule: "0 0 31 2 *"},
+			wantErr: "invalid schedule: 0 0 31 2 * never runs",
+		},
+		{
+			name:    "Unknown time zone",
+			alert:   models.Alert{Frequency: models.FrequencyDaily, TimeZone: "Mars/Olympus_Mons"},
+			wantErr: "invalid time zone: Mars/Olympus_Mons",
+		},
+		{
+			name:    "Custom frequency without a schedule",
+			alert:   models.Alert{Frequency: models.FrequencyCustom},
+			wantErr: "invalid schedule: an alert needs a schedule or a frequency",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := tt.alert
+			err := applySchedule(&alert, now)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Expected error %q but got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if alert.Frequency != tt.frequency {
+				t.Errorf("Expected frequency %s but got %s", tt.frequency, alert.Frequency)
+			}
+			if alert.NextRunAt == nil || !alert.NextRunAt.Equal(tt.nextRun) {
+				t.Errorf("Expected next run %v but got %v", tt.nextRun, alert.NextRunAt)
+			}
+		})
+	}
+}
+
+func TestAlertService_UpdateSchedule(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	alertService := NewAlertService(repositories.NewAlertRepository(db), setupTestRedis())
+
+	created, err := alertService.CreateAlert(ctx, 1, &models.AlertCreateRequest{
+		Topic:             "Markets",
+		Schedule:          "0 7 * * *",
+		TimeZone:          "Europe/Berlin",
+		AlertMatchRequest: models.AlertMatchRequest{Keywords: []string{"stocks"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create alert: %v", err)
+	}
+	if created.Frequency != models.FrequencyCustom || created.NextRunAt == nil {
+		t.Fatalf("Expected a custom alert with a next run but got %+v", created)
+	}
+
+	// Choosing a frequency drops the schedule
+	hourly := models.FrequencyHourly
+	updated, err := alertService.UpdateAlert(ctx, 1, created.ID, &models.AlertUpdateRequest{Frequency: &hourly})
+	if err != nil {
+		t.Fatalf("Failed to update alert: %v", err)
+	}
+	if updated.Schedule != "" || updated.NextRunAt == nil || updated.NextRunAt.Minute() != 0 || updated.TimeZone != "Europe/Berlin" {
+		t.Errorf("Expected an hourly alert without a schedule running on the hour but got %+v", updated)
+	}
+
+	invalid := "every morning"
+	if _, err := alertService.UpdateAlert(ctx, 1, created.ID, &models.AlertUpdateRequest{Schedule: &invalid}); err == nil || !strings.HasPrefix(err.Error(), "invalid schedule") {
+		t.Errorf("Expected the schedule to be rejected but got %v", err)
+	}
+}
+
+func TestAlertService_UpdateKeepsSchedulerColumns(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	alertRepo := repositories.NewAlertRepository(db)
+	alertService := NewAlertService(alertRepo, setupTestRedis())
+
+	created, err := alertService.CreateAlert(ctx, 1, &models.AlertCreateRequest{
+		Topic:             "Markets",
+		Schedule:          "0 7 * * *",
+		TimeZone:          "Europe/Berlin",
+		AlertMatchRequest: models.AlertMatchRequest{Keywords: []string{"stocks"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create alert: %v", err)
+	}
+
+	// The scheduler claims the run and checks the alert while the owner edits it
+	claimedNext := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	checked := time.Now().Truncate(time.Second)
+	if err := db.Model(&models.Alert{}).Where("id = ?", created.ID).Updates(map[string]interface{}{"next_run_at": claimedNext, "last_checked": checked}).Error; err != nil {
+		t.Fatalf("Failed to update alert: %v", err)
+	}
+
+	topic := "Stock markets"
+	schedule := "0 7 * * *"
+	if _, err := alertService.UpdateAlert(ctx, 1, created.ID, &models.AlertUpdateRequest{Topic: &topic, Schedule: &schedule}); err != nil {
+		t.Fatalf("Failed to update alert: %v", err)
+	}
+
+	stored, err := alertRepo.GetByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Failed to load alert: %v", err)
+	}
+	if stored.Topic != topic {
+		t.Errorf("Expected the topic to be saved but got %q", stored.Topic)
+	}
+	if stored.NextRunAt == nil || !stored.NextRunAt.Equal(claimedNext) || stored.LastChecked == nil || !stored.LastChecked.Equal(checked) {
+		t.Errorf("Expected the scheduler's next run and last check to be kept but got %v and %v", stored.NextRunAt, stored.LastChecked)
+	}
+
+	// Changing the schedule moves the next run
+	schedule = "30 18 * * *"
+	if _, err := alertService.UpdateAlert(ctx, 1, created.ID, &models.AlertUpdateRequest{Schedule: &schedule}); err != nil {
+		t.Fatalf("Failed to update alert: %v", err)
+	}
+	if stored, _ := alertRepo.GetByID(ctx, created.ID); stored.NextRunAt == nil || stored.NextRunAt.Equal(claimedNext) {
+		t.Errorf("Expected a new schedule to move the next run but got %v", stored.NextRunAt)
+	}
+}
+
+func TestBackgroundService_ProcessDueAlerts(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	alertRepo := repositories.NewAlertRepository(db)
+	provider := &stubProvider{name: "rss", articles: []models.NewsArticle{
+		{Title: "Stocks rally", URL: "https://example.com/stocks"},
+	}}
+	ingestionService := NewIngestionService([]NewsProvider{provider}, repositories.NewArticleRepository(db), nil, time.Minute, 0)
+	if _, err := ingestionService.Ingest(ctx); err != nil {
+		t.Fatalf("Failed to ingest: %v", err)
+	}
+	notifier := &recordingNotifier{}
+
+	background := &backgroundService{
+		alertService:        NewAlertService(alertRepo, nil),
+		newsService:         NewNewsService(nil, nil),
+		ingestionService:    ingestionService,
+		seenService:         NewSeenArticleService(alertRepo, nil),
+		notificationService: notifier,
+		config:              SchedulerConfig{Interval: time.Minute, MaxCatchUp: 6 * time.Hour, Workers: 2},
+		ctx:                 context.Background(),
+	}
+
+	owner := createVerifiedUser(t, db, "owner@example.com")
+
+	// Missed is the first run after ten hours of downtime; CaughtUp after two
+	past := time.Now().Add(-time.Minute)
+	twoHoursAgo := time.Now().Add(-2 * time.Hour)
+	tenHoursAgo := time.Now().Add(-10 * time.Hour)
+	future := time.Now().Add(time.Hour)
+	alerts := []*models.Alert{
+		{UserID: owner.ID, Topic: "Due", Keywords: models.Keywords{"stocks"}, Frequency: models.FrequencyCustom, Schedule: "*/5 * * * *", NextRunAt: &past, Active: true},
+		{UserID: owner.ID, Topic: "CaughtUp", Keywords: models.Keywords{"stocks"}, Frequency: models.FrequencyHourly, NextRunAt: &twoHoursAgo, Active: true},
+		{UserID: owner.ID, Topic: "Missed", Keywords: models.Keywords{"stocks"}, Frequency: models.FrequencyDaily, NextRunAt: &tenHoursAgo, Active: true},
+		{UserID: owner.ID, Topic: "Later", Keywords: models.Keywords{"stocks"}, Frequency: models.FrequencyCustom, Schedule: "0 9 * * *", NextRunAt: &future, Active: true},
+		{UserID: owner.ID, Topic: "Unscheduled", Keywords: models.Keywords{"stocks"}, Frequency: models.FrequencyRealTime, Active: true},
+		{UserID: owner.ID, Topic: "Inactive", Keywords: models.Keywords{"stocks"}, Frequency: models.FrequencyRealTime, NextRunAt: &past, Active: true},
+	}
+	for _, alert := range alerts {
+		if err := alertRepo.Create(ctx, alert); err != nil {
+			t.Fatalf("Failed to create alert: %v", err)
+		}
+	}
+	// Active defaults to true, so deactivate after creating
+	if err := db.Model(alerts[5]).Update("active", false).Error; err != nil {
+		t.Fatalf("Failed to deactivate alert: %v", err)
+	}
+
+	if err := background.ProcessAlerts(); err != nil {
+		t.Fatalf("Failed to process due alerts: %v", err)
+	}
+
+	if len(notifier.sent) != 2 {
+		t.Fatalf("Expected the due and caught up alerts to be sent but got %d notifications", len(notifier.sent))
+	}
+
+	for _, alert := range alerts {
+		stored, err := alertRepo.GetByID(ctx, alert.ID)
+		if err != nil {
+			t.Fatalf("Failed to load alert: %v", err)
+		}
+
+		switch alert.Topic {
+		case "Due", "CaughtUp":
+			if stored.NextRunAt == nil || !stored.NextRunAt.After(time.Now()) || stored.LastChecked == nil {
+				t.Errorf("Expected %s to be checked and rescheduled but got %+v", alert.Topic, stored)
+			}
+		case "Missed":
+			if stored.NextRunAt == nil || !stored.NextRunAt.After(time.Now()) || stored.LastChecked == nil {
+				t.Errorf("Expected the missed run to be skipped and rescheduled but got %+v", stored)
+			}
+		case "Later":
+			if !stored.NextRunAt.Equal(future) || stored.LastChecked != nil {
+				t.Errorf("Expected the later alert to be left alone but got %+v", stored)
+			}
+		case "Unscheduled":
+			if stored.NextRunAt == nil || stored.LastChecked != nil {
+				t.Errorf("Expected the unscheduled alert to be scheduled without running but got %+v", stored)
+			}
+		case "Inactive":
+			if !stored.NextRunAt.Equal(past) || stored.LastChecked != nil {
+				t.Errorf("Expected the inactive alert to be left alone but got %+v", stored)
+			}
+		}
+	}
+
+	state, err := background.State(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get scheduler state: %v", err)
+	}
+	run := state.LastRun
+	if run == nil || run.Claimed != 4 || run.Run != 2 || run.Skipped != 1 || run.Scheduled != 1 || run.Failed != 0 {
+		t.Errorf("Expected 4 claimed, 2 run, 1 skipped and 1 scheduled but got %+v", run)
+	}
+	if state.Due != 0 || len(state.Upcoming) != 5 {
+		t.Errorf("Expected nothing due and 5 upcoming runs but got %d and %d", state.Due, len(state.Upcoming))
+	}
+	for i := 1; i < len(state.Upcoming); i++ {
+		if state.Upcoming[i].NextRunAt.Before(state.Upcoming[i-1].NextRunAt) {
+			t.Errorf("Expected upcoming runs soonest first but got %+v", state.Upcoming)
+		}
+	}
+
+	// A second pass finds nothing due
+	if err := background.ProcessAlerts(); err != nil {
+		t.Fatalf("Failed to process due alerts: %v", err)
+	}
+	if len(notifier.sent) != 2 || background.lastRun.Claimed != 0 {
+		t.Errorf("Expected nothing to run again but got %d notifications and %+v", len(notifier.sent), background.lastRun)
+	}
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/repositories"
+	"news-to-text/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// seenSetCacheTTL is how long an alert's seen-set stays in Redis after the
+// last delivery. The database remains the source of truth.
+const seenSetCacheTTL = 30 * 24 * time.Hour
+
+// SeenArticleService keeps the per-alert set of stories that have already
+// been delivered, so no story is sent twice for the same alert
+type SeenArticleService interface {
+	FilterUnseen(ctx context.Context, alertID uint, articles []models.NewsArticle) ([]models.NewsArticle, error)
+	RecordDelivery(ctx context.Context, alertID uint, articles []models.NewsArticle, notification *models.Notification, token int64) error
+	RecordHeld(ctx context.Context, alertID uint, articles []models.NewsArticle, until time.Time, token int64) error
+	HeldDeliveries(ctx context.Context, now time.Time) ([]models.AlertHistory, error)
+	RecordRelease(ctx context.Context, histories []models.AlertHistory, notification *models.Notification, token int64) error
+}
+
+type seenArticleService struct {
+	alertRepo repositories.AlertRepository
+	redis     *redis.Client
+}
+
+// NewSeenArticleService creates a seen-set backed by the database, with
+// Redis in front of it when a client is given
+func NewSeenArticleService(alertRepo repositories.AlertRepository, redisClient *redis.Client) SeenArticleService {
+	return &seenArticleService{
+		alertRepo: alertRepo,
+		redis:     redisClient,
+	}
+}
+
+// FilterUnseen drops the articles whose story has already been delivered for
+// the alert, and repeated copies of the same story within the batch
+func (s *seenArticleService) FilterUnseen(ctx context.Context, alertID uint, articles []models.NewsArticle) ([]models.NewsArticle, error) {
+	if len(articles) == 0 {
+		return articles, nil
+	}
+
+	var keys []string
+	batch := make(map[string]bool, len(articles))
+	for _, article := range articles {
+		key := storyIdentity(article)
+		if !batch[key] {
+			batch[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	seen := s.cachedSeen(ctx, alertID, keys)
+
+	var uncached []string
+	for _, key := range keys {
+		if !seen[key] {
+			uncached = append(uncached, key)
+		}
+	}
+
+	stored, err := s.alertRepo.GetSeenStoryKeys(ctx, alertID, uncached)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range stored {
+		seen[key] = true
+	}
+	s.cacheSeen(ctx, alertID, stored)
+
+	unseen := make([]models.NewsArticle, 0, len(articles))
+	for _, article := range articles {
+		key := storyIdentity(article)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unseen = append(unseen, article)
+	}
+
+	return unseen, nil
+}
+
+// RecordDelivery writes a history row for every article, queues the
+// notification that carries them and adds the stories to the seen-set, in
+// one transaction. The rows record the outcome of each attempt to send it.
+// token is the scheduler leader's fencing token, 0 without an election.
+func (s *seenArticleService) RecordDelivery(ctx context.Context, alertID uint, articles []models.NewsArticle, notification *models.Notification, token int64) error {
+	histories, keys := deliveryHistories(alertID, articles)
+
+	if err := s.alertRepo.RecordDelivery(ctx, histories, notification, token); err != nil {
+		return err
+	}
+
+	s.cacheSeen(ctx, alertID, keys)
+	return nil
+}
+
+// RecordHeld writes a held history row for every article, to be sent when
+// the owner's quiet hours end at until. The stories join the seen-set now so
+// later runs in the quiet hours don't hold them again.
+func (s *seenArticleService) RecordHeld(ctx context.Context, alertID uint, articles []models.NewsArticle, until time.Time, token int64) error {
+	histories, keys := deliveryHistories(alertID, articles)
+	for _, history := range histories {
+		history.Held = true
+		history.HeldUntil = &until
+	}
+
+	if err := s.alertRepo.RecordDelivery(ctx, histories, nil, token); err != nil {
+		return err
+	}
+
+	s.cacheSeen(ctx, alertID, keys)
+	return nil
+}
+
+// HeldDeliveries returns the held notifications whose quiet hours have ended
+// by now, with their alert and its owner
+func (s *seenArticleService) HeldDeliveries(ctx context.Context, now time.Time) ([]models.AlertHistory, error) {
+	return s.alertRepo.GetHeldHistory(ctx, now)
+}
+
+// RecordRelease queues the notification that carries held rows and marks
+// them as no longer held
+func (s *seenArticleService) RecordRelease(ctx context.Context, histories []models.AlertHistory, notification *models.Notification, token int64) error {
+	ids := make([]uint, len(histories))
+	for i, history := range histories {
+		ids[i] = history.ID
+	}
+
+	return s.alertRepo.ReleaseHeld(ctx, ids, notification, token)
+}
+
+// deliveryHistories builds a history row for each article and returns them
+// with the articles' story keys
+func deliveryHistories(alertID uint, articles []models.NewsArticle) ([]*models.AlertHistory, []string) {
+	now := time.Now()
+	histories := make([]*models.AlertHistory, 0, len(articles))
+	keys := make([]string, 0, len(articles))
+
+	for _, article := range articles {
+		key := storyIdentity(article)
+		histories = append(histories, &models.AlertHistory{
+			AlertID:    alertID,
+			NewsTitle:  article.Title,
+			NewsURL:    article.URL,
+			NewsSource: article.Source,
+			StoryKey:   key,
+			SentAt:     now,
+
+			Explanation: article.Explanation,
+		})
+		keys = append(keys, key)
+	}
+
+	return histories, keys
+}
+
+func (s *seenArticleService) cachedSeen(ctx context.Context, alertID uint, keys []string) map[string]bool {
+	seen := make(map[string]bool, len(keys))
+	if s.redis == nil || len(keys) == 0 {
+		return seen
+	}
+
+	members := make([]interface{}, len(keys))
+	for i, key := range keys {
+		members[i] = key
+	}
+
+	found, err := s.redis.SMIsMember(ctx, seenSetCacheKey(alertID), members...).Result()
+	if err != nil {
+		logger.Error("Failed to read seen-set from Redis for alert", alertID, ":", err)
+		return seen
+	}
+
+	for i, isMember := range found {
+		if isMember {
+			seen[keys[i]] = true
+		}
+	}
+	return seen
+}
+
+func (s *seenArticleService) cacheSeen(ctx context.Context, alertID uint, keys []string) {
+	if s.redis == nil || len(keys) == 0 {
+		return
+	}
+
+	members := make([]interface{}, len(keys))
+	for i, key := range keys {
+		members[i] = key
+	}
+
+	cacheKey := seenSetCacheKey(alertID)
+
+	pipe := s.redis.TxPipeline()
+	pipe.SAdd(ctx, cacheKey, members...)
+	pipe.Expire(ctx, cacheKey, seenSetCacheTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Error("Failed to cache seen-set in Redis for alert", alertID, ":", err)
+	}
+}
+
+func seenSetCacheKey(alertID uint) string {
+	return fmt.Sprintf("alert:%d:seen", alertID)
+}
+
+// storyIdentity is the canonical identity of an article's story: the story
+// key assigned at ingestion, else the key of its canonical URL or GUID, else
+// a hash of its source and title
+func storyIdentity(article models.NewsArticle) string {
+	if article.StoryKey != "" {
+		return article.StoryKey
+	}
+	if key := articleKey(article); key != "" {
+		return key
+	}
+
+	sum := sha256.Sum256([]byte(strings.ToLower(article.Source + "\n" + article.Title)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/repositories"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// recordingNotifier is a NotificationService that remembers the
+// notifications queued through it and the messages it sent
+type recordingNotifier struct {
+	mu       sync.Mutex
+	sent     [][]models.NewsArticle
+	held     [][]models.AlertHistory
+	messages []string
+	err      error
+}
+
+func (n *recordingNotifier) SendSMS(ctx context.Context, phoneNumber, message string) (string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.err != nil {
+		return "", n.err
+	}
+	n.messages = append(n.messages, message)
+	return fmt.Sprintf("SM%d", len(n.messages)), nil
+}
+
+func (n *recordingNotifier) FormatNewsMessage(alert *models.Alert, articles []models.NewsArticle) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, articles)
+	return alert.Topic
+}
+
+func (n *recordingNotifier) FormatHeldMessage(held []models.AlertHistory) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.held = append(n.held, held)
+	return "held"
+}
+
+// createVerifiedUser stores a user with a verified phone number, so
+// notifications are queued and sent for them
+func createVerifiedUser(t *testing.T, db *gorm.DB, email string) *models.User {
+	t.Helper()
+
+	verifiedAt := time.Now()
+	user := &models.User{Email: email, Password: "hashed", Phone: "+14155552671", PhoneVerifiedAt: &verifiedAt}
+	if err := repositories.NewUserRepository(db).Create(context.Background(), user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	return user
+}
+
+func TestSeenArticleService_FilterUnseen(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	seenService := NewSeenArticleService(repositories.NewAlertRepository(db), nil)
+
+	articles := []models.NewsArticle{
+		{Title: "Fed holds rates", URL: "https://example.com/fed", StoryKey: "fed"},
+		{Title: "Fed holds rates - CNBC", URL: "https://cnbc.com/fed", StoryKey: "fed"},
+		{Title: "Tesla recalls vehicles", URL: "https://example.com/tesla?utm_source=rss"},
+		{Title: "Undated wire copy without a link", Source: "Wire"},
+	}
+
+	unseen, err := seenService.FilterUnseen(ctx, 1, articles)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(unseen) != 3 {
+		t.Fatalf("Expected 3 unseen stories but got %d", len(unseen))
+	}
+
+	notification := &models.Notification{UserID: 1, Message: "Fed", NextAttemptAt: time.Now()}
+	if err := seenService.RecordDelivery(ctx, 1, unseen, notification, 0); err != nil {
+		t.Fatalf("Failed to record delivery: %v", err)
+	}
+
+	// The same stories, reached through a different copy or tracking URL
+	again := []models.NewsArticle{
+		{Title: "Fed holds rates - CNBC", URL: "https://cnbc.com/fed", StoryKey: "fed"},
+		{Title: "Tesla recalls vehicles", URL: "https://www.example.com/tesla"},
+		{Title: "Undated wire copy without a link", Source: "Wire"},
+		{Title: "Bitcoin climbs", URL: "https://example.com/bitcoin"},
+	}
+
+	unseen, err = seenService.FilterUnseen(ctx, 1, again)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(unseen) != 1 || unseen[0].Title != "Bitcoin climbs" {
+		t.Errorf("Expected only the new story but got %v", unseen)
+	}
+
+	// The seen-set is per alert
+	unseen, err = seenService.FilterUnseen(ctx, 2, again)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(unseen) != 4 {
+		t.Errorf("Expected another alert to see all 4 stories but got %d", len(unseen))
+	}
+
+	var history []models.AlertHistory
+	db.Where("alert_id = ?", 1).Find(&history)
+	if len(history) != 3 {
+		t.Errorf("Expected 3 history rows but got %d", len(history))
+	}
+	for _, h := range history {
+		if h.NotificationID == nil || *h.NotificationID != notification.ID || h.StoryKey == "" {
+			t.Errorf("Expected history row with a story key pointing at notification %d but got %+v", notification.ID, h)
+		}
+	}
+}
+
+func TestSeenArticleService_RecordDeliveryQueuesNotification(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	seenService := NewSeenArticleService(repositories.NewAlertRepository(db), nil)
+	articles := []models.NewsArticle{{Title: "Fed holds rates", URL: "https://example.com/fed"}}
+
+	notification := &models.Notification{UserID: 1, Message: "Fed holds rates", NextAttemptAt: time.Now()}
+	if err := seenService.RecordDelivery(ctx, 1, articles, notification, 0); err != nil {
+		t.Fatalf("Failed to record delivery: %v", err)
+	}
+
+	// The story is seen once its notification is queued; the dispatcher
+	// retries the send rather than the next run
+	unseen, err := seenService.FilterUnseen(ctx, 1, articles)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(unseen) != 0 {
+		t.Errorf("Expected the queued story to be seen")
+	}
+
+	queued, err := repositories.NewNotificationRepository(db).GetByID(ctx, notification.ID)
+	if err != nil {
+		t.Fatalf("Failed to load notification: %v", err)
+	}
+	if queued.Status != models.NotificationPending || queued.Attempts != 0 {
+		t.Errorf("Expected a pending notification but got %+v", queued)
+	}
+
+	var history models.AlertHistory
+	db.Where("alert_id = ?", 1).First(&history)
+	if history.Success || history.NotificationID == nil || *history.NotificationID != notification.ID {
+		t.Errorf("Expected an unsent history row pointing at notification %d but got %+v", notification.ID, history)
+	}
+}
+
+func TestSeenArticleService_RedisAcceleration(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	seenService := NewSeenArticleService(repositories.NewAlertRepository(db), redisClient)
+	articles := []models.NewsArticle{
+		{Title: "Fed holds rates", URL: "https://example.com/fed", StoryKey: "fed"},
+		{Title: "Tesla recalls vehicles", URL: "https://example.com/tesla", StoryKey: "tesla"},
+	}
+
+	if err := seenService.RecordDelivery(ctx, 7, articles, nil, 0); err != nil {
+		t.Fatalf("Failed to record delivery: %v", err)
+	}
+
+	if ok, _ := mr.SIsMember(seenSetCacheKey(7), "fed"); !ok {
+		t.Fatalf("Expected delivered story to be cached in Redis")
+	}
+
+	// Redis answers without the database
+	db.Exec("DELETE FROM seen_articles")
+	unseen, err := seenService.FilterUnseen(ctx, 7, articles)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(unseen) != 0 {
+		t.Errorf("Expected Redis to report both stories as seen but got %d unseen", len(unseen))
+	}
+
+	// The database answers when Redis has lost the set, and Redis is warmed
+	if err := seenService.RecordDelivery(ctx, 8, articles[:1], nil, 0); err != nil {
+		t.Fatalf("Failed to record delivery: %v", err)
+	}
+	mr.FlushAll()
+
+	unseen, err = seenService.FilterUnseen(ctx, 8, articles)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(unseen) != 1 || unseen[0].StoryKey != "tesla" {
+		t.Errorf("Expected only the undelivered story but got %v", unseen)
+	}
+	if ok, _ := mr.SIsMember(seenSetCacheKey(8), "fed"); !ok {
+		t.Errorf("Expected Redis to be warmed from the database")
+	}
+}
+
+func TestBackgroundService_NeverResendsArticles(t *testing.T) {
+	ctx := context.Background()
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	alertRepo := repositories.NewAlertRepository(db)
+	provider := &stubProvider{name: "rss", articles: []models.NewsArticle{
+		{Title: "Apple unveils new iPhone", URL: "https://example.com/iphone"},
+		{Title: "Tesla recalls vehicles", URL: "https://example.com/tesla"},
+	}}
+	ingestionService := NewIngestionService([]NewsProvider{provider}, repositories.NewArticleRepository(db), nil, time.Minute, 0)
+	notifier := &recordingNotifier{}
+
+	background := &backgroundService{
+		alertService:        NewAlertService(alertRepo, nil),
+		newsService:         NewNewsService(nil, nil),
+		ingestionService:    ingestionService,
+		seenService:         NewSeenArticleService(alertRepo, nil),
+		notificationService: notifier,
+		config:              SchedulerConfig{MaxCatchUp: time.Hour},
+		ctx:                 context.Background(),
+	}
+
+	owner := createVerifiedUser(t, db, "owner@example.com")
+	due := time.Now().Add(-time.Minute)
+	alert := &models.Alert{UserID: owner.ID, Topic: "Apple", Keywords: models.Keywords{"Apple"}, Frequency: models.FrequencyRealTime, NextRunAt: &due, Active: true}
+	if err := alertRepo.Create(ctx, alert); err != nil {
+		t.Fatalf("Failed to create alert: %v", err)
+	}
+
+	if _, err := ingestionService.Ingest(ctx); err != nil {
+		t.Fatalf("Failed to ingest: %v", err)
+	}
+	if err := background.ProcessAlerts(); err != nil {
+		t.Fatalf("Failed to process alerts: %v", err)
+	}
+
+	// The next run re-reads the overlap window and sees the same article
+	if err := db.Model(alert).Update("next_run_at", due).Error; err != nil {
+		t.Fatalf("Failed to make the alert due again: %v", err)
+	}
+	if err := background.ProcessAlerts(); err != nil {
+		t.Fatalf("Failed to process alerts: %v", err)
+	}
+	if background.lastRun.Run != 1 {
+		t.Fatalf("Expected the alert to run again but got %+v", background.lastRun)
+	}
+
+	if len(notifier.sent) != 1 || len(notifier.sent[0]) != 1 {
+		t.Errorf("Expected a single notification with one article but got %v", notifier.sent)
+	}
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrSMSNotConfigured is returned by an SMS provider constructor when the
+// provider lacks its credentials. Texts are then only logged.
+var ErrSMSNotConfigured = errors.New("sms provider not configured")
+
+// SMSProvider sends text messages through an SMS gateway. Send returns the
+// gateway's ID for the message.
+type SMSProvider interface {
+	Send(ctx context.Context, to, body string) (string, error)
+}
+
+// SMSError is a message the gateway refused. Permanent errors, such as an
+// invalid or unsubscribed number, fail again however often they are retried.
+type SMSError struct {
+	Code      int // the gateway's error code, 0 when it gave none
+	Status    int // the HTTP status of the response, 0 when there was none
+	Message   string
+	Permanent bool
+}
+
+func (e *SMSError) Error() string {
+	if e.Code == 0 && e.Status == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("sms error %d (status %d): %s", e.Code, e.Status, e.Message)
+}
+
+// isPermanentSMSError reports whether err is an SMSError that retrying
+// won't fix
+func isPermanentSMSError(err error) bool {
+	var smsErr *SMSError
+	return errors.As(err, &smsErr) && smsErr.Permanent
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"news-to-text/pkg/logger"
+)
+
+const twilioBaseURL = "https://api.twilio.com"
+
+// retryableTwilioCodes are the Twilio error codes on a 4xx response that
+// can succeed when tried again later. Any other 4xx is permanent; 429 and
+// 5xx responses are always retried.
+var retryableTwilioCodes = map[int]bool{
+	20003: true, // authentication failed: a credentials problem, not the message's
+	20429: true, // too many requests
+	20500: true, // internal error
+	20503: true, // service unavailable
+	21611: true, // the sender's queue is full
+}
+
+// TwilioConfig holds the settings for sending texts through Twilio.
+// Messages are sent from the messaging service when one is set, else from
+// the from number.
+type TwilioConfig struct {
+	AccountSID          string
+	AuthToken           string
+	MessagingServiceSID string
+	FromNumber          string
+	BaseURL             string // overrides the Twilio API, for tests
+	Timeout             time.Duration
+}
+
+// twilioProvider sends texts through Twilio's Messages API
+type twilioProvider struct {
+	config  TwilioConfig
+	baseURL string
+	client  *http.Client
+}
+
+// twilioMessage is the part of Twilio's message resource, or error body,
+// that is read back
+type twilioMessage struct {
+	SID     string `json:"sid"`
+	Status  string `json:"status"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewTwilioProvider creates a Twilio SMS provider. It returns
+// ErrSMSNotConfigured without an account SID and auth token.
+func NewTwilioProvider(cfg TwilioConfig) (SMSProvider, error) {
+	if cfg.AccountSID == "" || cfg.AuthToken == "" {
+		return nil, ErrSMSNotConfigured
+	}
+	if cfg.MessagingServiceSID == "" {
+		if cfg.FromNumber == "" {
+			return nil, errors.New("twilio needs a messaging service SID or a from number")
+		}
+		from, err := normalizePhoneNumber(cfg.FromNumber)
+		if err != nil {
+			return nil, fmt.Errorf("twilio from number: %w", err)
+		}
+		cfg.FromNumber = from
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = twilioBaseURL
+	}
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &twilioProvider{
+		config:  cfg,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Send creates a message and returns its SID. Twilio queues the message
+// and answers 201; an answer outside 2xx is returned as an SMSError. An
+// accepted message whose SID can't be read counts as sent, with no SID.
+func (p *twilioProvider) Send(ctx context.Context, to, body string) (string, error) {
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("Body", body)
+	if p.config.MessagingServiceSID != "" {
+		form.Set("MessagingServiceSid", p.config.MessagingServiceSID)
+	} else {
+		form.Set("From", p.config.FromNumber)
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", p.baseURL, url.PathEscape(p.config.AccountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(p.config.AccountSID, p.config.AuthToken)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", err
+	}
+
+	var message twilioMessage
+	decodeErr := json.Unmarshal(data, &message)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", twilioError(resp.StatusCode, message)
+	}
+
+	// Twilio has accepted the message, so sending it again would text the
+	// recipient twice even when the answer can't be read
+	if decodeErr != nil || message.SID == "" {
+		logger.Error("Twilio accepted a message with status", resp.StatusCode, "but returned no message SID:", decodeErr)
+		return "", nil
+	}
+
+	return message.SID, nil
+}
+
+// twilioError turns an error response into an SMSError, deciding from its
+// status and code whether sending again can work
+func twilioError(status int, message twilioMessage) *SMSError {
+	text := message.Message
+	if text == "" {
+		text = http.StatusText(status)
+	}
+
+	clientError := status >= 400 && status < 500 && status != http.StatusTooManyRequests
+	return &SMSError{
+		Code:      message.Code,
+		Status:    status,
+		Message:   text,
+		Permanent: clientError && !retryableTwilioCodes[message.Code],
+	}
+}
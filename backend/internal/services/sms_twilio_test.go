@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewTwilioProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  TwilioConfig
+		wantErr string
+	}{
+		{"Not configured", TwilioConfig{}, ErrSMSNotConfigured.Error()},
+		{"No auth token", TwilioConfig{AccountSID: "AC123", FromNumber: "+15005550006"}, ErrSMSNotConfigured.Error()},
+		{"No sender", TwilioConfig{AccountSID: "AC123", AuthToken: "secret"}, "twilio needs a messaging service SID or a from number"},
+		{"Invalid from number", TwilioConfig{AccountSID: "AC123", AuthToken: "secret", FromNumber: "5550006"}, "twilio from number: invalid phone number: expected E.164 format such as +14155552671"},
+		{"From number", TwilioConfig{AccountSID: "AC123", AuthToken: "secret", FromNumber: "+1 500 555 0006"}, ""},
+		{"Messaging service", TwilioConfig{AccountSID: "AC123", AuthToken: "secret", MessagingServiceSID: "MG123"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTwilioProvider(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Expected %q but got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTwilioProvider_Send(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		config TwilioConfig
+		from   string
+		via    string
+	}{
+		{"From number", TwilioConfig{FromNumber: "+1 500 555 0006"}, "+15005550006", ""},
+		{"Messaging service", TwilioConfig{MessagingServiceSID: "MG123", FromNumber: "+15005550006"}, "", "MG123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/2010-04-01/Accounts/AC123/Messages.json" {
+					t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				if user, pass, ok := r.BasicAuth(); !ok || user != "AC123" || pass != "secret" {
+					t.Errorf("Expected the account SID and auth token as basic auth")
+				}
+				if r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+					t.Errorf("Expected a form body but got %q", r.Header.Get("Content-Type"))
+				}
+				if err := r.ParseForm(); err != nil {
+					t.Fatalf("Failed to parse form: %v", err)
+				}
+				if r.PostForm.Get("To") != "+14155552671" || r.PostForm.Get("Body") != "Stocks rally" {
+					t.Errorf("Unexpected message %v", r.PostForm)
+				}
+				if r.PostForm.Get("From") != tt.from || r.PostForm.Get("MessagingServiceSid") != tt.via {
+					t.Errorf("Expected from %q via %q but got %v", tt.from, tt.via, r.PostForm)
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"sid":"SM0123456789abcdef","status":"queued","to":"+14155552671"}`))
+			}))
+			defer server.Close()
+
+			config := tt.config
+			config.AccountSID, config.AuthToken, config.BaseURL = "AC123", "secret", server.URL
+			provider, err := NewTwilioProvider(config)
+			if err != nil {
+				t.Fatalf("Failed to create provider: %v", err)
+			}
+
+			sid, err := provider.Send(ctx, "+14155552671", "Stocks rally")
+			if err != nil || sid != "SM0123456789abcdef" {
+				t.Errorf("Expected the message SID but got %q, %v", sid, err)
+			}
+		})
+	}
+}
+
+func TestTwilioProvider_AcceptedWithoutSID(t *testing.T) {
+	ctx := context.Background()
+
+	// Twilio took the message, so it must not be retried and sent twice
+	for _, body := range []string{`{"status":"queued"}`, `not json`, ``} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(body))
+		}))
+
+		provider, err := NewTwilioProvider(TwilioConfig{AccountSID: "AC123", AuthToken: "secret", FromNumber: "+15005550006", BaseURL: server.URL})
+		if err != nil {
+			t.Fatalf("Failed to create provider: %v", err)
+		}
+
+		sid, err := provider.Send(ctx, "+14155552671", "Stocks rally")
+		if err != nil || sid != "" {
+			t.Errorf("Expected %q to count as sent without a SID but got %q, %v", body, sid, err)
+		}
+		server.Close()
+	}
+}
+
+func TestTwilioProvider_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		code      int
+		permanent bool
+	}{
+		{"Invalid number", 400, `{"code":21211,"message":"Invalid 'To' Phone Number","more_info":"https://www.twilio.com/docs/errors/21211","status":400}`, 21211, true},
+		{"Unsubscribed", 400, `{"code":21610,"message":"Attempt to send to unsubscribed recipient","status":400}`, 21610, true},
+		{"Not a mobile number", 400, `{"code":21614,"message":"'To' number is not a valid mobile number","status":400}`, 21614, true},
+		{"Bad credentials", 401, `{"code":20003,"message":"Authenticate","status":401}`, 20003, false},
+		{"Rate limited", 429, `{"code":20429,"message":"Too Many Requests","status":429}`, 20429, false},
+		{"Queue full", 400, `{"code":21611,"message":"This 'From' number has exceeded the maximum number of queued messages","status":400}`, 21611, false},
+		{"Server error", 503, `{"code":20503,"message":"Service Unavailable","status":503}`, 20503, false},
+		{"Gateway error without a body", 502, ``, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			provider, err := NewTwilioProvider(TwilioConfig{AccountSID: "AC123", AuthToken: "secret", FromNumber: "+15005550006", BaseURL: server.URL})
+			if err != nil {
+				t.Fatalf("Failed to create provider: %v", err)
+			}
+
+			_, err = provider.Send(ctx, "+14155552671", "Stocks rally")
+			var smsErr *SMSError
+			if !errors.As(err, &smsErr) {
+				t.Fatalf("Expected an SMS error but got %v", err)
+			}
+			if smsErr.Code != tt.code || smsErr.Status != tt.status || smsErr.Message == "" {
+				t.Errorf("Expected code %d and status %d but got %+v", tt.code, tt.status, smsErr)
+			}
+			if isPermanentSMSError(err) != tt.permanent {
+				t.Errorf("Expected permanent to be %t but got %+v", tt.permanent, smsErr)
+			}
+		})
+	}
+}
+
+func TestTwilioProvider_NetworkErrorIsRetried(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	provider, err := NewTwilioProvider(TwilioConfig{AccountSID: "AC123", AuthToken: "secret", FromNumber: "+15005550006", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	_, err = provider.Send(context.Background(), "+14155552671", "Stocks rally")
+	if err == nil || isPermanentSMSError(err) {
+		t.Errorf("Expected a retryable error but got %v", err)
+	}
+}
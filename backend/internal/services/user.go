@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"news-to-text/internal/models"
+	"news-to-text/internal/repositories"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// UserService manages the settings users keep on their own account
+type UserService interface {
+	GetProfile(ctx context.Context, userID uint) (*models.UserResponse, error)
+	UpdateQuietHours(ctx context.Context, userID uint, req *models.QuietHoursUpdateRequest) (*models.UserResponse, error)
+	StartPhoneVerification(ctx context.Context, userID uint, req *models.PhoneUpdateRequest) error
+	VerifyPhone(ctx context.Context, userID uint, req *models.PhoneVerifyRequest) (*models.UserResponse, error)
+}
+
+type userService struct {
+	userRepo            repositories.UserRepository
+	redis               *redis.Client
+	notificationService NotificationService
+	secret              []byte
+}
+
+// NewUserService creates a user service. Phone verification codes are kept
+// in Redis, hashed under secret, and texted through notificationService.
+func NewUserService(userRepo repositories.UserRepository, redisClient *redis.Client, notificationService NotificationService, secret string) UserService {
+	return &userService{
+		userRepo:            userRepo,
+		redis:               redisClient,
+		notificationService: notificationService,
+		secret:              []byte(secret),
+	}
+}
+
+func (s *userService) GetProfile(ctx context.Context, userID uint) (*models.UserResponse, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return user.ToResponse(), nil
+}
+
+func (s *userService) UpdateQuietHours(ctx context.Context, userID uint, req *models.QuietHoursUpdateRequest) (*models.UserResponse, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Start != nil {
+		user.QuietHoursStart = strings.TrimSpace(*req.Start)
+	}
+	if req.End != nil {
+		user.QuietHoursEnd = strings.TrimSpace(*req.End)
+	}
+	if req.TimeZone != nil {
+		user.TimeZone = strings.TrimSpace(*req.TimeZone)
+	}
+
+	if _, _, _, err := quietHours(user); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user.ToResponse(), nil
+}
+
+// StartPhoneVerification texts a verification code to the number. The
+// user's phone number only changes once the code is confirmed with
+// VerifyPhone; until then the code is kept in Redis, hashed, for
+// phoneCodeTTL, and a new request replaces it.
+func (s *userService) StartPhoneVerification(ctx context.Context, userID uint, req *models.PhoneUpdateRequest) error {
+	phone, err := normalizePhoneNumber(req.Phone)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return err
+	}
+
+	allowed, err := s.redis.SetNX(ctx, phoneResendKey(userID), "1", phoneCodeResendInterval).Result()
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.New("verification code recently sent")
+	}
+
+	code, err := newPhoneCode()
+	if err != nil {
+		return err
+	}
+
+	key := phoneVerificationKey(userID)
+	pipe := s.redis.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.HSet(ctx, key, "phone", phone, "code", hashPhoneCode(s.secret, userID, phone, code), "attempts", 0)
+	pipe.Expire(ctx, key, phoneCodeTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Your NewsToText verification code is %s. It expires in %d minutes.", code, int(phoneCodeTTL.Minutes()))
+	if _, err := s.notificationService.SendSMS(ctx, phone, message); err != nil {
+		// Let the user ask again straight away rather than wait for a code
+		// that never arrives
+		s.redis.Del(ctx, key, phoneResendKey(userID))
+		if isPermanentSMSError(err) {
+			return fmt.Errorf("invalid phone number: %w", err)
+		}
+		return fmt.Errorf("failed to send verification code: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyPhone sets the user's phone number to the one the code was texted
+// to. After phoneCodeMaxAttempts wrong codes the pending code is dropped.
+func (s *userService) VerifyPhone(ctx context.Context, userID uint, req *models.PhoneVerifyRequest) (*models.UserResponse, error) {
+	key := phoneVerificationKey(userID)
+
+	attempts, err := checkPhoneCodeScript.Run(ctx, s.redis, []string{key}).Int()
+	if err != nil {
+		return nil, err
+	}
+	if attempts == 0 {
+		return nil, errors.New("no phone verification pending")
+	}
+	if attempts > phoneCodeMaxAttempts {
+		s.redis.Del(ctx, key)
+		return nil, errors.New("too many verification attempts")
+	}
+
+	pending, err := s.redis.HMGet(ctx, key, "phone", "code").Result()
+	if err != nil {
+		return nil, err
+	}
+	phone, _ := pending[0].(string)
+	hash, _ := pending[1].(string)
+	if phone == "" || hash == "" {
+		return nil, errors.New("no phone verification pending")
+	}
+
+	expected := hashPhoneCode(s.secret, userID, phone, strings.TrimSpace(req.Code))
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(hash)) != 1 {
+		return nil, errors.New("invalid verification code")
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	verifiedAt := time.Now()
+	user.Phone = phone
+	user.PhoneVerifiedAt = &verifiedAt
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.redis.Del(ctx, key)
+	return user.ToResponse(), nil
+}
+
+func (s *userService) getUser(ctx context.Context, userID uint) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	return user, nil
+}
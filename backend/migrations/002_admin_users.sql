@@ -0,0 +1,6 @@
+-- Admin flag for managing news sources through the API
+
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE AFTER password;
+
+-- Grant admin access with:
+-- UPDATE users SET is_admin = TRUE WHERE email = 'admin@example.com';
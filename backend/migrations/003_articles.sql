@@ -0,0 +1,25 @@
+-- Articles stored by the ingestion pipeline. article_key is a SHA-256 of the
+-- article URL (or feed GUID) so each story is stored once.
+
+CREATE TABLE IF NOT EXISTS articles (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    article_key VARCHAR(64) NOT NULL,
+    title TEXT NOT NULL,
+    url TEXT NOT NULL,
+    source VARCHAR(255),
+    description TEXT,
+    author VARCHAR(255),
+    category VARCHAR(100),
+    categories JSON,
+    image_url TEXT,
+    guid TEXT,
+    provider VARCHAR(50),
+    published_at TIMESTAMP NULL,
+    published_at_inferred BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_articles_article_key (article_key),
+    INDEX idx_articles_category (category),
+    INDEX idx_articles_published_at (published_at),
+    INDEX idx_articles_created_at (created_at)
+);
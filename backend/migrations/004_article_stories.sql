@@ -0,0 +1,9 @@
+-- Cross-source deduplication: canonical URL and a story key shared by every
+-- copy of the same story
+
+ALTER TABLE articles
+    ADD COLUMN story_key VARCHAR(64) AFTER article_key,
+    ADD COLUMN canonical_url TEXT AFTER url,
+    ADD INDEX idx_articles_story_key (story_key);
+
+UPDATE articles SET story_key = article_key WHERE story_key IS NULL;
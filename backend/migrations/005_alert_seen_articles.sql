@@ -0,0 +1,15 @@
+-- Per-alert seen-set: stories already delivered for an alert are never sent
+-- again. Rows are written in the same transaction as the alert_histories row.
+
+ALTER TABLE alert_histories ADD COLUMN story_key VARCHAR(64) AFTER news_source,
+    ADD INDEX idx_alert_histories_story_key (story_key);
+
+CREATE TABLE IF NOT EXISTS seen_articles (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    alert_id BIGINT UNSIGNED NOT NULL,
+    story_key VARCHAR(64) NOT NULL,
+    alert_history_id BIGINT UNSIGNED,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_seen_articles_alert_story (alert_id, story_key),
+    FOREIGN KEY (alert_id) REFERENCES alerts(id) ON DELETE CASCADE
+);
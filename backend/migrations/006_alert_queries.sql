@@ -0,0 +1,3 @@
+-- Boolean query expression for alerts; keywords are used when it is empty
+
+ALTER TABLE alerts ADD COLUMN query TEXT AFTER keywords;
@@ -0,0 +1,3 @@
+-- Match alert terms by their English stem when enabled
+
+ALTER TABLE alerts ADD COLUMN stemming BOOLEAN NOT NULL DEFAULT FALSE AFTER query;
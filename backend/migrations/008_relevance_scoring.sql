@@ -0,0 +1,8 @@
+-- Relevance scoring: provider relevance per article, a weight per source and
+-- a minimum score per alert
+
+ALTER TABLE articles ADD COLUMN provider_relevance DOUBLE NULL AFTER published_at_inferred;
+
+ALTER TABLE news_sources ADD COLUMN weight DOUBLE NOT NULL DEFAULT 1 AFTER category;
+
+ALTER TABLE alerts ADD COLUMN min_score DOUBLE NOT NULL DEFAULT 0 AFTER stemming;
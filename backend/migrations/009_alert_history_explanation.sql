@@ -0,0 +1,3 @@
+-- Why each delivered article matched: terms per field and the score breakdown
+
+ALTER TABLE alert_histories ADD COLUMN explanation JSON AFTER error_msg;
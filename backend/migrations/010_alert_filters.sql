@@ -0,0 +1,7 @@
+-- Alert filters: excluded keywords, allowed and blocked sources, and the
+-- categories an article must be in
+
+ALTER TABLE alerts ADD COLUMN exclude_keywords JSON AFTER keywords;
+ALTER TABLE alerts ADD COLUMN allowed_sources JSON AFTER exclude_keywords;
+ALTER TABLE alerts ADD COLUMN blocked_sources JSON AFTER allowed_sources;
+ALTER TABLE alerts ADD COLUMN categories JSON AFTER blocked_sources;
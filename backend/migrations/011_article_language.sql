@@ -0,0 +1,9 @@
+-- Language and locale of stored articles, and the languages and locales an
+-- alert is limited to
+
+ALTER TABLE articles ADD COLUMN language VARCHAR(8) AFTER categories;
+ALTER TABLE articles ADD COLUMN locale VARCHAR(8) AFTER language;
+CREATE INDEX idx_articles_language ON articles (language);
+
+ALTER TABLE alerts ADD COLUMN languages JSON AFTER categories;
+ALTER TABLE alerts ADD COLUMN locales JSON AFTER languages;
@@ -0,0 +1,9 @@
+-- Custom alert schedules: a cron expression and time zone per alert, and the
+-- next run computed by the scheduler
+
+ALTER TABLE alerts MODIFY COLUMN frequency ENUM('realtime', 'hourly', 'daily', 'custom') NOT NULL DEFAULT 'daily';
+
+ALTER TABLE alerts ADD COLUMN schedule VARCHAR(100) AFTER frequency;
+ALTER TABLE alerts ADD COLUMN time_zone VARCHAR(64) AFTER schedule;
+ALTER TABLE alerts ADD COLUMN next_run_at TIMESTAMP NULL AFTER last_checked;
+CREATE INDEX idx_alerts_next_run_at ON alerts (next_run_at);
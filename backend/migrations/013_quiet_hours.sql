@@ -0,0 +1,13 @@
+-- Quiet hours: a daily window per user, in their time zone, during which
+-- notifications are held; urgent alerts are sent regardless, and held
+-- notifications are marked in the history until they go out
+
+ALTER TABLE users ADD COLUMN quiet_hours_start VARCHAR(5) AFTER is_admin;
+ALTER TABLE users ADD COLUMN quiet_hours_end VARCHAR(5) AFTER quiet_hours_start;
+ALTER TABLE users ADD COLUMN time_zone VARCHAR(64) AFTER quiet_hours_end;
+
+ALTER TABLE alerts ADD COLUMN urgent BOOLEAN NOT NULL DEFAULT FALSE AFTER next_run_at;
+
+ALTER TABLE alert_histories ADD COLUMN held BOOLEAN NOT NULL DEFAULT FALSE AFTER success;
+ALTER TABLE alert_histories ADD COLUMN held_until TIMESTAMP NULL AFTER held;
+CREATE INDEX idx_alert_histories_held ON alert_histories (held, held_until);
@@ -0,0 +1,6 @@
+-- Persistent scheduler: every active alert now has a next run, including
+-- real-time and hourly ones. Alerts without one are due straight away, and
+-- the scheduler claims due alerts by (active, next_run_at).
+
+UPDATE alerts SET next_run_at = CURRENT_TIMESTAMP WHERE next_run_at IS NULL AND active = TRUE AND deleted_at IS NULL;
+CREATE INDEX idx_alerts_active_next_run_at ON alerts (active, next_run_at);
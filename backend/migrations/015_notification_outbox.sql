@@ -0,0 +1,23 @@
+-- Notification outbox: texts are queued in the same transaction as the
+-- alert history rows they report, then sent by the dispatcher with retries.
+-- Each history row points at its notification and follows its attempts.
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    message TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NULL,
+    last_error TEXT,
+    sent_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_notifications_user_id (user_id),
+    INDEX idx_notifications_status_next_attempt (status, next_attempt_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+ALTER TABLE alert_histories ADD COLUMN notification_id BIGINT UNSIGNED AFTER error_msg,
+    ADD COLUMN attempts INT NOT NULL DEFAULT 0 AFTER notification_id,
+    ADD INDEX idx_alert_histories_notification_id (notification_id);
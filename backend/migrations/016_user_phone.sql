@@ -0,0 +1,5 @@
+-- Phone numbers: each user's E.164 number, set once confirmed with a code
+-- texted to it. Notifications are only sent to verified numbers.
+
+ALTER TABLE users ADD COLUMN phone VARCHAR(16) AFTER time_zone;
+ALTER TABLE users ADD COLUMN phone_verified_at TIMESTAMP NULL AFTER phone;
@@ -0,0 +1,4 @@
+-- SMS gateway message IDs: the SID Twilio returns for each text it accepts,
+-- to look the message up in its logs
+
+ALTER TABLE notifications ADD COLUMN message_sid VARCHAR(64) AFTER last_error;
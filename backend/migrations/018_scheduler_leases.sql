@@ -0,0 +1,10 @@
+-- Scheduler fencing: the highest fencing token a scheduler leader has
+-- written under. Claims and deliveries check their token against it in the
+-- same transaction, so a replica whose lease ran out can't write after the
+-- leader that replaced it.
+
+CREATE TABLE IF NOT EXISTS scheduler_leases (
+    name VARCHAR(64) PRIMARY KEY,
+    token BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
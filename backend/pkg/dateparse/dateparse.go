@@ -0,0 +1,202 @@
+// Package dateparse normalizes the publication dates found in news feeds and
+// APIs, which rarely stick to a single format.
+//
+// Supported inputs:
+//   - RFC 822 / RFC 1123 and common variants: optional weekday, one or two
+//     digit days, two or four digit years, optional seconds, full month names
+//   - numeric offsets (-0700, -07:00) and named zones (GMT, UT, Z, EST, PDT...)
+//   - ISO 8601 / RFC 3339, including dc:date values, with or without a zone
+//   - Unix timestamps in seconds or milliseconds
+//
+// Values without any zone information are interpreted as UTC.
+package dateparse
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrUnrecognized = errors.New("unrecognized date format")
+
+// Offsets for zone abbreviations seen in feeds. Go's time.Parse accepts any
+// abbreviation but silently assigns it a zero offset unless it happens to be
+// the local zone, so they are rewritten to numeric offsets before parsing.
+var zoneOffsets = map[string]string{
+	"UT":   "+0000",
+	"UTC":  "+0000",
+	"GMT":  "+0000",
+	"Z":    "+0000",
+	"EST":  "-0500",
+	"EDT":  "-0400",
+	"CST":  "-0600",
+	"CDT":  "-0500",
+	"MST":  "-0700",
+	"MDT":  "-0600",
+	"PST":  "-0800",
+	"PDT":  "-0700",
+	"AKST": "-0900",
+	"AKDT": "-0800",
+	"HST":  "-1000",
+	"BST":  "+0100",
+	"IST":  "+0530",
+	"CET":  "+0100",
+	"CEST": "+0200",
+	"EET":  "+0200",
+	"EEST": "+0300",
+	"MSK":  "+0300",
+	"JST":  "+0900",
+	"KST":  "+0900",
+	"AEST": "+1000",
+	"AEDT": "+1100",
+	"NZST": "+1200",
+	"NZDT": "+1300",
+}
+
+var weekdays = map[string]bool{
+	"mon": true, "tue": true, "wed": true, "thu": true, "fri": true, "sat": true, "sun": true,
+	"monday": true, "tuesday": true, "wednesday": true, "thursday": true,
+	"friday": true, "saturday": true, "sunday": true,
+	"tues": true, "thur": true, "thurs": true,
+}
+
+var (
+	whitespace     = regexp.MustCompile(`\s+`)
+	trailingNote   = regexp.MustCompile(`\s*\([^)]*\)$`)
+	trailingZone   = regexp.MustCompile(`\s([A-Za-z]{1,5})$`)
+	unixTimestamp  = regexp.MustCompile(`^\d{9,13}(\.\d+)?$`)
+	leadingWeekday = regexp.MustCompile(`^([A-Za-z]+),?\s+`)
+)
+
+// Layouts tried after the weekday has been stripped and named zones have
+// been rewritten to numeric offsets. Order matters: zoned layouts come first.
+var layouts = []string{
+	// RFC 822 / 1123 family
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 -07:00",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04 -0700",
+	"2 January 2006 15:04:05 -0700",
+	"2 January 2006 15:04 -0700",
+	"2-Jan-06 15:04:05 -0700",
+	"2-Jan-2006 15:04:05 -0700",
+	"Jan 2 2006 15:04:05 -0700",
+	"Jan 2, 2006 15:04:05 -0700",
+	"January 2, 2006 15:04:05 -0700",
+	"January 2, 2006 15:04 -0700",
+	"Jan 2 15:04:05 -0700 2006",
+
+	// ISO 8601 family
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05.999999999-0700",
+	"2006-01-02T15:04-07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05-07:00",
+	"2006-01-02 15:04:05 -07:00",
+
+	// No zone information, interpreted as UTC
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04",
+	"2 January 2006 15:04:05",
+	"Jan 2, 2006 15:04:05",
+	"January 2, 2006 15:04",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 Jan 2006",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Parse converts a feed or API date string into a time. It returns
+// ErrUnrecognized when no known format matches.
+func Parse(value string) (time.Time, error) {
+	value = strings.TrimSpace(whitespace.ReplaceAllString(value, " "))
+	if value == "" {
+		return time.Time{}, ErrUnrecognized
+	}
+
+	if unixTimestamp.MatchString(value) {
+		return parseUnix(value)
+	}
+
+	value = normalize(value)
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, ErrUnrecognized
+}
+
+// normalize strips the weekday and comments and rewrites a trailing named
+// zone to a numeric offset
+func normalize(value string) string {
+	value = trailingNote.ReplaceAllString(value, "")
+
+	if match := leadingWeekday.FindStringSubmatch(value); match != nil && weekdays[strings.ToLower(match[1])] {
+		value = value[len(match[0]):]
+	}
+
+	if match := trailingZone.FindStringSubmatch(value); match != nil {
+		if offset, ok := zoneOffsets[strings.ToUpper(match[1])]; ok {
+			value = value[:len(value)-len(match[1])] + offset
+		}
+	}
+
+	// ISO 8601 "Z" suffix directly after the time
+	if strings.HasSuffix(value, "Z") && strings.Contains(value, "T") {
+		value = strings.TrimSuffix(value, "Z") + "+00:00"
+	}
+
+	// GMT+2 / UTC-05:00 style offsets
+	for _, prefix := range []string{" GMT", " UTC", " UT"} {
+		if i := strings.LastIndex(value, prefix); i > 0 {
+			rest := value[i+len(prefix):]
+			if strings.HasPrefix(rest, "+") || strings.HasPrefix(rest, "-") {
+				value = value[:i] + " " + numericOffset(rest)
+			}
+		}
+	}
+
+	return value
+}
+
+// numericOffset turns "+2", "-5", "+0530" or "-05:00" into "+0200" form
+func numericOffset(offset string) string {
+	sign, digits := offset[:1], strings.ReplaceAll(offset[1:], ":", "")
+	switch len(digits) {
+	case 1:
+		digits = "0" + digits + "00"
+	case 2:
+		digits += "00"
+	case 3:
+		digits = "0" + digits
+	}
+	return sign + digits
+}
+
+func parseUnix(value string) (time.Time, error) {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return time.Time{}, ErrUnrecognized
+	}
+
+	// 13-digit values are milliseconds
+	if seconds > 1e11 {
+		seconds /= 1000
+	}
+
+	whole := int64(seconds)
+	nanos := int64((seconds - float64(whole)) * 1e9)
+	return time.Unix(whole, nanos).UTC(), nil
+}
@@ -0,0 +1,86 @@
+package dateparse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	expected := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	expectedNoSeconds := time.Date(2024, 1, 2, 15, 4, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Time
+	}{
+		// RFC 822 / 1123 variants
+		{name: "RFC1123Z", value: "Tue, 02 Jan 2024 15:04:05 +0000", expected: expected},
+		{name: "RFC1123 with GMT", value: "Tue, 02 Jan 2024 15:04:05 GMT", expected: expected},
+		{name: "Single digit day", value: "Tue, 2 Jan 2024 15:04:05 +0000", expected: expected},
+		{name: "No weekday", value: "02 Jan 2024 15:04:05 +0000", expected: expected},
+		{name: "Wrong weekday is ignored", value: "Fri, 02 Jan 2024 15:04:05 +0000", expected: expected},
+		{name: "Two digit year", value: "Tue, 02 Jan 24 15:04:05 +0000", expected: expected},
+		{name: "No seconds", value: "Tue, 02 Jan 2024 15:04 +0000", expected: expectedNoSeconds},
+		{name: "Full month name", value: "Tuesday, 2 January 2024 15:04:05 +0000", expected: expected},
+		{name: "Colon offset", value: "Tue, 02 Jan 2024 10:04:05 -05:00", expected: expected},
+		{name: "Extra whitespace", value: "  Tue,  02 Jan 2024\n15:04:05   +0000 ", expected: expected},
+		{name: "Lowercase month", value: "tue, 02 jan 2024 15:04:05 +0000", expected: expected},
+		{name: "Trailing comment", value: "Tue, 02 Jan 2024 15:04:05 +0000 (UTC)", expected: expected},
+
+		// Named zones
+		{name: "EST", value: "Tue, 02 Jan 2024 10:04:05 EST", expected: expected},
+		{name: "PDT", value: "Tue, 02 Jan 2024 08:04:05 PDT", expected: expected},
+		{name: "UT", value: "Tue, 02 Jan 2024 15:04:05 UT", expected: expected},
+		{name: "Military Z", value: "Tue, 02 Jan 2024 15:04:05 Z", expected: expected},
+		{name: "GMT with offset", value: "Tue, 02 Jan 2024 17:04:05 GMT+2", expected: expected},
+		{name: "IST half hour", value: "Tue, 02 Jan 2024 20:34:05 IST", expected: expected},
+
+		// ISO 8601 and dc:date
+		{name: "RFC3339", value: "2024-01-02T15:04:05Z", expected: expected},
+		{name: "RFC3339 with offset", value: "2024-01-02T10:04:05-05:00", expected: expected},
+		{name: "RFC3339 fractional", value: "2024-01-02T15:04:05.000Z", expected: expected},
+		{name: "ISO compact offset", value: "2024-01-02T17:04:05+0200", expected: expected},
+		{name: "ISO without zone", value: "2024-01-02T15:04:05", expected: expected},
+		{name: "ISO space separated", value: "2024-01-02 15:04:05", expected: expected},
+		{name: "ISO minutes only", value: "2024-01-02T15:04", expected: expectedNoSeconds},
+		{name: "Date only", value: "2024-01-02", expected: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+
+		// Unix timestamps
+		{name: "Unix seconds", value: "1704207845", expected: expected},
+		{name: "Unix milliseconds", value: "1704207845000", expected: expected},
+
+		// Other common forms
+		{name: "US style", value: "January 2, 2024 15:04:05 +0000", expected: expected},
+		{name: "RFC850", value: "Tuesday, 02-Jan-24 15:04:05 GMT", expected: expected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Parse(tt.value)
+			if err != nil {
+				t.Fatalf("Unexpected error for %q: %v", tt.value, err)
+			}
+
+			if !result.Equal(tt.expected) {
+				t.Errorf("Expected %v but got %v for %q", tt.expected, result.UTC(), tt.value)
+			}
+		})
+	}
+}
+
+func TestParse_Unrecognized(t *testing.T) {
+	values := []string{
+		"",
+		"   ",
+		"yesterday",
+		"not a date at all",
+		"32 Foo 2024 25:99:99",
+	}
+
+	for _, value := range values {
+		if result, err := Parse(value); err == nil {
+			t.Errorf("Expected error for %q but got %v", value, result)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package langdetect guesses the language of short news text such as a
+// headline and its lead paragraph, and parses language tags like "en-US".
+package langdetect
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minStopwords is how many common words of a language a text needs before
+// it is reported as written in that language
+const minStopwords = 2
+
+// scripts identify the languages written in a script of their own. Text
+// with Hiragana or Katakana is Japanese even though it also uses Han.
+var scripts = []struct {
+	language string
+	table    *unicode.RangeTable
+}{
+	{"ja", unicode.Hiragana},
+	{"ja", unicode.Katakana},
+	{"ko", unicode.Hangul},
+	{"zh", unicode.Han},
+	{"ru", unicode.Cyrillic},
+	{"ar", unicode.Arabic},
+	{"he", unicode.Hebrew},
+	{"el", unicode.Greek},
+	{"th", unicode.Thai},
+	{"hi", unicode.Devanagari},
+}
+
+// stopwords are the most frequent words of the languages written in the
+// Latin script
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "in", "is", "that", "for", "with", "on", "was", "are", "as", "by", "from", "this", "it", "be", "at", "has", "have", "will", "said", "after", "new", "its", "not", "but"},
+	"es": {"el", "la", "los", "las", "de", "del", "que", "y", "en", "un", "una", "por", "con", "para", "es", "se", "su", "al", "lo", "como", "más", "pero", "sus", "tras", "según"},
+	"fr": {"le", "la", "les", "des", "de", "du", "et", "un", "une", "est", "en", "que", "pour", "dans", "sur", "au", "aux", "avec", "ce", "qui", "pas", "par", "son", "sont", "selon", "après"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "mit", "den", "dem", "von", "zu", "ein", "eine", "im", "auf", "für", "sich", "des", "auch", "es", "wird", "bei", "nach", "wie", "über"},
+	"it": {"il", "lo", "la", "gli", "le", "di", "del", "della", "che", "e", "è", "un", "una", "per", "con", "non", "sono", "nel", "alla", "anche", "più", "da", "dopo", "secondo"},
+	"pt": {"o", "a", "os", "as", "de", "do", "da", "dos", "das", "que", "e", "em", "um", "uma", "para", "com", "não", "no", "na", "por", "mais", "se", "foi", "após", "segundo"},
+	"nl": {"de", "het", "een", "en", "van", "in", "is", "dat", "op", "te", "met", "voor", "niet", "zijn", "die", "er", "aan", "ook", "als", "bij", "door", "naar", "wordt"},
+}
+
+// stopwordLanguages maps each stopword to the languages it is common in
+var stopwordLanguages = func() map[string][]string {
+	index := make(map[string][]string)
+	for language, words := range stopwords {
+		for _, word := range words {
+			index[word] = append(index[word], language)
+		}
+	}
+	return index
+}()
+
+// Detect returns the ISO 639-1 code of the language the text is most likely
+// written in, or "" when it cannot tell. Text in a script used by a single
+// language is recognized by its script; Latin text by its common words.
+func Detect(text string) string {
+	if language := detectScript(text); language != "" {
+		return language
+	}
+	return detectStopwords(text)
+}
+
+// detectScript reports the language of a script making up most of the
+// letters of the text
+func detectScript(text string) string {
+	letters := 0
+	counts := make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if r < 0x0370 {
+			continue // Latin
+		}
+		for _, script := range scripts {
+			if unicode.Is(script.table, r) {
+				counts[script.language]++
+				break
+			}
+		}
+	}
+
+	// Japanese mixes kana with Han, so any kana at all decides it
+	if counts["ja"] > 0 && counts["ja"]+counts["zh"] > letters/2 {
+		return "ja"
+	}
+
+	for _, script := range scripts {
+		if counts[script.language] > letters/2 {
+			return script.language
+		}
+	}
+	return ""
+}
+
+// detectStopwords reports the language with the most common words in the
+// text, when it has enough of them and no other language has as many
+func detectStopwords(text string) string {
+	counts := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		for _, language := range stopwordLanguages[word] {
+			counts[language]++
+		}
+	}
+
+	best, bestCount, tied := "", 0, false
+	for language, count := range counts {
+		switch {
+		case count > bestCount:
+			best, bestCount, tied = language, count, false
+		case count == bestCount:
+			tied = true
+		}
+	}
+
+	if bestCount < minStopwords || tied {
+		return ""
+	}
+	return best
+}
+
+// ParseTag splits a language tag such as "en-US", "pt_BR" or "zh-Hant-TW"
+// into a lower-case language code and region. Either is "" when the tag
+// does not have one.
+func ParseTag(tag string) (language, region string) {
+	parts := strings.FieldsFunc(strings.ToLower(strings.TrimSpace(tag)), func(r rune) bool {
+		return r == '-' || r == '_'
+	})
+	if len(parts) == 0 || !isAlpha(parts[0]) || len(parts[0]) < 2 || len(parts[0]) > 3 {
+		return "", ""
+	}
+
+	language = parts[0]
+	for _, part := range parts[1:] {
+		// Regions are two letters or three digits; scripts are four letters
+		if len(part) == 2 && isAlpha(part) {
+			region = part
+			break
+		}
+	}
+	return language, region
+}
+
+func isAlpha(s string) bool {
+	for _, r := range s {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
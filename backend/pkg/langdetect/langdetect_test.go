@@ -0,0 +1,59 @@
+package langdetect
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"Apple unveils new iPhone as sales slow in China. The company said it will ship the phone in March.", "en"},
+		{"El Gobierno aprueba la reforma de las pensiones tras el acuerdo con los sindicatos", "es"},
+		{"Le président annonce une réforme des retraites pour les fonctionnaires", "fr"},
+		{"Die Regierung will die Steuern für Unternehmen nicht erhöhen, sagt der Minister", "de"},
+		{"Il governo approva la riforma delle pensioni dopo il voto alla Camera", "it"},
+		{"O governo anuncia um novo plano para a economia após a reunião com os bancos", "pt"},
+		{"Het kabinet wil de belasting voor bedrijven niet verhogen, zegt de minister", "nl"},
+		{"東京で新しい電車が運行を開始しました", "ja"},
+		{"北京今天发布了新的经济政策", "zh"},
+		{"서울에서 새로운 정책이 발표되었습니다", "ko"},
+		{"Правительство объявило о новых мерах поддержки экономики", "ru"},
+		{"Αθήνα: νέα μέτρα για την οικονομία", "el"},
+		{"Apple iPhone", ""},
+		{"Tesla Model Y", ""},
+		{"", ""},
+		{"2024 Q3 10-K", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Detect(tt.text); got != tt.expected {
+			t.Errorf("Detect(%q) = %q, expected %q", tt.text, got, tt.expected)
+		}
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		tag      string
+		language string
+		region   string
+	}{
+		{"en", "en", ""},
+		{"en-US", "en", "us"},
+		{"pt_BR", "pt", "br"},
+		{" EN-gb ", "en", "gb"},
+		{"zh-Hant-TW", "zh", "tw"},
+		{"es-419", "es", ""},
+		{"fil", "fil", ""},
+		{"", "", ""},
+		{"english", "", ""},
+		{"1-2", "", ""},
+	}
+
+	for _, tt := range tests {
+		language, region := ParseTag(tt.tag)
+		if language != tt.language || region != tt.region {
+			t.Errorf("ParseTag(%q) = %q, %q, expected %q, %q", tt.tag, language, region, tt.language, tt.region)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Package stemmer reduces English words to their stem with the Porter
+// algorithm, so "recalls", "recalled" and "recalling" all become "recal".
+package stemmer
+
+// Stem returns the Porter stem of a lower-case English word. Words shorter
+// than three letters or containing anything but a-z are returned unchanged.
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	b := []byte(word)
+	b = step1a(b)
+	b = step1b(b)
+	b = step1c(b)
+	b = step2(b)
+	b = step3(b)
+	b = step4(b)
+	b = step5(b)
+	return string(b)
+}
+
+// consonant reports whether b[i] is a consonant. Y is a consonant at the
+// start of a word or after a vowel.
+func consonant(b []byte, i int) bool {
+	switch b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !consonant(b, i-1)
+	}
+	return true
+}
+
+// measure counts the vowel-consonant sequences in b, the m of [C](VC){m}[V]
+func measure(b []byte) int {
+	m, i := 0, 0
+	for i < len(b) && consonant(b, i) {
+		i++
+	}
+	for i < len(b) {
+		for i < len(b) && !consonant(b, i) {
+			i++
+		}
+		if i == len(b) {
+			break
+		}
+		for i < len(b) && consonant(b, i) {
+			i++
+		}
+		m++
+	}
+	return m
+}
+
+func hasVowel(b []byte) bool {
+	for i := range b {
+		if !consonant(b, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func endsDoubleConsonant(b []byte) bool {
+	l := len(b)
+	return l >= 2 && b[l-1] == b[l-2] && consonant(b, l-1)
+}
+
+// endsCVC reports whether b ends consonant-vowel-consonant where the last
+// consonant is not w, x or y, as in "hop" but not "snow"
+func endsCVC(b []byte) bool {
+	l := len(b)
+	if l < 3 || !consonant(b, l-3) || consonant(b, l-2) || !consonant(b, l-1) {
+		return false
+	}
+	switch b[l-1] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+func hasSuffix(b []byte, suffix string) bool {
+	return len(b) >= len(suffix) && string(b[len(b)-len(suffix):]) == suffix
+}
+
+func replaceSuffix(b []byte, suffix, replacement string) []byte {
+	return append(b[:len(b)-len(suffix)], replacement...)
+}
+
+type rule struct {
+	suffix      string
+	replacement string
+}
+
+// applyRules replaces the first matching suffix when the remaining stem has
+// a measure above minMeasure. Only the first match is considered.
+func applyRules(b []byte, rules []rule, minMeasure int) []byte {
+	for _, r := range rules {
+		if !hasSuffix(b, r.suffix) {
+			continue
+		}
+		if measure(b[:len(b)-len(r.suffix)]) > minMeasure {
+			return replaceSuffix(b, r.suffix, r.replacement)
+		}
+		return b
+	}
+	return b
+}
+
+func step1a(b []byte) []byte {
+	switch {
+	case hasSuffix(b, "sses"):
+		return replaceSuffix(b, "sses", "ss")
+	case hasSuffix(b, "ies"):
+		return replaceSuffix(b, "ies", "i")
+	case hasSuffix(b, "ss"):
+		return b
+	case hasSuffix(b, "s"):
+		return b[:len(b)-1]
+	}
+	return b
+}
+
+func step1b(b []byte) []byte {
+	if hasSuffix(b, "eed") {
+		if measure(b[:len(b)-3]) > 0 {
+			return b[:len(b)-1]
+		}
+		return b
+	}
+
+	var stem []byte
+	switch {
+	case hasSuffix(b, "ed") && hasVowel(b[:len(b)-2]):
+		stem = b[:len(b)-2]
+	case hasSuffix(b, "ing") && hasVowel(b[:len(b)-3]):
+		stem = b[:len(b)-3]
+	default:
+		return b
+	}
+
+	switch {
+	case hasSuffix(stem, "at"), hasSuffix(stem, "bl"), hasSuffix(stem, "iz"):
+		return append(stem, 'e')
+	case endsDoubleConsonant(stem):
+		switch stem[len(stem)-1] {
+		case 'l', 's', 'z':
+			return stem
+		}
+		return stem[:len(stem)-1]
+	case measure(stem) == 1 && endsCVC(stem):
+		return append(stem, 'e')
+	}
+	return stem
+}
+
+func step1c(b []byte) []byte {
+	if hasSuffix(b, "y") && hasVowel(b[:len(b)-1]) {
+		b[len(b)-1] = 'i'
+	}
+	return b
+}
+
+var step2Rules = []rule{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"bli", "ble"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	{"logi", "log"},
+}
+
+func step2(b []byte) []byte {
+	return applyRules(b, step2Rules, 0)
+}
+
+var step3Rules = []rule{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+func step3(b []byte) []byte {
+	return applyRules(b, step3Rules, 0)
+}
+
+var step4Suffixes = []string{
+	"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment",
+	"ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+}
+
+func step4(b []byte) []byte {
+	for _, suffix := range step4Suffixes {
+		if !hasSuffix(b, suffix) {
+			continue
+		}
+
+		stem := b[:len(b)-len(suffix)]
+		if measure(stem) <= 1 {
+			return b
+		}
+		if suffix == "ion" && !hasSuffix(stem, "s") && !hasSuffix(stem, "t") {
+			return b
+		}
+		return stem
+	}
+	return b
+}
+
+func step5(b []byte) []byte {
+	if hasSuffix(b, "e") {
+		stem := b[:len(b)-1]
+		if m := measure(stem); m > 1 || (m == 1 && !endsCVC(stem)) {
+			b = stem
+		}
+	}
+
+	if measure(b) > 1 && endsDoubleConsonant(b) && hasSuffix(b, "l") {
+		b = b[:len(b)-1]
+	}
+	return b
+}
@@ -0,0 +1,51 @@
+package stemmer
+
+import "testing"
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word     string
+		expected string
+	}{
+		{"caresses", "caress"},
+		{"ponies", "poni"},
+		{"cats", "cat"},
+		{"feed", "feed"},
+		{"agreed", "agre"},
+		{"plastered", "plaster"},
+		{"motoring", "motor"},
+		{"sing", "sing"},
+		{"conflated", "conflat"},
+		{"hopping", "hop"},
+		{"falling", "fall"},
+		{"filing", "file"},
+		{"happy", "happi"},
+		{"relational", "relat"},
+		{"conditional", "condit"},
+		{"digitizer", "digit"},
+		{"generalization", "gener"},
+		{"hopefulness", "hope"},
+		{"electrical", "electr"},
+		{"adjustment", "adjust"},
+		{"adoption", "adopt"},
+		{"controlling", "control"},
+		{"rating", "rate"},
+		{"rates", "rate"},
+		{"recalls", "recal"},
+		{"recalled", "recal"},
+		{"recalling", "recal"},
+		{"earnings", "earn"},
+		{"ai", "ai"},
+		{"iphone", "iphon"},
+		{"café", "café"},
+		{"Apple", "Apple"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := Stem(tt.word); got != tt.expected {
+				t.Errorf("Expected %q but got %q", tt.expected, got)
+			}
+		})
+	}
+}